@@ -11,9 +11,7 @@ import (
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	"k8s.io/client-go/rest"
 
-	appv1alpha1 "github.com/xunholy/k8s-istio-gateway-service-operator/pkg/apis/crd/v1alpha1"
-	k8sruntime "k8s.io/apimachinery/pkg/runtime"
-
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/index"
 	"github.com/xunholy/k8s-istio-gateway-service-operator/pkg/apis"
 	"github.com/xunholy/k8s-istio-gateway-service-operator/pkg/controller"
 
@@ -70,6 +68,8 @@ func main() {
 
 	printVersion()
 
+	// An empty watch namespace watches every namespace, as GatewayServices, Gateways and the secrets served by the
+	// gateway pods are spread across namespaces and the operator config and namespaces are cluster-scoped.
 	namespace, err := k8sutil.GetWatchNamespace()
 	if err != nil {
 		log.Error(err, "Failed to get watch namespace")
@@ -110,6 +110,13 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Setup cache for FieldSelector
+	// The indexes must be registered before the cache is started to allow FieldSelectors with local cache work as expected.
+	if err := index.Register(mgr.GetCache()); err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+
 	// Setup all Controllers
 	if err := controller.AddToManager(mgr); err != nil {
 		log.Error(err, "")
@@ -120,19 +127,6 @@ func main() {
 		log.Info("Could not generate and serve custom resource metrics", "error", err.Error())
 	}
 
-	// Setup cache for FieldSelector
-	// The indexer function must be defined to allow FieldSelectors with local cache work as expected.
-	cache := mgr.GetCache()
-
-	indexFunc := func(obj k8sruntime.Object) []string {
-		return []string{obj.(*appv1alpha1.GatewayService).Spec.TrafficType}
-	}
-
-	err = cache.IndexField(&appv1alpha1.GatewayService{}, "spec.trafficType", indexFunc)
-	if err != nil {
-		log.Info("Could not setup index on local cache", "error", err.Error())
-	}
-
 	// Add to the below struct any other metrics ports you want to expose.
 	servicePorts := []v1.ServicePort{
 		{Port: metricsPort, Name: metrics.OperatorPortName, Protocol: v1.ProtocolTCP, TargetPort: intstr.IntOrString{Type: intstr.Int, IntVal: metricsPort}},
@@ -147,7 +141,12 @@ func main() {
 	// CreateServiceMonitors will automatically create the prometheus-operator ServiceMonitor resources
 	// necessary to configure Prometheus to scrape metrics from this operator.
 	services := []*v1.Service{service}
-	_, err = metrics.CreateServiceMonitors(cfg, namespace, services)
+	operatorNs, err := k8sutil.GetOperatorNamespace()
+	if err != nil {
+		log.Error(err, "Failed to get operator namespace")
+		os.Exit(1)
+	}
+	_, err = metrics.CreateServiceMonitors(cfg, operatorNs, services)
 	if err != nil {
 		log.Info("Could not create ServiceMonitor object", "error", err.Error())
 		// If this operator is deployed to a cluster without the prometheus-operator running, it will return
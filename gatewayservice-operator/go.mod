@@ -3,7 +3,9 @@ module github.com/xunholy/k8s-istio-gateway-service-operator
 require (
 	github.com/go-openapi/spec v0.19.0
 	github.com/operator-framework/operator-sdk v0.10.1-0.20190912205659-c084b570a6af
+	github.com/prometheus/client_golang v0.9.3-0.20190127221311-3c4408c8b829
 	github.com/spf13/pflag v1.0.3
+	golang.org/x/net v0.0.0-20190923162816-aa69164e4478
 	istio.io/api v0.0.0-20191029012234-9fe6a7da3673
 	istio.io/client-go v0.0.0-20191024204624-13a7366c1cab
 	k8s.io/api v0.0.0-20190612125737-db0771252981
@@ -0,0 +1,99 @@
+package certificate
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultThresholds are used to raise expiry warnings when no thresholds have been configured: 30, 7 and 1 day(s)
+// prior to the certificate expiring.
+var DefaultThresholds = []time.Duration{720 * time.Hour, 168 * time.Hour, 24 * time.Hour}
+
+// Parse decodes the first certificate within data. Certificates may be PEM encoded, or base64 encoded PEM as is the
+// case for certificates supplied through TLSSecret.
+func Parse(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil {
+			return nil, fmt.Errorf("certificate is not PEM or base64 encoded")
+		}
+		block, _ = pem.Decode(decoded)
+		if block == nil {
+			return nil, fmt.Errorf("certificate does not contain a PEM block")
+		}
+	}
+	if block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("PEM block of type %s is not a certificate", block.Type)
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// ParseThresholds parses a comma separated list of durations such as "720h,168h,24h". The thresholds are returned
+// sorted from the largest to the smallest.
+func ParseThresholds(s string) ([]time.Duration, error) {
+	thresholds := []time.Duration{}
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate expiry threshold %q: %v", v, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("certificate expiry threshold %q must be positive", v)
+		}
+		thresholds = append(thresholds, d)
+	}
+	sort.Slice(thresholds, func(i, j int) bool { return thresholds[i] > thresholds[j] })
+	return thresholds, nil
+}
+
+// Expiry describes how close a certificate is to expiring relative to the configured thresholds.
+type Expiry struct {
+	NotAfter time.Time
+
+	// Expiring is true once the remaining validity falls within any threshold, or the certificate has expired.
+	Expiring bool
+
+	// Expired is true once the certificate is no longer valid.
+	Expired bool
+
+	// Threshold is the smallest threshold the remaining validity falls within.
+	Threshold time.Duration
+
+	// Next is the duration until the next threshold, or the expiry itself, is crossed. Zero once expired.
+	Next time.Duration
+}
+
+// Evaluate compares the remaining validity of a certificate against the thresholds.
+func Evaluate(notAfter time.Time, now time.Time, thresholds []time.Duration) Expiry {
+	e := Expiry{NotAfter: notAfter}
+	remaining := notAfter.Sub(now)
+	if remaining <= 0 {
+		e.Expiring = true
+		e.Expired = true
+		return e
+	}
+	e.Next = remaining
+	for _, t := range thresholds {
+		if remaining <= t {
+			if !e.Expiring || t < e.Threshold {
+				e.Threshold = t
+			}
+			e.Expiring = true
+			continue
+		}
+		if d := remaining - t; d < e.Next {
+			e.Next = d
+		}
+	}
+	return e
+}
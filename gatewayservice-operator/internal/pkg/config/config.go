@@ -0,0 +1,523 @@
+package config
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/certificate"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/gateway"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/host"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/vault"
+	appv1alpha1 "github.com/xunholy/k8s-istio-gateway-service-operator/pkg/apis/crd/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// Name is the name of the GatewayServiceOperatorConfig applied by the operator.
+	Name = "gatewayservice-operator"
+
+	// DefaultSecretNamespace is the namespace the gateway pods are assumed to run within when they are neither
+	// configured nor discovered.
+	DefaultSecretNamespace = "istio-system"
+
+	// defaultGatewaySelectors select the gateway pods of the Gateways created for ingress and egress.
+	defaultGatewaySelectors = "ingress:istio=ingressgateway,egress:istio=egressgateway"
+	// defaultRolloutMaxUnavailable is the share of the gateway pods which may be unavailable while rolling.
+	defaultRolloutMaxUnavailable = "25%"
+	// defaultRolloutGracePeriod is the time a new gateway pod must be ready before the next batch is rolled.
+	defaultRolloutGracePeriod = 30 * time.Second
+)
+
+var (
+	// Modes which may be allowed, matching the enum of the GatewayService mode.
+	Modes = []string{"SIMPLE", "PASSTHROUGH", "MUTUAL", "OPTIONAL_MUTUAL", "ISTIO_MUTUAL", "AUTO_PASSTHROUGH"}
+	// Protocols which may be allowed, matching the enum of the GatewayService protocol.
+	Protocols = []string{"HTTP", "HTTPS", "GRPC", "HTTP2", "MONGO", "TCP", "TLS"}
+
+	// mu guards applied, the last valid configuration.
+	mu      sync.Mutex
+	applied *Config
+)
+
+// Config holds the settings of the operator, configured by the GatewayServiceOperatorConfig.
+type Config struct {
+	Domain              string
+	Cluster             string
+	GatewayNameTemplate string
+	SecretNamespace     string
+	DefaultServer       gateway.DefaultServer
+	AllowedModes        []string
+	AllowedProtocols    []string
+	HostNamespaces      []string
+	ResyncPeriod        time.Duration
+	TrafficTypes        []string
+	TrafficClasses      map[string]TrafficClass
+	Vault               vault.Config
+
+	CreateGateways              bool
+	GatewaySelectors            map[string]map[string]string
+	GatewayNamespaces           map[string][]string
+	RolloutMaxUnavailable       intstr.IntOrString
+	RolloutGracePeriod          time.Duration
+	CertificateExpiryThresholds []time.Duration
+}
+
+// TrafficClass holds the settings of the Gateways serving a trafficType. Settings which are empty fall back to those of
+// the Config.
+type TrafficClass struct {
+	GatewayNameTemplate string
+	Selector            map[string]string
+	SecretNamespace     string
+	GatewayNamespaces   []string
+}
+
+// Defaults returns the configuration applied without a GatewayServiceOperatorConfig, read from the DOMAIN, CLUSTER,
+// RESYNC_PERIOD, GATEWAY_CREATE, GATEWAY_SELECTORS, GATEWAY_NAMESPACES, ROLLOUT_MAX_UNAVAILABLE, ROLLOUT_GRACE_PERIOD
+// and CERTIFICATE_EXPIRY_THRESHOLDS environment variables. Invalid variables fall back to their defaults.
+func Defaults() Config {
+	resyncPeriod, err := time.ParseDuration(getEnv("RESYNC_PERIOD", "1h"))
+	if err != nil || resyncPeriod <= 0 {
+		resyncPeriod = time.Hour
+	}
+	createGateways, _ := strconv.ParseBool(getEnv("GATEWAY_CREATE", "false"))
+	selectors, err := gateway.ParseSelectors(getEnv("GATEWAY_SELECTORS", defaultGatewaySelectors))
+	if err != nil {
+		selectors, _ = gateway.ParseSelectors(defaultGatewaySelectors)
+	}
+	namespaces, err := gateway.ParseNamespaces(getEnv("GATEWAY_NAMESPACES", ""))
+	if err != nil {
+		namespaces = map[string][]string{}
+	}
+	maxUnavailable, err := parseMaxUnavailable(getEnv("ROLLOUT_MAX_UNAVAILABLE", defaultRolloutMaxUnavailable))
+	if err != nil {
+		maxUnavailable = intstr.FromString(defaultRolloutMaxUnavailable)
+	}
+	gracePeriod, err := time.ParseDuration(getEnv("ROLLOUT_GRACE_PERIOD", defaultRolloutGracePeriod.String()))
+	if err != nil || gracePeriod < 0 {
+		gracePeriod = defaultRolloutGracePeriod
+	}
+	thresholds, err := certificate.ParseThresholds(getEnv("CERTIFICATE_EXPIRY_THRESHOLDS", "720h,168h,24h"))
+	if err != nil {
+		thresholds = certificate.DefaultThresholds
+	}
+	return Config{
+		Domain:                      getEnv("DOMAIN", "example.com"),
+		Cluster:                     getEnv("CLUSTER", ""),
+		GatewayNameTemplate:         gateway.DefaultNameTemplate,
+		SecretNamespace:             DefaultSecretNamespace,
+		ResyncPeriod:                resyncPeriod,
+		TrafficTypes:                []string{"ingress", "egress"},
+		CreateGateways:              createGateways,
+		GatewaySelectors:            selectors,
+		GatewayNamespaces:           namespaces,
+		RolloutMaxUnavailable:       maxUnavailable,
+		RolloutGracePeriod:          gracePeriod,
+		CertificateExpiryThresholds: thresholds,
+	}
+}
+
+// Parse validates the spec of a GatewayServiceOperatorConfig, returning the configuration with the settings omitted
+// by the spec taken from the defaults.
+func Parse(spec appv1alpha1.GatewayServiceOperatorConfigSpec, defaults Config) (Config, error) {
+	c := defaults
+	if spec.Domain != "" {
+		if errs := validation.IsDNS1123Subdomain(spec.Domain); len(errs) > 0 {
+			return defaults, fmt.Errorf("domain %q is invalid: %s", spec.Domain, strings.Join(errs, ", "))
+		}
+		c.Domain = spec.Domain
+	}
+	if spec.Cluster != "" {
+		if errs := validation.IsDNS1123Label(spec.Cluster); len(errs) > 0 {
+			return defaults, fmt.Errorf("cluster %q is invalid: %s", spec.Cluster, strings.Join(errs, ", "))
+		}
+		c.Cluster = spec.Cluster
+	}
+	if len(spec.TrafficClasses) > 0 {
+		c.TrafficTypes = []string{}
+		c.TrafficClasses = map[string]TrafficClass{}
+		for _, class := range spec.TrafficClasses {
+			err := validateTrafficClass(class)
+			if err != nil {
+				return defaults, err
+			}
+			if _, ok := c.TrafficClasses[class.Name]; ok {
+				return defaults, fmt.Errorf("trafficClasses %s is declared more than once", class.Name)
+			}
+			c.TrafficTypes = append(c.TrafficTypes, class.Name)
+			c.TrafficClasses[class.Name] = TrafficClass{
+				GatewayNameTemplate: class.GatewayNameTemplate,
+				Selector:            class.Selector,
+				SecretNamespace:     class.SecretNamespace,
+				GatewayNamespaces:   class.GatewayNamespaces,
+			}
+		}
+	}
+	if spec.GatewayNameTemplate != "" {
+		c.GatewayNameTemplate = spec.GatewayNameTemplate
+	}
+	if spec.GatewayNameTemplate != "" || len(spec.TrafficClasses) > 0 {
+		err := c.validateNameTemplate()
+		if err != nil {
+			return defaults, err
+		}
+	}
+	if spec.SecretNamespace != "" {
+		if errs := validation.IsDNS1123Label(spec.SecretNamespace); len(errs) > 0 {
+			return defaults, fmt.Errorf("secretNamespace %q is invalid: %s", spec.SecretNamespace, strings.Join(errs, ", "))
+		}
+		c.SecretNamespace = spec.SecretNamespace
+	}
+	if spec.DefaultServer != nil {
+		c.DefaultServer = gateway.DefaultServer{
+			Disabled:       spec.DefaultServer.Disabled,
+			Port:           spec.DefaultServer.Port,
+			Protocol:       spec.DefaultServer.Protocol,
+			HostTemplate:   spec.DefaultServer.Host,
+			CredentialName: spec.DefaultServer.CredentialName,
+		}
+		err := c.validateDefaultServer()
+		if err != nil {
+			return defaults, err
+		}
+	}
+	for _, mode := range spec.AllowedModes {
+		if !contains(Modes, mode) {
+			return defaults, fmt.Errorf("allowedModes %s is invalid, options are %s", mode, strings.Join(Modes, ", "))
+		}
+	}
+	c.AllowedModes = spec.AllowedModes
+	for _, protocol := range spec.AllowedProtocols {
+		if !contains(Protocols, protocol) {
+			return defaults, fmt.Errorf("allowedProtocols %s is invalid, options are %s", protocol, strings.Join(Protocols, ", "))
+		}
+	}
+	c.AllowedProtocols = spec.AllowedProtocols
+	for _, namespace := range spec.AllowedHostNamespaces {
+		if errs := validation.IsDNS1123Label(namespace); namespace != "*" && len(errs) > 0 {
+			return defaults, fmt.Errorf("allowedHostNamespaces %q is invalid: %s", namespace, strings.Join(errs, ", "))
+		}
+	}
+	c.HostNamespaces = spec.AllowedHostNamespaces
+	if spec.Vault != nil {
+		v, err := parseVault(*spec.Vault)
+		if err != nil {
+			return defaults, err
+		}
+		c.Vault = v
+	}
+	if spec.ResyncPeriod != "" {
+		d, err := time.ParseDuration(spec.ResyncPeriod)
+		if err != nil || d <= 0 {
+			return defaults, fmt.Errorf("resyncPeriod %q must be a positive duration, EG. 1h", spec.ResyncPeriod)
+		}
+		c.ResyncPeriod = d
+	}
+	if spec.CreateGateways != nil {
+		c.CreateGateways = *spec.CreateGateways
+	}
+	if spec.Rollout != nil && spec.Rollout.MaxUnavailable != "" {
+		maxUnavailable, err := parseMaxUnavailable(spec.Rollout.MaxUnavailable)
+		if err != nil {
+			return defaults, fmt.Errorf("rollout maxUnavailable %q must be a number or percentage, EG. 25%%", spec.Rollout.MaxUnavailable)
+		}
+		c.RolloutMaxUnavailable = maxUnavailable
+	}
+	if spec.Rollout != nil && spec.Rollout.GracePeriod != "" {
+		d, err := time.ParseDuration(spec.Rollout.GracePeriod)
+		if err != nil || d < 0 {
+			return defaults, fmt.Errorf("rollout gracePeriod %q must be a duration, EG. 30s", spec.Rollout.GracePeriod)
+		}
+		c.RolloutGracePeriod = d
+	}
+	if len(spec.CertificateExpiryThresholds) > 0 {
+		thresholds, err := certificate.ParseThresholds(strings.Join(spec.CertificateExpiryThresholds, ","))
+		if err != nil {
+			return defaults, fmt.Errorf("certificateExpiryThresholds: %v", err)
+		}
+		c.CertificateExpiryThresholds = thresholds
+	}
+	return c, nil
+}
+
+// parseMaxUnavailable parses the number or percentage of gateway pods which may be unavailable while rolling.
+func parseMaxUnavailable(s string) (intstr.IntOrString, error) {
+	v := intstr.Parse(s)
+	value, err := intstr.GetValueFromIntOrPercent(&v, 100, true)
+	if err != nil || value < 0 {
+		return v, fmt.Errorf("invalid value %q", s)
+	}
+	return v, nil
+}
+
+// validateTrafficClass reports an error when the settings of the traffic class are invalid. The gatewayNameTemplate is
+// validated along with those of every other trafficType.
+func validateTrafficClass(class appv1alpha1.TrafficClass) error {
+	if errs := validation.IsDNS1123Label(class.Name); len(errs) > 0 {
+		return fmt.Errorf("trafficClasses name %q is invalid: %s", class.Name, strings.Join(errs, ", "))
+	}
+	for k, v := range class.Selector {
+		errs := append(validation.IsQualifiedName(k), validation.IsValidLabelValue(v)...)
+		if len(errs) > 0 {
+			return fmt.Errorf("trafficClasses %s selector %s=%s is invalid: %s", class.Name, k, v, strings.Join(errs, ", "))
+		}
+	}
+	if errs := validation.IsDNS1123Label(class.SecretNamespace); class.SecretNamespace != "" && len(errs) > 0 {
+		return fmt.Errorf("trafficClasses %s secretNamespace %q is invalid: %s", class.Name, class.SecretNamespace, strings.Join(errs, ", "))
+	}
+	for _, namespace := range class.GatewayNamespaces {
+		if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+			return fmt.Errorf("trafficClasses %s gatewayNamespaces %q is invalid: %s", class.Name, namespace, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
+
+// parseVault validates the Vault server and the paths and roles each namespace may use.
+func parseVault(spec appv1alpha1.VaultConfig) (vault.Config, error) {
+	u, err := url.Parse(spec.Address)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return vault.Config{}, fmt.Errorf("vault address %q must be an https URL, EG. https://vault.example.com:8200", spec.Address)
+	}
+	if spec.CACert != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(spec.CACert)) {
+		return vault.Config{}, fmt.Errorf("vault caCert does not contain a PEM encoded certificate")
+	}
+	if spec.Auth.Role == "" {
+		return vault.Config{}, fmt.Errorf("vault auth role cannot be empty")
+	}
+	c := vault.Config{
+		Address:       spec.Address,
+		CACert:        spec.CACert,
+		TLSServerName: spec.TLSServerName,
+		Role:          spec.Auth.Role,
+		AuthMountPath: spec.Auth.MountPath,
+		Namespaces:    map[string]vault.Policy{},
+	}
+	for _, namespace := range spec.Namespaces {
+		if errs := validation.IsDNS1123Label(namespace.Name); len(errs) > 0 {
+			return vault.Config{}, fmt.Errorf("vault namespaces name %q is invalid: %s", namespace.Name, strings.Join(errs, ", "))
+		}
+		for _, prefix := range namespace.KVPathPrefixes {
+			if prefix == "" || prefix != path.Clean(prefix) || strings.HasPrefix(prefix, "/") || strings.HasPrefix(prefix, "..") {
+				return vault.Config{}, fmt.Errorf("vault namespaces %s kvPathPrefixes %q must be a relative path in its shortest form", namespace.Name, prefix)
+			}
+		}
+		for _, role := range namespace.PKIRoles {
+			if i := strings.LastIndex(role, "/"); i <= 0 || i == len(role)-1 {
+				return vault.Config{}, fmt.Errorf("vault namespaces %s pkiRoles %q must be of the form mountPath/role", namespace.Name, role)
+			}
+		}
+		policy := c.Namespaces[namespace.Name]
+		policy.KVPathPrefixes = append(policy.KVPathPrefixes, namespace.KVPathPrefixes...)
+		policy.PKIRoles = append(policy.PKIRoles, namespace.PKIRoles...)
+		c.Namespaces[namespace.Name] = policy
+	}
+	return c, nil
+}
+
+// validateNameTemplate reports an error when the templates do not render a valid and distinct Gateway name for every
+// trafficType.
+func (c Config) validateNameTemplate() error {
+	names := map[string]string{}
+	for _, trafficType := range c.TrafficTypes {
+		template := c.nameTemplate(trafficType)
+		name, err := gateway.NameFromTemplate(template, "namespace", trafficType)
+		if err != nil {
+			return fmt.Errorf("gatewayNameTemplate %q is invalid: %v", template, err)
+		}
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return fmt.Errorf("gatewayNameTemplate %q renders the invalid name %q: %s", template, name, strings.Join(errs, ", "))
+		}
+		if other, ok := names[name]; ok {
+			return fmt.Errorf("gatewayNameTemplate %q renders the same name for trafficTypes %s and %s", template, other, trafficType)
+		}
+		names[name] = trafficType
+	}
+	return nil
+}
+
+// validateDefaultServer reports an error when the default server would be rejected by Istio.
+func (c Config) validateDefaultServer() error {
+	d := c.DefaultServer
+	if d.Port > 65535 {
+		return fmt.Errorf("defaultServer port %d must be between 1 and 65535", d.Port)
+	}
+	if d.Protocol != "" && !contains(Protocols, d.Protocol) {
+		return fmt.Errorf("defaultServer protocol %s is invalid, options are %s", d.Protocol, strings.Join(Protocols, ", "))
+	}
+	for _, trafficType := range c.TrafficTypes {
+		rendered, err := gateway.DefaultHost(d.HostTemplate, c.HostValues("namespace", trafficType, nil))
+		if err != nil {
+			return fmt.Errorf("defaultServer host %q is invalid: %v", d.HostTemplate, err)
+		}
+		if _, err := host.Parse(rendered); err != nil || strings.Contains(rendered, "/") {
+			return fmt.Errorf("defaultServer host %q renders the invalid host %q", d.HostTemplate, rendered)
+		}
+	}
+	tls := d.Protocol == "HTTPS" || d.Protocol == "TLS"
+	if tls && d.CredentialName == "" {
+		return fmt.Errorf("defaultServer credentialName is required for protocol %s", d.Protocol)
+	}
+	if !tls && d.CredentialName != "" {
+		return fmt.Errorf("defaultServer credentialName requires protocol HTTPS or TLS")
+	}
+	if errs := validation.IsDNS1123Subdomain(d.CredentialName); d.CredentialName != "" && len(errs) > 0 {
+		return fmt.Errorf("defaultServer credentialName %q is invalid: %s", d.CredentialName, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// Load returns the configuration applied by the operator. The GatewayServiceOperatorConfig named Name is read from the
+// cache of the client, and the last valid configuration remains applied while it is invalid or cannot be read.
+func Load(c client.Client) Config {
+	obj := &appv1alpha1.GatewayServiceOperatorConfig{}
+	err := c.Get(context.TODO(), types.NamespacedName{Name: Name}, obj)
+	mu.Lock()
+	defer mu.Unlock()
+	switch {
+	case errors.IsNotFound(err):
+		defaults := Defaults()
+		applied = &defaults
+	case err == nil:
+		if parsed, err := Parse(obj.Spec, Defaults()); err == nil {
+			applied = &parsed
+		}
+	}
+	if applied == nil {
+		return Defaults()
+	}
+	return *applied
+}
+
+// GatewayName returns the name of the Gateway serving GatewayServices of the trafficType within the namespace. The
+// default name is returned if the template cannot be rendered.
+func (c Config) GatewayName(namespace string, trafficType string) string {
+	name, err := gateway.NameFromTemplate(c.nameTemplate(trafficType), namespace, trafficType)
+	if err != nil || name == "" {
+		return gateway.Name(namespace, trafficType)
+	}
+	return name
+}
+
+// TrafficType returns the trafficType served by the Gateway named within the namespace, false when the Gateway does not
+// serve any trafficType.
+func (c Config) TrafficType(namespace string, name string) (string, bool) {
+	for _, trafficType := range c.TrafficTypes {
+		if c.GatewayName(namespace, trafficType) == name {
+			return trafficType, true
+		}
+	}
+	return "", false
+}
+
+// nameTemplate returns the template of the names of the Gateways serving the trafficType.
+func (c Config) nameTemplate(trafficType string) string {
+	if t := c.TrafficClasses[trafficType].GatewayNameTemplate; t != "" {
+		return t
+	}
+	return c.GatewayNameTemplate
+}
+
+// Selectors returns the selector of the gateway pods of the Gateways created for each trafficType, those declared by
+// the traffic classes taking precedence over GATEWAY_SELECTORS.
+func (c Config) Selectors() map[string]map[string]string {
+	selectors := map[string]map[string]string{}
+	for trafficType, selector := range c.GatewaySelectors {
+		selectors[trafficType] = selector
+	}
+	for trafficType, class := range c.TrafficClasses {
+		if len(class.Selector) > 0 {
+			selectors[trafficType] = class.Selector
+		}
+	}
+	return selectors
+}
+
+// TrafficSecretNamespace returns the namespace holding the secrets of the trafficType when the namespaces of the
+// gateway pods are neither configured nor discovered.
+func (c Config) TrafficSecretNamespace(trafficType string) string {
+	if namespace := c.TrafficClasses[trafficType].SecretNamespace; namespace != "" {
+		return namespace
+	}
+	return c.SecretNamespace
+}
+
+// Namespaces returns the configured namespaces of the gateway pods serving the trafficType, those declared by the
+// traffic class taking precedence over GATEWAY_NAMESPACES. The namespaces are discovered when none are configured.
+func (c Config) Namespaces(trafficType string) []string {
+	if namespaces := c.TrafficClasses[trafficType].GatewayNamespaces; len(namespaces) > 0 {
+		return namespaces
+	}
+	return c.GatewayNamespaces[trafficType]
+}
+
+// HostValues returns the values host templates of the trafficType within the namespace are rendered with, given the
+// labels of the namespace.
+func (c Config) HostValues(namespace string, trafficType string, labels map[string]string) gateway.HostValues {
+	return gateway.HostValues{
+		Namespace:   namespace,
+		TrafficType: trafficType,
+		Domain:      c.Domain,
+		Cluster:     c.Cluster,
+		Labels:      labels,
+	}
+}
+
+// LoadHostValues returns the values host templates of the trafficType within the namespace are rendered with, reading
+// the labels of the namespace from the cache of the client. A namespace which cannot be found has no labels.
+func (c Config) LoadHostValues(cl client.Client, namespace string, trafficType string) (gateway.HostValues, error) {
+	ns := &corev1.Namespace{}
+	err := cl.Get(context.TODO(), types.NamespacedName{Name: namespace}, ns)
+	if err != nil && !errors.IsNotFound(err) {
+		return gateway.HostValues{}, err
+	}
+	return c.HostValues(namespace, trafficType, ns.Labels), nil
+}
+
+// HostPolicy returns the policy deciding which namespaces the hosts of GatewayServices may name.
+func (c Config) HostPolicy() host.Policy {
+	return host.Policy{Namespaces: c.HostNamespaces}
+}
+
+// Allows reports an error when the trafficType of the GatewayService is not declared, or its mode or protocol is not
+// allowed.
+func (c Config) Allows(spec appv1alpha1.GatewayServiceSpec) error {
+	if !contains(c.TrafficTypes, spec.TrafficType) {
+		return fmt.Errorf("trafficType %q is not declared by the operator config, trafficTypes are %s", spec.TrafficType, strings.Join(c.TrafficTypes, ", "))
+	}
+	if len(c.AllowedModes) > 0 && !contains(c.AllowedModes, spec.Mode) {
+		return fmt.Errorf("mode %s is not allowed by the operator config, allowed modes are %s", spec.Mode, strings.Join(c.AllowedModes, ", "))
+	}
+	if len(c.AllowedProtocols) > 0 && !contains(c.AllowedProtocols, spec.Protocol) {
+		return fmt.Errorf("protocol %s is not allowed by the operator config, allowed protocols are %s", spec.Protocol, strings.Join(c.AllowedProtocols, ", "))
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func getEnv(k string, d string) string {
+	if v, e := os.LookupEnv(k); e {
+		return v
+	}
+	return d
+}
@@ -0,0 +1,260 @@
+package config_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/config"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/gateway"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/vault"
+	appv1alpha1 "github.com/xunholy/k8s-istio-gateway-service-operator/pkg/apis/crd/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestParse(t *testing.T) {
+	defaults := config.Defaults()
+	createGateways := true
+	spec := appv1alpha1.GatewayServiceOperatorConfigSpec{
+		Domain:              "example.org",
+		Cluster:             "east",
+		GatewayNameTemplate: "{{.Namespace}}-{{.TrafficType}}",
+		SecretNamespace:     "istio-ingress",
+		DefaultServer: &appv1alpha1.DefaultServer{
+			Port:           443,
+			Protocol:       "HTTPS",
+			Host:           "{{.TrafficType}}.{{.Cluster}}.{{.Domain}}",
+			CredentialName: "default-credential",
+		},
+		AllowedModes:                []string{"SIMPLE"},
+		AllowedProtocols:            []string{"HTTPS"},
+		AllowedHostNamespaces:       []string{"shared"},
+		ResyncPeriod:                "10m",
+		CreateGateways:              &createGateways,
+		Rollout:                     &appv1alpha1.RolloutConfig{MaxUnavailable: "1", GracePeriod: "1m"},
+		CertificateExpiryThresholds: []string{"336h", "48h"},
+	}
+	expected := config.Config{
+		Domain:              "example.org",
+		Cluster:             "east",
+		GatewayNameTemplate: "{{.Namespace}}-{{.TrafficType}}",
+		SecretNamespace:     "istio-ingress",
+		DefaultServer: gateway.DefaultServer{
+			Port:           443,
+			Protocol:       "HTTPS",
+			HostTemplate:   "{{.TrafficType}}.{{.Cluster}}.{{.Domain}}",
+			CredentialName: "default-credential",
+		},
+		AllowedModes:     []string{"SIMPLE"},
+		AllowedProtocols: []string{"HTTPS"},
+		HostNamespaces:   []string{"shared"},
+		ResyncPeriod:     10 * time.Minute,
+		TrafficTypes:     defaults.TrafficTypes,
+
+		CreateGateways:              true,
+		GatewaySelectors:            defaults.GatewaySelectors,
+		GatewayNamespaces:           defaults.GatewayNamespaces,
+		RolloutMaxUnavailable:       intstr.FromInt(1),
+		RolloutGracePeriod:          time.Minute,
+		CertificateExpiryThresholds: []time.Duration{336 * time.Hour, 48 * time.Hour},
+	}
+	found, err := config.Parse(spec, defaults)
+	if err != nil {
+		t.Fatalf("parse: (%v)", err)
+	}
+	if !reflect.DeepEqual(found, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, found)
+	}
+
+	// Settings omitted by the spec are taken from the defaults.
+	found, err = config.Parse(appv1alpha1.GatewayServiceOperatorConfigSpec{}, defaults)
+	if err != nil {
+		t.Fatalf("parse: (%v)", err)
+	}
+	if !reflect.DeepEqual(found, defaults) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", defaults, found)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	auth := appv1alpha1.VaultKubernetesAuth{Role: "gatewayservice"}
+	tests := []appv1alpha1.GatewayServiceOperatorConfigSpec{
+		{Domain: "Example.com"},
+		{Cluster: "east.example"},
+		{GatewayNameTemplate: "{{.Namespace"},
+		{GatewayNameTemplate: "{{.Namespace}}_{{.TrafficType}}"},
+		{GatewayNameTemplate: "{{.Namespace}}-gateway"},
+		{SecretNamespace: "istio.system"},
+		{DefaultServer: &appv1alpha1.DefaultServer{Port: 65536}},
+		{DefaultServer: &appv1alpha1.DefaultServer{Host: "{{.Cluster}}.{{.Domain}}"}},
+		{DefaultServer: &appv1alpha1.DefaultServer{Host: "{{.Namespace}}_{{.Domain}}"}},
+		{DefaultServer: &appv1alpha1.DefaultServer{Protocol: "HTTPS"}},
+		{DefaultServer: &appv1alpha1.DefaultServer{CredentialName: "default-credential"}},
+		{AllowedModes: []string{"STRICT"}},
+		{AllowedProtocols: []string{"UDP"}},
+		{AllowedHostNamespaces: []string{"Shared"}},
+		{DefaultServer: &appv1alpha1.DefaultServer{Host: "shared/{{.Domain}}"}},
+		{ResyncPeriod: "-1h"},
+		{Rollout: &appv1alpha1.RolloutConfig{MaxUnavailable: "abc"}},
+		{Rollout: &appv1alpha1.RolloutConfig{GracePeriod: "-1s"}},
+		{CertificateExpiryThresholds: []string{"7d"}},
+		{TrafficClasses: []appv1alpha1.TrafficClass{{Name: "partner", GatewayNamespaces: []string{"Partner_System"}}}},
+		{Vault: &appv1alpha1.VaultConfig{Address: "http://vault.example.com:8200", Auth: auth}},
+		{Vault: &appv1alpha1.VaultConfig{Address: "https://vault.example.com:8200"}},
+		{Vault: &appv1alpha1.VaultConfig{Address: "https://vault.example.com:8200", Auth: auth, CACert: "ca"}},
+		{Vault: &appv1alpha1.VaultConfig{Address: "https://vault.example.com:8200", Auth: auth, Namespaces: []appv1alpha1.VaultNamespace{{Name: "application", KVPathPrefixes: []string{"secret/data/../other"}}}}},
+		{Vault: &appv1alpha1.VaultConfig{Address: "https://vault.example.com:8200", Auth: auth, Namespaces: []appv1alpha1.VaultNamespace{{Name: "application", PKIRoles: []string{"application"}}}}},
+		{TrafficClasses: []appv1alpha1.TrafficClass{{Name: "Partner"}}},
+		{TrafficClasses: []appv1alpha1.TrafficClass{{Name: "partner"}, {Name: "partner"}}},
+		{TrafficClasses: []appv1alpha1.TrafficClass{{Name: "partner", Selector: map[string]string{"istio": "partner gateway"}}}},
+		{TrafficClasses: []appv1alpha1.TrafficClass{{Name: "partner", SecretNamespace: "partner.system"}}},
+		{TrafficClasses: []appv1alpha1.TrafficClass{{Name: "ingress"}, {Name: "partner", GatewayNameTemplate: "{{.Namespace}}-ingress-gateway"}}},
+	}
+	for _, spec := range tests {
+		_, err := config.Parse(spec, config.Defaults())
+		if err == nil {
+			t.Fatalf("expected spec (%+v) to be invalid", spec)
+		}
+	}
+}
+
+func TestParseVault(t *testing.T) {
+	spec := appv1alpha1.GatewayServiceOperatorConfigSpec{
+		Vault: &appv1alpha1.VaultConfig{
+			Address: "https://vault.example.com:8200",
+			Auth:    appv1alpha1.VaultKubernetesAuth{Role: "gatewayservice", MountPath: "kubernetes-east"},
+			Namespaces: []appv1alpha1.VaultNamespace{
+				{Name: "application", KVPathPrefixes: []string{"secret/data/application"}, PKIRoles: []string{"pki/application"}},
+			},
+		},
+	}
+	expected := vault.Config{
+		Address:       "https://vault.example.com:8200",
+		Role:          "gatewayservice",
+		AuthMountPath: "kubernetes-east",
+		Namespaces: map[string]vault.Policy{
+			"application": {KVPathPrefixes: []string{"secret/data/application"}, PKIRoles: []string{"pki/application"}},
+		},
+	}
+	found, err := config.Parse(spec, config.Defaults())
+	if err != nil {
+		t.Fatalf("parse: (%v)", err)
+	}
+	if !reflect.DeepEqual(found.Vault, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, found.Vault)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	operatorConfig := &appv1alpha1.GatewayServiceOperatorConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: config.Name},
+		Spec:       appv1alpha1.GatewayServiceOperatorConfigSpec{Domain: "example.org"},
+	}
+	s := scheme.Scheme
+	s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, operatorConfig, &appv1alpha1.GatewayServiceOperatorConfigList{})
+
+	// The defaults are applied without an operator config.
+	if found := config.Load(fake.NewFakeClient()); !reflect.DeepEqual(found, config.Defaults()) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", config.Defaults(), found)
+	}
+	if found := config.Load(fake.NewFakeClient(operatorConfig)); found.Domain != "example.org" {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", "example.org", found.Domain)
+	}
+
+	// The last valid config remains applied while the operator config is invalid.
+	operatorConfig.Spec.Domain = "Example.org"
+	if found := config.Load(fake.NewFakeClient(operatorConfig)); found.Domain != "example.org" {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", "example.org", found.Domain)
+	}
+}
+
+func TestGatewayName(t *testing.T) {
+	c := config.Defaults()
+	if found := c.GatewayName("application", "ingress"); found != "application-ingress-gateway" {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", "application-ingress-gateway", found)
+	}
+	c.GatewayNameTemplate = "{{.TrafficType}}-{{.Namespace}}"
+	if found, ok := c.TrafficType("application", "egress-application"); !ok || found != "egress" {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", "egress", found)
+	}
+	if _, ok := c.TrafficType("application", "application-egress-gateway"); ok {
+		t.Fatal("expected a gateway named by another template not to serve a trafficType")
+	}
+}
+
+func TestTrafficClasses(t *testing.T) {
+	spec := appv1alpha1.GatewayServiceOperatorConfigSpec{
+		TrafficClasses: []appv1alpha1.TrafficClass{
+			{Name: "internal-ingress"},
+			{
+				Name:                "partner",
+				GatewayNameTemplate: "{{.TrafficType}}-{{.Namespace}}",
+				Selector:            map[string]string{"istio": "partnergateway"},
+				SecretNamespace:     "partner-system",
+				GatewayNamespaces:   []string{"partner-gateways"},
+			},
+		},
+	}
+	defaults := config.Defaults()
+	defaults.GatewaySelectors = map[string]map[string]string{"internal-ingress": {"istio": "internalgateway"}, "partner": {"istio": "ingressgateway"}}
+	defaults.GatewayNamespaces = map[string][]string{"internal-ingress": {"internal-gateways"}, "partner": {"istio-ingress"}}
+	c, err := config.Parse(spec, defaults)
+	if err != nil {
+		t.Fatalf("parse: (%v)", err)
+	}
+	if !reflect.DeepEqual(c.TrafficTypes, []string{"internal-ingress", "partner"}) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", []string{"internal-ingress", "partner"}, c.TrafficTypes)
+	}
+
+	// Settings omitted by a traffic class are taken from the config.
+	if found := c.GatewayName("application", "internal-ingress"); found != "application-internal-ingress-gateway" {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", "application-internal-ingress-gateway", found)
+	}
+	if found, ok := c.TrafficType("application", "partner-application"); !ok || found != "partner" {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", "partner", found)
+	}
+	if found := c.TrafficSecretNamespace("internal-ingress"); found != config.DefaultSecretNamespace {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", config.DefaultSecretNamespace, found)
+	}
+	if found := c.TrafficSecretNamespace("partner"); found != "partner-system" {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", "partner-system", found)
+	}
+	expected := map[string]map[string]string{"internal-ingress": {"istio": "internalgateway"}, "partner": {"istio": "partnergateway"}}
+	if found := c.Selectors(); !reflect.DeepEqual(found, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, found)
+	}
+	if found := c.Namespaces("internal-ingress"); !reflect.DeepEqual(found, []string{"internal-gateways"}) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", []string{"internal-gateways"}, found)
+	}
+	if found := c.Namespaces("partner"); !reflect.DeepEqual(found, []string{"partner-gateways"}) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", []string{"partner-gateways"}, found)
+	}
+
+	// GatewayServices may only target the declared traffic classes.
+	err = c.Allows(appv1alpha1.GatewayServiceSpec{TrafficType: "ingress"})
+	expectedErr := `trafficType "ingress" is not declared by the operator config, trafficTypes are internal-ingress, partner`
+	if err == nil || err.Error() != expectedErr {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expectedErr, err)
+	}
+	if err := c.Allows(appv1alpha1.GatewayServiceSpec{TrafficType: "partner"}); err != nil {
+		t.Fatalf("expected trafficType partner to be allowed, found (%v)", err)
+	}
+}
+
+func TestAllows(t *testing.T) {
+	c := config.Defaults()
+	spec := appv1alpha1.GatewayServiceSpec{Mode: "PASSTHROUGH", Protocol: "TLS", TrafficType: "ingress"}
+	if err := c.Allows(spec); err != nil {
+		t.Fatalf("expected every mode and protocol to be allowed, found (%v)", err)
+	}
+	c.AllowedModes = []string{"SIMPLE", "PASSTHROUGH"}
+	c.AllowedProtocols = []string{"HTTPS"}
+	err := c.Allows(spec)
+	expected := "protocol TLS is not allowed by the operator config, allowed protocols are HTTPS"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, err)
+	}
+}
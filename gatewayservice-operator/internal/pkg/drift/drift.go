@@ -0,0 +1,108 @@
+package drift
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	networkv3 "istio.io/api/networking/v1alpha3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// HashAnnotation records the hash of the servers last applied to the Gateway by the operator. A Gateway whose servers
+// no longer match the hash has been changed by someone else.
+const HashAnnotation = "crd.xunholy.github.com/servers-hash"
+
+// Hash returns a hash of the servers.
+func Hash(servers []*networkv3.Server) string {
+	b, _ := json.Marshal(servers)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// Diff describes the changes required to revert the current servers to the desired servers, EG. "restored server
+// https-example-application".
+func Diff(current []*networkv3.Server, desired []*networkv3.Server) []string {
+	currentServers := byName(current)
+	desiredServers := byName(desired)
+	changes := []string{}
+	for name, server := range desiredServers {
+		c, ok := currentServers[name]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("restored server %s", name))
+			continue
+		}
+		if !equal(c, server) {
+			changes = append(changes, fmt.Sprintf("reverted server %s", name))
+		}
+	}
+	for name := range currentServers {
+		if _, ok := desiredServers[name]; !ok {
+			changes = append(changes, fmt.Sprintf("removed server %s", name))
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+// Manager describes who last changed an object, from the managedFields of the object.
+type Manager struct {
+	Manager   string
+	Operation string
+	Time      time.Time
+}
+
+func (m Manager) String() string {
+	if m.Manager == "" {
+		return "an unknown manager"
+	}
+	if m.Time.IsZero() {
+		return fmt.Sprintf("%s (%s)", m.Manager, m.Operation)
+	}
+	return fmt.Sprintf("%s (%s at %s)", m.Manager, m.Operation, m.Time.UTC().Format(time.RFC3339))
+}
+
+// LastManager returns the manager which most recently changed the object other than the excluded manager. The
+// manager is empty if the API server does not record managedFields.
+func LastManager(obj *unstructured.Unstructured, exclude string) Manager {
+	last := Manager{}
+	entries, _, _ := unstructured.NestedSlice(obj.Object, "metadata", "managedFields")
+	for _, e := range entries {
+		entry, ok := e.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		manager, _ := entry["manager"].(string)
+		if manager == "" || manager == exclude {
+			continue
+		}
+		operation, _ := entry["operation"].(string)
+		timestamp, _ := entry["time"].(string)
+		t, _ := time.Parse(time.RFC3339, timestamp)
+		if last.Manager == "" || !t.Before(last.Time) {
+			last = Manager{Manager: manager, Operation: operation, Time: t}
+		}
+	}
+	return last
+}
+
+func byName(servers []*networkv3.Server) map[string]*networkv3.Server {
+	m := map[string]*networkv3.Server{}
+	for i, server := range servers {
+		name := fmt.Sprintf("#%d", i)
+		if server.Port != nil && server.Port.Name != "" {
+			name = server.Port.Name
+		}
+		m[name] = server
+	}
+	return m
+}
+
+func equal(a *networkv3.Server, b *networkv3.Server) bool {
+	x, _ := json.Marshal(a)
+	y, _ := json.Marshal(b)
+	return string(x) == string(y)
+}
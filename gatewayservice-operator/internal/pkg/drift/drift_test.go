@@ -0,0 +1,72 @@
+package drift_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/drift"
+	networkv3 "istio.io/api/networking/v1alpha3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func server(name string, hosts ...string) *networkv3.Server {
+	return &networkv3.Server{
+		Port:  &networkv3.Port{Name: name, Number: 443, Protocol: "HTTPS"},
+		Hosts: hosts,
+	}
+}
+
+func TestDriftDiff(t *testing.T) {
+	current := []*networkv3.Server{
+		server("https-example-application", "modified.example.com"),
+		server("http-manual"),
+	}
+	desired := []*networkv3.Server{
+		server("https-example-application", "*"),
+		server("https-other-application", "*"),
+	}
+	expected := []string{
+		"removed server http-manual",
+		"restored server https-other-application",
+		"reverted server https-example-application",
+	}
+	changes := drift.Diff(current, desired)
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, changes)
+	}
+	if len(drift.Diff(desired, desired)) != 0 {
+		t.Fatalf("expected no changes between identical servers")
+	}
+}
+
+func TestDriftHash(t *testing.T) {
+	servers := []*networkv3.Server{server("https-example-application", "*")}
+	if drift.Hash(servers) != drift.Hash([]*networkv3.Server{server("https-example-application", "*")}) {
+		t.Fatalf("expected hash to be stable")
+	}
+	if drift.Hash(servers) == drift.Hash(nil) {
+		t.Fatalf("expected hash to change with the servers")
+	}
+}
+
+func TestDriftLastManager(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"managedFields": []interface{}{
+				map[string]interface{}{"manager": "gatewayservice-operator", "operation": "Update", "time": "2020-01-01T00:02:00Z"},
+				map[string]interface{}{"manager": "kubectl", "operation": "Update", "time": "2020-01-01T00:01:00Z"},
+				map[string]interface{}{"manager": "helm", "operation": "Update", "time": "2020-01-01T00:00:00Z"},
+			},
+		},
+	}}
+	expected := drift.Manager{Manager: "kubectl", Operation: "Update", Time: time.Date(2020, 1, 1, 0, 1, 0, 0, time.UTC)}
+	manager := drift.LastManager(obj, "gatewayservice-operator")
+	if !reflect.DeepEqual(manager, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, manager)
+	}
+	manager = drift.LastManager(&unstructured.Unstructured{Object: map[string]interface{}{}}, "gatewayservice-operator")
+	if manager.String() != "an unknown manager" {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", "an unknown manager", manager.String())
+	}
+}
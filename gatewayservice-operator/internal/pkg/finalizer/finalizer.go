@@ -0,0 +1,34 @@
+package finalizer
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Has reports whether the finalizer is present on the object.
+func Has(obj metav1.Object, finalizer string) bool {
+	for _, f := range obj.GetFinalizers() {
+		if f == finalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// Add appends the finalizer to the object if it is not already present.
+func Add(obj metav1.Object, finalizer string) {
+	if Has(obj, finalizer) {
+		return
+	}
+	obj.SetFinalizers(append(obj.GetFinalizers(), finalizer))
+}
+
+// Remove strips every occurrence of the finalizer from the object.
+func Remove(obj metav1.Object, finalizer string) {
+	finalizers := []string{}
+	for _, f := range obj.GetFinalizers() {
+		if f != finalizer {
+			finalizers = append(finalizers, f)
+		}
+	}
+	obj.SetFinalizers(finalizers)
+}
@@ -0,0 +1,43 @@
+package finalizer_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/finalizer"
+	appv1alpha1 "github.com/xunholy/k8s-istio-gateway-service-operator/pkg/apis/crd/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	name = "finalizer.gatewayservice.crd.xunholy.github.com"
+)
+
+func TestFinalizerAdd(t *testing.T) {
+	gatewayservice := &appv1alpha1.GatewayService{}
+	finalizer.Add(gatewayservice, name)
+	finalizer.Add(gatewayservice, name)
+	expected := []string{name}
+	if !reflect.DeepEqual(gatewayservice.GetFinalizers(), expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, gatewayservice.GetFinalizers())
+	}
+	if !finalizer.Has(gatewayservice, name) {
+		t.Fatalf("expected finalizer %s to be present", name)
+	}
+}
+
+func TestFinalizerRemove(t *testing.T) {
+	gatewayservice := &appv1alpha1.GatewayService{
+		ObjectMeta: metav1.ObjectMeta{
+			Finalizers: []string{"example.com/other", name},
+		},
+	}
+	finalizer.Remove(gatewayservice, name)
+	expected := []string{"example.com/other"}
+	if !reflect.DeepEqual(gatewayservice.GetFinalizers(), expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, gatewayservice.GetFinalizers())
+	}
+	if finalizer.Has(gatewayservice, name) {
+		t.Fatalf("expected finalizer %s to be removed", name)
+	}
+}
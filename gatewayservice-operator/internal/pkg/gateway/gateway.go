@@ -1,34 +1,172 @@
 package gateway
 
 import (
+	"bytes"
 	"fmt"
+	"sort"
 	"strings"
+	"text/template"
 
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/host"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/names"
 	appv1alpha1 "github.com/xunholy/k8s-istio-gateway-service-operator/pkg/apis/crd/v1alpha1"
 	networkv3 "istio.io/api/networking/v1alpha3"
 	"istio.io/client-go/pkg/apis/networking/v1alpha3"
 )
 
+// ManagedServersAnnotation lists the port names of the servers owned by the operator. Servers not listed were added by
+// someone else and are left untouched.
+const ManagedServersAnnotation = "crd.xunholy.github.com/managed-servers"
+
+const (
+	// CreatedAnnotation marks the Gateways created by the operator, which are removed once they no longer serve
+	// anything.
+	CreatedAnnotation = "crd.xunholy.github.com/created"
+
+	// DefaultNameTemplate renders the names of the Gateways unless configured otherwise.
+	DefaultNameTemplate = "{{.Namespace}}-{{.TrafficType}}-gateway"
+	// DefaultHostTemplate renders the host of the default server unless configured otherwise.
+	DefaultHostTemplate = "{{.Namespace}}.{{.Domain}}"
+)
+
 type GatewayConfig struct {
 	Name           string
 	TrafficType    string
 	GatewayService *appv1alpha1.GatewayServiceList
 	Gateway        *v1alpha3.Gateway
 	Domain         string
+	Cluster        string
+	// NamespaceLabels are the labels of the namespace of the Gateway, available to host templates as .Labels.
+	NamespaceLabels map[string]string
+	DefaultServer   DefaultServer
+}
+
+// HostValues are the values host templates are rendered with.
+type HostValues struct {
+	Namespace   string
+	TrafficType string
+	Domain      string
+	Cluster     string
+	Labels      map[string]string
+}
+
+// DefaultServer configures the server rendered into a Gateway without any other servers. Zero values render an HTTP
+// server on port 80 for the host of DefaultHostTemplate.
+type DefaultServer struct {
+	Disabled bool
+	Port     uint32
+	Protocol string
+	// HostTemplate is rendered with the HostValues.
+	HostTemplate string
+	// CredentialName is the secret holding the certificate served for HTTPS and TLS.
+	CredentialName string
+}
+
+// Name returns the name of the Gateway serving GatewayServices of the trafficType within the namespace.
+func Name(namespace string, trafficType string) string {
+	return fmt.Sprintf("%s-%s-gateway", namespace, trafficType)
+}
+
+// NameFromTemplate renders the name of the Gateway serving GatewayServices of the trafficType within the namespace from
+// a template using .Namespace and .TrafficType.
+func NameFromTemplate(nameTemplate string, namespace string, trafficType string) (string, error) {
+	return render(nameTemplate, struct {
+		Namespace   string
+		TrafficType string
+	}{Namespace: namespace, TrafficType: trafficType})
+}
+
+// DefaultHost renders the host of the default server from a template using the HostValues.
+func DefaultHost(hostTemplate string, values HostValues) (string, error) {
+	if hostTemplate == "" {
+		hostTemplate = DefaultHostTemplate
+	}
+	return render(hostTemplate, values)
+}
+
+// ExpandHosts renders the hosts of a GatewayService, which may be templates such as "api.{{.Cluster}}.{{.Domain}}".
+// Hosts without a template are returned unchanged. An error is returned when a template cannot be rendered, EG. it
+// refers to a namespace label which is not set, or renders an empty host.
+func ExpandHosts(hosts []string, values HostValues) ([]string, error) {
+	expanded := []string{}
+	for _, h := range hosts {
+		if !strings.Contains(h, "{{") {
+			expanded = append(expanded, h)
+			continue
+		}
+		result, err := render(h, values)
+		if err != nil {
+			return nil, fmt.Errorf("host %q cannot be expanded: %v", h, err)
+		}
+		if result == "" {
+			return nil, fmt.Errorf("host %q expands to an empty host", h)
+		}
+		expanded = append(expanded, result)
+	}
+	return expanded, nil
+}
+
+// hostValues returns the values the host templates of GatewayServices within the namespace are rendered with.
+func (g GatewayConfig) hostValues(namespace string) HostValues {
+	return HostValues{
+		Namespace:   namespace,
+		TrafficType: g.TrafficType,
+		Domain:      g.Domain,
+		Cluster:     g.Cluster,
+		Labels:      g.NamespaceLabels,
+	}
+}
+
+func render(text string, data interface{}) (string, error) {
+	t, err := template.New("gateway").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	result := &bytes.Buffer{}
+	err = t.Execute(result, data)
+	if err != nil {
+		return "", err
+	}
+	return result.String(), nil
 }
 
 func Reconcile(g GatewayConfig) *v1alpha3.Gateway {
 	// Create empty server stanza array
 	servers := []*networkv3.Server{}
+	rendered := renderedPortNames(g.Gateway)
+	used := map[string]bool{}
 
 	// Add all gatewayservice server entries into servers array
 	for _, gatewayservice := range g.GatewayService.Items {
+		// GatewayServices pending deletion are waiting on their finalizer to
+		// remove them from the Gateway, so their server must not be rendered.
+		if gatewayservice.ObjectMeta.DeletionTimestamp != nil {
+			continue
+		}
+		// Hosts which can no longer be expanded, EG. after a namespace label was
+		// removed, are reported by the GatewayService controller. The server is
+		// left out rather than rendered with a host Istio would reject.
+		hosts, err := ExpandHosts(gatewayservice.Spec.Hosts, g.hostValues(gatewayservice.ObjectMeta.Namespace))
+		if err == nil {
+			hosts, err = host.Canonicalize(hosts)
+		}
+		if err != nil {
+			continue
+		}
+		// Servers rendered before port names were made unique keep their name,
+		// unless another GatewayService already claimed it.
+		portName := names.Port(gatewayservice.Spec.Protocol, gatewayservice.ObjectMeta.Name, gatewayservice.ObjectMeta.Namespace)
+		legacy := names.LegacyPort(gatewayservice.Spec.Protocol, gatewayservice.ObjectMeta.Name, gatewayservice.ObjectMeta.Namespace)
+		if rendered[legacy] && !used[legacy] {
+			portName = legacy
+		}
+		used[portName] = true
 		servers = append(servers, &networkv3.Server{
 			// REQUIRED: The Port on which the proxy should listen for incoming
 			// connections
 			Port: &networkv3.Port{
 				// Label assigned to the port.
-				Name: fmt.Sprintf("%s-%s-%s", strings.ToLower(string(gatewayservice.Spec.Protocol)), gatewayservice.ObjectMeta.Name, gatewayservice.ObjectMeta.Namespace),
+				Name: portName,
 
 				// REQUIRED: A valid non-negative integer port number.
 				Number: gatewayservice.Spec.Port,
@@ -52,24 +190,97 @@ func Reconcile(g GatewayConfig) *v1alpha3.Gateway {
 			// in its default destination. Specifically one of the VirtualService
 			// destination hosts is a strict suffix of a gateway host or
 			// a gateway host is a suffix of one of the VirtualService hosts.
-			Hosts: gatewayservice.Spec.Hosts,
+			Hosts: hosts,
 		})
 	}
-	if len(servers) == 0 {
+	unmanaged := UnmanagedServers(g.Gateway)
+	if len(servers) == 0 && len(unmanaged) == 0 && !g.DefaultServer.Disabled {
 		servers = append(servers, defaultServer(g))
 	}
-	g.Gateway.Spec.Servers = servers
+	portNames := []string{}
+	for _, server := range servers {
+		portNames = append(portNames, server.Port.Name)
+	}
+	sort.Strings(portNames)
+	if g.Gateway.Annotations == nil {
+		g.Gateway.Annotations = map[string]string{}
+	}
+	g.Gateway.Annotations[ManagedServersAnnotation] = strings.Join(portNames, ",")
+	g.Gateway.Spec.Servers = append(unmanaged, servers...)
 	return g.Gateway
 }
 
+// renderedPortNames returns the port names of the servers last rendered into the Gateway by the operator. Gateways
+// reconciled before ownership was recorded only contain servers rendered by the operator.
+func renderedPortNames(gateway *v1alpha3.Gateway) map[string]bool {
+	rendered := map[string]bool{}
+	annotation, recorded := gateway.Annotations[ManagedServersAnnotation]
+	if recorded {
+		for _, name := range strings.Split(annotation, ",") {
+			rendered[name] = true
+		}
+		return rendered
+	}
+	for _, server := range gateway.Spec.Servers {
+		if server.Port != nil {
+			rendered[server.Port.Name] = true
+		}
+	}
+	return rendered
+}
+
+// UnmanagedServers returns the servers of the Gateway which are not owned by the operator, in their existing order.
+// Gateways reconciled before ownership was recorded only contain servers rendered by the operator, which are named
+// after the namespace of the Gateway.
+func UnmanagedServers(gateway *v1alpha3.Gateway) []*networkv3.Server {
+	managed := map[string]bool{}
+	annotation, recorded := gateway.Annotations[ManagedServersAnnotation]
+	if annotation != "" {
+		for _, name := range strings.Split(annotation, ",") {
+			managed[name] = true
+		}
+	}
+	unmanaged := []*networkv3.Server{}
+	for _, server := range gateway.Spec.Servers {
+		name := ""
+		if server.Port != nil {
+			name = server.Port.Name
+		}
+		if managed[name] || (!recorded && strings.HasSuffix(name, "-"+gateway.Namespace)) {
+			continue
+		}
+		unmanaged = append(unmanaged, server)
+	}
+	return unmanaged
+}
+
 func defaultServer(g GatewayConfig) *networkv3.Server {
-	return &networkv3.Server{
+	port := g.DefaultServer.Port
+	if port == 0 {
+		port = 80
+	}
+	protocol := g.DefaultServer.Protocol
+	if protocol == "" {
+		protocol = "HTTP"
+	}
+	// Default to use the namespace as a unique identifier to avoid Hosts from clashing
+	host, err := DefaultHost(g.DefaultServer.HostTemplate, g.hostValues(g.Gateway.ObjectMeta.Namespace))
+	if err != nil {
+		host = fmt.Sprintf("%s.%s", g.Gateway.ObjectMeta.Namespace, g.Domain)
+	}
+	server := &networkv3.Server{
 		Port: &networkv3.Port{
-			Name:     fmt.Sprintf("http-%s", g.Gateway.ObjectMeta.Namespace),
-			Number:   80,
-			Protocol: "HTTP",
+			Name:     fmt.Sprintf("%s-%s", strings.ToLower(protocol), g.Gateway.ObjectMeta.Namespace),
+			Number:   port,
+			Protocol: protocol,
 		},
-		// Default to use the namespace as a unique identifier to avoid Hosts from clashing
-		Hosts: []string{fmt.Sprintf("%s.%s", g.Gateway.ObjectMeta.Namespace, g.Domain)},
+		Hosts: []string{host},
+	}
+	if g.DefaultServer.CredentialName != "" {
+		server.Tls = &networkv3.Server_TLSOptions{
+			Mode:           networkv3.Server_TLSOptions_SIMPLE,
+			CredentialName: g.DefaultServer.CredentialName,
+		}
 	}
+	return server
 }
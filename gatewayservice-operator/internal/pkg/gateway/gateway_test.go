@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	g "github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/gateway"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/names"
 	appv1alpha1 "github.com/xunholy/k8s-istio-gateway-service-operator/pkg/apis/crd/v1alpha1"
 	networkv3 "istio.io/api/networking/v1alpha3"
 	"istio.io/client-go/pkg/apis/networking/v1alpha3"
@@ -18,12 +19,18 @@ var (
 	trafficType = "ingress"
 	cert        = "Q2VydAo="
 	key         = "S2V5Cg=="
+	httpsPort   = names.Port("HTTPS", name, namespace)
+	tlsPort     = names.Port("TLS", name, namespace)
+	secretName  = names.Secret(name, namespace)
 )
 
 func TestGatewayReconcile_Default(t *testing.T) {
 	gatewayserviceList := &appv1alpha1.GatewayServiceList{}
 	gateway := &v1alpha3.Gateway{}
 	expected := &v1alpha3.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{g.ManagedServersAnnotation: "http-"},
+		},
 		Spec: networkv3.Gateway{
 			Servers: []*networkv3.Server{
 				{
@@ -49,6 +56,173 @@ func TestGatewayReconcile_Default(t *testing.T) {
 	}
 }
 
+func TestGatewayReconcile_DefaultServerDisabled(t *testing.T) {
+	gatewayserviceList := &appv1alpha1.GatewayServiceList{}
+	gateway := &v1alpha3.Gateway{}
+	expected := &v1alpha3.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{g.ManagedServersAnnotation: ""},
+		},
+		Spec: networkv3.Gateway{
+			Servers: []*networkv3.Server{},
+		},
+	}
+	gatewayConfig := g.GatewayConfig{
+		Name:           fmt.Sprintf("%s-%s-gateway", namespace, trafficType),
+		TrafficType:    trafficType,
+		GatewayService: gatewayserviceList,
+		Gateway:        gateway,
+		DefaultServer:  g.DefaultServer{Disabled: true},
+	}
+	gatewayObject := g.Reconcile(gatewayConfig)
+	if !reflect.DeepEqual(gatewayObject, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, gatewayObject)
+	}
+}
+
+func TestGatewayReconcile_DefaultServerConfigured(t *testing.T) {
+	gatewayserviceList := &appv1alpha1.GatewayServiceList{}
+	gateway := &v1alpha3.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+		},
+	}
+	expected := &v1alpha3.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespace,
+			Annotations: map[string]string{g.ManagedServersAnnotation: fmt.Sprintf("https-%s", namespace)},
+		},
+		Spec: networkv3.Gateway{
+			Servers: []*networkv3.Server{
+				{
+					Port: &networkv3.Port{
+						Name:     fmt.Sprintf("https-%s", namespace),
+						Number:   8443,
+						Protocol: "HTTPS",
+					},
+					Hosts: []string{fmt.Sprintf("%s.%s.example.com", trafficType, namespace)},
+					Tls: &networkv3.Server_TLSOptions{
+						Mode:           networkv3.Server_TLSOptions_SIMPLE,
+						CredentialName: "default-credential",
+					},
+				},
+			},
+		},
+	}
+	gatewayConfig := g.GatewayConfig{
+		Name:           fmt.Sprintf("%s-%s-gateway", namespace, trafficType),
+		TrafficType:    trafficType,
+		GatewayService: gatewayserviceList,
+		Gateway:        gateway,
+		Domain:         "example.com",
+		DefaultServer: g.DefaultServer{
+			Port:           8443,
+			Protocol:       "HTTPS",
+			HostTemplate:   "{{.TrafficType}}.{{.Namespace}}.{{.Domain}}",
+			CredentialName: "default-credential",
+		},
+	}
+	gatewayObject := g.Reconcile(gatewayConfig)
+	if !reflect.DeepEqual(gatewayObject, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, gatewayObject)
+	}
+}
+
+func TestNameFromTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		expected string
+		err      bool
+	}{
+		{template: g.DefaultNameTemplate, expected: g.Name(namespace, trafficType)},
+		{template: "{{.TrafficType}}", expected: trafficType},
+		{template: "{{.Cluster}}-gateway", err: true},
+		{template: "{{.Namespace", err: true},
+	}
+	for _, test := range tests {
+		found, err := g.NameFromTemplate(test.template, namespace, trafficType)
+		if (err != nil) != test.err {
+			t.Fatalf("template %q: unexpected error (%v)", test.template, err)
+		}
+		if found != test.expected {
+			t.Fatalf("Expected: (%+v) \n Found: (%+v)", test.expected, found)
+		}
+	}
+}
+
+func TestExpandHosts(t *testing.T) {
+	values := g.HostValues{
+		Namespace:   namespace,
+		TrafficType: trafficType,
+		Domain:      "example.com",
+		Cluster:     "east",
+		Labels:      map[string]string{"team": "payments"},
+	}
+	hosts := []string{"{{.Namespace}}.{{.Domain}}", "api.{{.Cluster}}.{{.Domain}}", "{{.Labels.team}}.example.com", "*.example.com"}
+	expected := []string{"application.example.com", "api.east.example.com", "payments.example.com", "*.example.com"}
+	found, err := g.ExpandHosts(hosts, values)
+	if err != nil {
+		t.Fatalf("expand hosts: (%v)", err)
+	}
+	if !reflect.DeepEqual(found, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, found)
+	}
+	for _, host := range []string{"{{.Labels.owner}}.example.com", "{{.Namespace", "{{.Missing}}"} {
+		_, err := g.ExpandHosts([]string{host}, values)
+		if err == nil {
+			t.Fatalf("expected host (%+v) not to expand", host)
+		}
+	}
+}
+
+func TestGatewayReconcile_HostTemplates(t *testing.T) {
+	gatewayserviceList := &appv1alpha1.GatewayServiceList{
+		Items: []appv1alpha1.GatewayService{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace,
+				},
+				Spec: appv1alpha1.GatewayServiceSpec{
+					Hosts:    []string{"{{.Labels.team}}.{{.Cluster}}.{{.Domain}}"},
+					Port:     80,
+					Protocol: "HTTP",
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "unexpanded",
+					Namespace: namespace,
+				},
+				Spec: appv1alpha1.GatewayServiceSpec{
+					Hosts:    []string{"{{.Labels.owner}}.{{.Domain}}"},
+					Port:     80,
+					Protocol: "HTTP",
+				},
+			},
+		},
+	}
+	gateway := &v1alpha3.Gateway{}
+	gatewayConfig := g.GatewayConfig{
+		Name:            fmt.Sprintf("%s-%s-gateway", namespace, trafficType),
+		TrafficType:     trafficType,
+		GatewayService:  gatewayserviceList,
+		Gateway:         gateway,
+		Domain:          "example.com",
+		Cluster:         "east",
+		NamespaceLabels: map[string]string{"team": "payments"},
+	}
+	// The server of a GatewayService whose hosts cannot be expanded is left out.
+	servers := g.Reconcile(gatewayConfig).Spec.Servers
+	if len(servers) != 1 {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", 1, len(servers))
+	}
+	expected := []string{"payments.east.example.com"}
+	if !reflect.DeepEqual(servers[0].Hosts, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, servers[0].Hosts)
+	}
+}
+
 func TestGatewayReconcile_TLSSecret_PASSTHROUGH(t *testing.T) {
 	gatewayserviceList := &appv1alpha1.GatewayServiceList{
 		Items: []appv1alpha1.GatewayService{
@@ -75,17 +249,20 @@ func TestGatewayReconcile_TLSSecret_PASSTHROUGH(t *testing.T) {
 	}
 	gateway := &v1alpha3.Gateway{}
 	expected := &v1alpha3.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{g.ManagedServersAnnotation: httpsPort},
+		},
 		Spec: networkv3.Gateway{
 			Servers: []*networkv3.Server{
 				{
 					Port: &networkv3.Port{
-						Name:     "https-example-app-application",
+						Name:     httpsPort,
 						Number:   80,
 						Protocol: "HTTPS",
 					},
 					Hosts: []string{"*"},
 					Tls: &networkv3.Server_TLSOptions{
-						CredentialName: "example-app-application-secret",
+						CredentialName: secretName,
 					},
 				},
 			},
@@ -129,17 +306,20 @@ func TestGatewayReconcile_TLSSecret_SIMPLE(t *testing.T) {
 	}
 	gateway := &v1alpha3.Gateway{}
 	expected := &v1alpha3.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{g.ManagedServersAnnotation: httpsPort},
+		},
 		Spec: networkv3.Gateway{
 			Servers: []*networkv3.Server{
 				{
 					Port: &networkv3.Port{
-						Name:     "https-example-app-application",
+						Name:     httpsPort,
 						Number:   80,
 						Protocol: "HTTPS",
 					},
 					Hosts: []string{"*"},
 					Tls: &networkv3.Server_TLSOptions{
-						CredentialName: "example-app-application-secret",
+						CredentialName: secretName,
 						Mode:           1,
 					},
 				},
@@ -184,11 +364,14 @@ func TestGatewayReconcile_TLSSecretPath(t *testing.T) {
 	}
 	gateway := &v1alpha3.Gateway{}
 	expected := &v1alpha3.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{g.ManagedServersAnnotation: httpsPort},
+		},
 		Spec: networkv3.Gateway{
 			Servers: []*networkv3.Server{
 				{
 					Port: &networkv3.Port{
-						Name:     "https-example-app-application",
+						Name:     httpsPort,
 						Number:   80,
 						Protocol: "HTTPS",
 					},
@@ -214,6 +397,68 @@ func TestGatewayReconcile_TLSSecretPath(t *testing.T) {
 	}
 }
 
+func TestGatewayReconcile_TLSSecretPath_OPTIONAL_MUTUAL(t *testing.T) {
+	caCertificates := "/example/path/to/ca"
+	gatewayserviceList := &appv1alpha1.GatewayServiceList{
+		Items: []appv1alpha1.GatewayService{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace,
+				},
+				Spec: appv1alpha1.GatewayServiceSpec{
+					Hosts:          []string{"*"},
+					Mode:           "OPTIONAL_MUTUAL",
+					Port:           443,
+					Protocol:       "HTTPS",
+					TrafficType:    "ingress",
+					CaCertificates: &caCertificates,
+					TLSOptions: &appv1alpha1.TLSOptions{
+						TLSSecretPath: &appv1alpha1.TLSSecretPath{
+							CertPath: "/example/path/to/file",
+							KeyPath:  "/example/path/to/file",
+						},
+					},
+				},
+			},
+		},
+	}
+	gateway := &v1alpha3.Gateway{}
+	expected := &v1alpha3.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{g.ManagedServersAnnotation: httpsPort},
+		},
+		Spec: networkv3.Gateway{
+			Servers: []*networkv3.Server{
+				{
+					Port: &networkv3.Port{
+						Name:     httpsPort,
+						Number:   443,
+						Protocol: "HTTPS",
+					},
+					Hosts: []string{"*"},
+					Tls: &networkv3.Server_TLSOptions{
+						ServerCertificate: "/example/path/to/file",
+						PrivateKey:        "/example/path/to/file",
+						CaCertificates:    caCertificates,
+						Mode:              g.OptionalMutual,
+					},
+				},
+			},
+		},
+	}
+	gatewayConfig := g.GatewayConfig{
+		Name:           fmt.Sprintf("%s-%s-gateway", namespace, trafficType),
+		TrafficType:    trafficType,
+		GatewayService: gatewayserviceList,
+		Gateway:        gateway,
+	}
+	gatewayObject := g.Reconcile(gatewayConfig)
+	if !reflect.DeepEqual(gatewayObject, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, gatewayObject)
+	}
+}
+
 func TestGatewayReconcile_TLSSecretRef(t *testing.T) {
 	gatewayserviceList := &appv1alpha1.GatewayServiceList{
 		Items: []appv1alpha1.GatewayService{
@@ -239,12 +484,159 @@ func TestGatewayReconcile_TLSSecretRef(t *testing.T) {
 	}
 	gateway := &v1alpha3.Gateway{}
 	expected := &v1alpha3.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{g.ManagedServersAnnotation: httpsPort},
+		},
+		Spec: networkv3.Gateway{
+			Servers: []*networkv3.Server{
+				{
+					Port: &networkv3.Port{
+						Name:     httpsPort,
+						Number:   80,
+						Protocol: "HTTPS",
+					},
+					Hosts: []string{"*"},
+					Tls: &networkv3.Server_TLSOptions{
+						CredentialName: "example-secret",
+						Mode:           1,
+					},
+				},
+			},
+		},
+	}
+	gatewayConfig := g.GatewayConfig{
+		Name:           fmt.Sprintf("%s-%s-gateway", namespace, trafficType),
+		TrafficType:    trafficType,
+		GatewayService: gatewayserviceList,
+		Gateway:        gateway,
+	}
+	gatewayObject := g.Reconcile(gatewayConfig)
+	if !reflect.DeepEqual(gatewayObject, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, gatewayObject)
+	}
+}
+
+func TestGatewayReconcile_DeletionPending(t *testing.T) {
+	deletionTimestamp := metav1.Now()
+	gatewayserviceList := &appv1alpha1.GatewayServiceList{
+		Items: []appv1alpha1.GatewayService{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              name,
+					Namespace:         namespace,
+					DeletionTimestamp: &deletionTimestamp,
+				},
+				Spec: appv1alpha1.GatewayServiceSpec{
+					Hosts:       []string{"*"},
+					Mode:        "SIMPLE",
+					Port:        443,
+					Protocol:    "HTTPS",
+					TrafficType: "ingress",
+					TLSOptions: &appv1alpha1.TLSOptions{
+						TLSSecret: &appv1alpha1.TLSSecret{
+							Cert: &cert,
+							Key:  &key,
+						},
+					},
+				},
+			},
+		},
+	}
+	gateway := &v1alpha3.Gateway{}
+	expected := &v1alpha3.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{g.ManagedServersAnnotation: "http-"},
+		},
 		Spec: networkv3.Gateway{
 			Servers: []*networkv3.Server{
 				{
 					Port: &networkv3.Port{
-						Name:     "https-example-app-application",
+						Name:     "http-",
 						Number:   80,
+						Protocol: "HTTP",
+					},
+					Hosts: []string{"."},
+				},
+			},
+		},
+	}
+	gatewayConfig := g.GatewayConfig{
+		Name:           fmt.Sprintf("%s-%s-gateway", namespace, trafficType),
+		TrafficType:    trafficType,
+		GatewayService: gatewayserviceList,
+		Gateway:        gateway,
+	}
+	gatewayObject := g.Reconcile(gatewayConfig)
+	if !reflect.DeepEqual(gatewayObject, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, gatewayObject)
+	}
+}
+
+func TestGatewayReconcile_UnmanagedServers(t *testing.T) {
+	gatewayserviceList := &appv1alpha1.GatewayServiceList{
+		Items: []appv1alpha1.GatewayService{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace,
+				},
+				Spec: appv1alpha1.GatewayServiceSpec{
+					Hosts:       []string{"*"},
+					Mode:        "SIMPLE",
+					Port:        443,
+					Protocol:    "HTTPS",
+					TrafficType: "ingress",
+					TLSOptions: &appv1alpha1.TLSOptions{
+						TLSSecretRef: &appv1alpha1.TLSSecretRef{
+							SecretName: "example-secret",
+						},
+					},
+				},
+			},
+		},
+	}
+	// A server added by hand and a server previously rendered for a GatewayService which has since been removed.
+	platform := &networkv3.Server{
+		Port: &networkv3.Port{
+			Name:     "http-platform",
+			Number:   8080,
+			Protocol: "HTTP",
+		},
+		Hosts: []string{"platform.example.com"},
+	}
+	gateway := &v1alpha3.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespace,
+			Annotations: map[string]string{g.ManagedServersAnnotation: "https-removed-application"},
+		},
+		Spec: networkv3.Gateway{
+			Selector: map[string]string{"istio": "ingressgateway"},
+			Servers: []*networkv3.Server{
+				platform,
+				{
+					Port: &networkv3.Port{
+						Name:     "https-removed-application",
+						Number:   443,
+						Protocol: "HTTPS",
+					},
+					Hosts: []string{"removed.example.com"},
+				},
+			},
+		},
+	}
+	expected := &v1alpha3.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespace,
+			Annotations: map[string]string{g.ManagedServersAnnotation: httpsPort},
+		},
+		Spec: networkv3.Gateway{
+			Selector: map[string]string{"istio": "ingressgateway"},
+			Servers: []*networkv3.Server{
+				platform,
+				{
+					Port: &networkv3.Port{
+						Name:     httpsPort,
+						Number:   443,
 						Protocol: "HTTPS",
 					},
 					Hosts: []string{"*"},
@@ -266,4 +658,185 @@ func TestGatewayReconcile_TLSSecretRef(t *testing.T) {
 	if !reflect.DeepEqual(gatewayObject, expected) {
 		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, gatewayObject)
 	}
+
+	// Without ownership recorded the servers named after the namespace were rendered by the operator.
+	delete(gateway.Annotations, g.ManagedServersAnnotation)
+	gateway.Spec.Servers = append([]*networkv3.Server{platform}, &networkv3.Server{
+		Port: &networkv3.Port{
+			Name:     "https-removed-application",
+			Number:   443,
+			Protocol: "HTTPS",
+		},
+	})
+	unmanaged := g.UnmanagedServers(gateway)
+	if !reflect.DeepEqual(unmanaged, []*networkv3.Server{platform}) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", []*networkv3.Server{platform}, unmanaged)
+	}
+}
+
+func TestGatewayReconcile_IstioModes(t *testing.T) {
+	for _, mode := range []networkv3.Server_TLSOptions_TLSmode{networkv3.Server_TLSOptions_ISTIO_MUTUAL, networkv3.Server_TLSOptions_AUTO_PASSTHROUGH} {
+		gatewayserviceList := &appv1alpha1.GatewayServiceList{
+			Items: []appv1alpha1.GatewayService{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      name,
+						Namespace: namespace,
+					},
+					Spec: appv1alpha1.GatewayServiceSpec{
+						Hosts:       []string{"*"},
+						Mode:        mode.String(),
+						Port:        443,
+						Protocol:    "TLS",
+						TrafficType: "ingress",
+					},
+				},
+			},
+		}
+		gateway := &v1alpha3.Gateway{}
+		expected := &v1alpha3.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{g.ManagedServersAnnotation: tlsPort},
+			},
+			Spec: networkv3.Gateway{
+				Servers: []*networkv3.Server{
+					{
+						Port: &networkv3.Port{
+							Name:     tlsPort,
+							Number:   443,
+							Protocol: "TLS",
+						},
+						Hosts: []string{"*"},
+						Tls: &networkv3.Server_TLSOptions{
+							Mode: mode,
+						},
+					},
+				},
+			},
+		}
+		gatewayConfig := g.GatewayConfig{
+			Name:           fmt.Sprintf("%s-%s-gateway", namespace, trafficType),
+			TrafficType:    trafficType,
+			GatewayService: gatewayserviceList,
+			Gateway:        gateway,
+		}
+		gatewayObject := g.Reconcile(gatewayConfig)
+		if !reflect.DeepEqual(gatewayObject, expected) {
+			t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, gatewayObject)
+		}
+	}
+}
+
+func TestGatewayReconcile_TLSSecretRef_PASSTHROUGH(t *testing.T) {
+	gatewayserviceList := &appv1alpha1.GatewayServiceList{
+		Items: []appv1alpha1.GatewayService{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace,
+				},
+				Spec: appv1alpha1.GatewayServiceSpec{
+					Hosts:       []string{"*"},
+					Mode:        "PASSTHROUGH",
+					Port:        443,
+					Protocol:    "TLS",
+					TrafficType: "ingress",
+					TLSOptions: &appv1alpha1.TLSOptions{
+						TLSSecretRef: &appv1alpha1.TLSSecretRef{
+							SecretName: "example-secret",
+						},
+					},
+				},
+			},
+		},
+	}
+	gateway := &v1alpha3.Gateway{}
+	// The application secret is not added to the Gateway.
+	expected := &v1alpha3.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{g.ManagedServersAnnotation: tlsPort},
+		},
+		Spec: networkv3.Gateway{
+			Servers: []*networkv3.Server{
+				{
+					Port: &networkv3.Port{
+						Name:     tlsPort,
+						Number:   443,
+						Protocol: "TLS",
+					},
+					Hosts: []string{"*"},
+					Tls: &networkv3.Server_TLSOptions{
+						Mode: networkv3.Server_TLSOptions_PASSTHROUGH,
+					},
+				},
+			},
+		},
+	}
+	gatewayConfig := g.GatewayConfig{
+		Name:           fmt.Sprintf("%s-%s-gateway", namespace, trafficType),
+		TrafficType:    trafficType,
+		GatewayService: gatewayserviceList,
+		Gateway:        gateway,
+	}
+	gatewayObject := g.Reconcile(gatewayConfig)
+	if !reflect.DeepEqual(gatewayObject, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, gatewayObject)
+	}
+}
+
+func TestGatewayReconcile_LegacyNames(t *testing.T) {
+	legacy := appv1alpha1.GatewayService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "a-b",
+			Namespace: "c",
+		},
+		Spec: appv1alpha1.GatewayServiceSpec{
+			Hosts:       []string{"*"},
+			Mode:        "SIMPLE",
+			Port:        443,
+			Protocol:    "HTTPS",
+			TrafficType: "ingress",
+			TLSOptions: &appv1alpha1.TLSOptions{
+				TLSSecret: &appv1alpha1.TLSSecret{
+					Cert: &cert,
+					Key:  &key,
+				},
+			},
+		},
+		Status: appv1alpha1.GatewayServiceStatus{
+			Condition: appv1alpha1.Condition{
+				CreatedSecretDetails: appv1alpha1.CreatedSecretDetails{
+					SecretName: names.LegacySecret("a-b", "c"),
+				},
+			},
+		},
+	}
+	// The legacy names of this GatewayService collide with those of the GatewayService above.
+	colliding := *legacy.DeepCopy()
+	colliding.ObjectMeta = metav1.ObjectMeta{Name: "a", Namespace: "b-c"}
+	colliding.Status = appv1alpha1.GatewayServiceStatus{}
+	gateway := &v1alpha3.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{g.ManagedServersAnnotation: names.LegacyPort("HTTPS", "a-b", "c")},
+		},
+	}
+	gatewayConfig := g.GatewayConfig{
+		Name:           fmt.Sprintf("%s-%s-gateway", namespace, trafficType),
+		TrafficType:    trafficType,
+		GatewayService: &appv1alpha1.GatewayServiceList{Items: []appv1alpha1.GatewayService{legacy, colliding}},
+		Gateway:        gateway,
+	}
+	// Existing names are kept so the Gateway and secret do not churn, the GatewayService which would collide with
+	// them is given a unique name.
+	servers := g.Reconcile(gatewayConfig).Spec.Servers
+	expected := []string{names.LegacyPort("HTTPS", "a-b", "c"), names.Port("HTTPS", "a", "b-c")}
+	found := []string{servers[0].Port.Name, servers[1].Port.Name}
+	if !reflect.DeepEqual(found, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, found)
+	}
+	expected = []string{names.LegacySecret("a-b", "c"), names.Secret("a", "b-c")}
+	found = []string{servers[0].Tls.CredentialName, servers[1].Tls.CredentialName}
+	if !reflect.DeepEqual(found, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, found)
+	}
 }
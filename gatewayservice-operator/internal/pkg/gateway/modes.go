@@ -0,0 +1,41 @@
+package gateway
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// CRDName is the name of the Gateway CRD installed by Istio.
+const CRDName = "gateways.networking.istio.io"
+
+// modePath is the path of the server TLS mode within the schema of the Gateway.
+var modePath = []string{"properties", "spec", "properties", "servers", "items", "properties", "tls", "properties", "mode", "enum"}
+
+// CRDModes returns the TLS modes published by the schema of the v1alpha3 Gateway CRD installed by Istio, nil when the
+// CRD does not publish a schema as is the case for older Istio versions.
+func CRDModes(crd *unstructured.Unstructured) []string {
+	schemas := []interface{}{}
+	if schema, ok, _ := unstructured.NestedFieldNoCopy(crd.Object, "spec", "validation", "openAPIV3Schema"); ok {
+		schemas = append(schemas, schema)
+	}
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	for _, version := range versions {
+		v, ok := version.(map[string]interface{})
+		if !ok || v["name"] != "v1alpha3" {
+			continue
+		}
+		if schema, ok, _ := unstructured.NestedFieldNoCopy(v, "schema", "openAPIV3Schema"); ok {
+			schemas = append(schemas, schema)
+		}
+	}
+	for _, schema := range schemas {
+		s, ok := schema.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		modes, ok, _ := unstructured.NestedStringSlice(s, modePath...)
+		if ok {
+			return modes
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package gateway_test
+
+import (
+	"reflect"
+	"testing"
+
+	g "github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/gateway"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestModeSupported(t *testing.T) {
+	for _, mode := range []string{"SIMPLE", "PASSTHROUGH", "MUTUAL", "OPTIONAL_MUTUAL", "ISTIO_MUTUAL", "AUTO_PASSTHROUGH"} {
+		if !g.ModeSupported(mode) {
+			t.Fatalf("expected mode %s to be supported", mode)
+		}
+	}
+	if g.ModeSupported("UNKNOWN") {
+		t.Fatalf("expected mode UNKNOWN not to be supported")
+	}
+	if !g.Mutual("MUTUAL") || !g.Mutual("OPTIONAL_MUTUAL") || g.Mutual("ISTIO_MUTUAL") {
+		t.Fatalf("expected only MUTUAL and OPTIONAL_MUTUAL to authenticate clients with the CA certificates")
+	}
+}
+
+func TestCRDModes(t *testing.T) {
+	schema := map[string]interface{}{
+		"properties": map[string]interface{}{
+			"spec": map[string]interface{}{
+				"properties": map[string]interface{}{
+					"servers": map[string]interface{}{
+						"items": map[string]interface{}{
+							"properties": map[string]interface{}{
+								"tls": map[string]interface{}{
+									"properties": map[string]interface{}{
+										"mode": map[string]interface{}{
+											"enum": []interface{}{"PASSTHROUGH", "SIMPLE", "MUTUAL", "AUTO_PASSTHROUGH", "ISTIO_MUTUAL", "OPTIONAL_MUTUAL"},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	expected := []string{"PASSTHROUGH", "SIMPLE", "MUTUAL", "AUTO_PASSTHROUGH", "ISTIO_MUTUAL", "OPTIONAL_MUTUAL"}
+	crd := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"versions": []interface{}{
+				map[string]interface{}{"name": "v1beta1"},
+				map[string]interface{}{"name": "v1alpha3", "schema": map[string]interface{}{"openAPIV3Schema": schema}},
+			},
+		},
+	}}
+	modes := g.CRDModes(crd)
+	if !reflect.DeepEqual(modes, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, modes)
+	}
+	crd = &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"validation": map[string]interface{}{"openAPIV3Schema": schema},
+		},
+	}}
+	modes = g.CRDModes(crd)
+	if !reflect.DeepEqual(modes, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, modes)
+	}
+	// Older Istio versions do not publish a schema.
+	modes = g.CRDModes(&unstructured.Unstructured{Object: map[string]interface{}{"spec": map[string]interface{}{}}})
+	if modes != nil {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", nil, modes)
+	}
+}
@@ -0,0 +1,47 @@
+package gateway
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ParseNamespaces parses the namespaces of the gateway pods for each trafficType, EG.
+// "ingress:istio-ingress,egress:istio-egress". A trafficType may be repeated when its gateway pods run in several
+// namespaces.
+func ParseNamespaces(s string) (map[string][]string, error) {
+	namespaces := map[string][]string{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("namespace %q must be of the form trafficType:namespace", entry)
+		}
+		namespaces[parts[0]] = appendUnique(namespaces[parts[0]], parts[1])
+	}
+	return namespaces, nil
+}
+
+// PodNamespaces returns the namespaces the pods are running within, sorted.
+func PodNamespaces(pods []corev1.Pod) []string {
+	namespaces := []string{}
+	for _, pod := range pods {
+		namespaces = appendUnique(namespaces, pod.Namespace)
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,41 @@
+package gateway_test
+
+import (
+	"reflect"
+	"testing"
+
+	g "github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/gateway"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseNamespaces(t *testing.T) {
+	namespaces, err := g.ParseNamespaces("ingress:istio-ingress, egress:gateways-external,egress:istio-egress,egress:istio-egress")
+	if err != nil {
+		t.Fatalf("parse namespaces: (%v)", err)
+	}
+	expected := map[string][]string{
+		"ingress": {"istio-ingress"},
+		"egress":  {"gateways-external", "istio-egress"},
+	}
+	if !reflect.DeepEqual(namespaces, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, namespaces)
+	}
+	_, err = g.ParseNamespaces("istio-ingress")
+	if err == nil {
+		t.Fatalf("expected a namespace without a trafficType to be rejected")
+	}
+}
+
+func TestPodNamespaces(t *testing.T) {
+	pods := []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "istio-ingress"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "gateways-external"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "c", Namespace: "istio-ingress"}},
+	}
+	expected := []string{"gateways-external", "istio-ingress"}
+	namespaces := g.PodNamespaces(pods)
+	if !reflect.DeepEqual(namespaces, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, namespaces)
+	}
+}
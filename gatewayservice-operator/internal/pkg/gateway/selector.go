@@ -0,0 +1,50 @@
+package gateway
+
+import (
+	"fmt"
+	"strings"
+
+	networkv3 "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ParseSelectors parses the selector of the gateway pods for each trafficType, EG.
+// "ingress:istio=ingressgateway,egress:istio=egressgateway". A trafficType may be repeated to select on several labels.
+func ParseSelectors(s string) (map[string]map[string]string, error) {
+	selectors := map[string]map[string]string{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("selector %q must be of the form trafficType:label=value", entry)
+		}
+		label := strings.SplitN(parts[1], "=", 2)
+		if parts[0] == "" || len(label) != 2 || label[0] == "" {
+			return nil, fmt.Errorf("selector %q must be of the form trafficType:label=value", entry)
+		}
+		if selectors[parts[0]] == nil {
+			selectors[parts[0]] = map[string]string{}
+		}
+		selectors[parts[0]][label[0]] = label[1]
+	}
+	return selectors, nil
+}
+
+// New returns a Gateway with the name within the namespace, without any servers. The Gateway is marked as created by
+// the operator.
+func New(name string, namespace string, selector map[string]string) *v1alpha3.Gateway {
+	return &v1alpha3.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Annotations: map[string]string{CreatedAnnotation: "true"},
+		},
+		Spec: networkv3.Gateway{
+			Selector: selector,
+		},
+	}
+}
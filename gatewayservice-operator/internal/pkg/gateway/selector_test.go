@@ -0,0 +1,26 @@
+package gateway_test
+
+import (
+	"reflect"
+	"testing"
+
+	g "github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/gateway"
+)
+
+func TestParseSelectors(t *testing.T) {
+	selectors, err := g.ParseSelectors("ingress:istio=ingressgateway, egress:istio=egressgateway,egress:app=egress")
+	if err != nil {
+		t.Fatalf("parse selectors: (%v)", err)
+	}
+	expected := map[string]map[string]string{
+		"ingress": {"istio": "ingressgateway"},
+		"egress":  {"istio": "egressgateway", "app": "egress"},
+	}
+	if !reflect.DeepEqual(selectors, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, selectors)
+	}
+	_, err = g.ParseSelectors("ingress=istio")
+	if err == nil {
+		t.Fatalf("expected a selector without a trafficType to be rejected")
+	}
+}
@@ -1,12 +1,16 @@
 package gateway
 
 import (
-	"fmt"
-
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/provider"
 	appv1alpha1 "github.com/xunholy/k8s-istio-gateway-service-operator/pkg/apis/crd/v1alpha1"
 	networkv3 "istio.io/api/networking/v1alpha3"
 )
 
+// OptionalMutual is the OPTIONAL_MUTUAL mode, which newer Istio releases define but the vendored Istio API predates.
+// Enum values unknown to the vendored API are written to the Gateway as their number, which Istio reads back as
+// OPTIONAL_MUTUAL. Istio releases without the mode are reported by the modes of their Gateway CRD.
+const OptionalMutual networkv3.Server_TLSOptions_TLSmode = 5
+
 func TlsMode(mode string) networkv3.Server_TLSOptions_TLSmode {
 	switch mode {
 
@@ -25,6 +29,12 @@ func TlsMode(mode string) networkv3.Server_TLSOptions_TLSmode {
 		// server certificates for authentication.
 		return networkv3.Server_TLSOptions_MUTUAL
 
+	case "OPTIONAL_MUTUAL":
+		// Similar to MUTUAL mode, except that the client certificate is
+		// optional. Clients which present a certificate are validated
+		// against the CA certificates.
+		return OptionalMutual
+
 	case "AUTO_PASSTHROUGH":
 		// Similar to the passthrough mode, except servers with this TLS mode
 		// do not require an associated VirtualService to map from the SNI
@@ -48,93 +58,85 @@ func TlsMode(mode string) networkv3.Server_TLSOptions_TLSmode {
 		return networkv3.Server_TLSOptions_ISTIO_MUTUAL
 
 	default:
-		// Incorrect Mode was specified, this doesn't get checked as it's already
-		// being validated in the CRD gatewayservice_types.go enum check.
+		// Incorrect Mode was specified, this is reported by ModeSupported.
 		return -1
 	}
 }
 
+// ModeSupported reports whether the mode is defined by the Istio API of the operator.
+func ModeSupported(mode string) bool {
+	return TlsMode(mode) >= 0
+}
+
+// Mutual reports whether the mode authenticates clients with the CA certificates, such as MUTUAL and OPTIONAL_MUTUAL.
+func Mutual(mode string) bool {
+	return mode == "MUTUAL" || mode == "OPTIONAL_MUTUAL"
+}
+
 func ServerTlsConfig(gatewayservice appv1alpha1.GatewayService) *networkv3.Server_TLSOptions {
 	tlsMode := TlsMode(gatewayservice.Spec.Mode)
-	if tlsMode == networkv3.Server_TLSOptions_SIMPLE || tlsMode == networkv3.Server_TLSOptions_MUTUAL {
-		if gatewayservice.Spec.TLSOptions != nil {
-			if gatewayservice.Spec.TLSOptions.TLSSecretPath != nil {
-				// TODO: This would require the Istio GW pod to be restarted to pickup secrets
-				// Restart pod using respective labels for ingres/egress and bounce pods based
-				// of a strategic percentage for optimization, perhaps include a grace period.
-				// https://github.com/xUnholy/k8s-istio-gateway-service-operator/issues/17
-				return &networkv3.Server_TLSOptions{
-					// REQUIRED if mode is "SIMPLE" or "MUTUAL". The path to the file
-					// holding the server-side TLS certificate to use.
-					ServerCertificate: gatewayservice.Spec.TLSOptions.TLSSecretPath.CertPath,
-
-					// REQUIRED if mode is "SIMPLE" or "MUTUAL". The path to the file
-					// holding the server's private key.
-					PrivateKey: gatewayservice.Spec.TLSOptions.TLSSecretPath.KeyPath,
-
-					// Optional: Indicates whether connections to this port should be
-					// secured using TLS. The value of this field determines how TLS is
-					// enforced.
-					Mode: tlsMode,
-				}
-			}
-			if gatewayservice.Spec.TLSOptions.TLSSecretRef != nil {
-				// If the secret has already been applied to the K8s cluster and the operator does not need to
-				// create the secret then a tlsSecretRef can be used which references the secret. There is an
-				// assumption the Gateway has access to the secret references and that it exists prior to being
-				// referenced.
-				return &networkv3.Server_TLSOptions{
-					CredentialName: gatewayservice.Spec.TLSOptions.TLSSecretRef.SecretName,
-
-					// Optional: Indicates whether connections to this port should be
-					// secured using TLS. The value of this field determines how TLS is
-					// enforced.
-					Mode: tlsMode,
-				}
-			}
-			if gatewayservice.Spec.TLSOptions.TLSSecret != nil {
-				return &networkv3.Server_TLSOptions{
-					// The credentialName stands for a unique identifier that can be used
-					// to identify the serverCertificate and the privateKey. The
-					// credentialName appended with suffix "-cacert" is used to identify
-					// the CaCertificates associated with this server. Gateway workloads
-					// capable of fetching credentials from a remote credential store such
-					// as Kubernetes secrets, will be configured to retrieve the
-					// serverCertificate and the privateKey using credentialName, instead
-					// of using the file system paths specified above. If using mutual TLS,
-					// gateway workload instances will retrieve the CaCertificates using
-					// credentialName-cacert. The semantics of the name are platform
-					// dependent.  In Kubernetes, the default Istio supplied credential
-					// server expects the credentialName to match the name of the
-					// Kubernetes secret that holds the server certificate, the private
-					// key, and the CA certificate (if using mutual TLS). Set the
-					// `ISTIO_META_USER_SDS` metadata variable in the gateway's proxy to
-					// enable the dynamic credential fetching feature.
-					CredentialName: fmt.Sprintf("%s-%s-secret", gatewayservice.ObjectMeta.Name, gatewayservice.ObjectMeta.Namespace),
-
-					// Optional: Indicates whether connections to this port should be
-					// secured using TLS. The value of this field determines how TLS is
-					// enforced.
-					Mode: tlsMode,
-				}
-			}
+	if tlsMode == networkv3.Server_TLSOptions_ISTIO_MUTUAL || tlsMode == networkv3.Server_TLSOptions_AUTO_PASSTHROUGH {
+		// The certificates are issued by Istio, so only the mode is rendered and all other fields must be empty.
+		return &networkv3.Server_TLSOptions{
+			Mode: tlsMode,
 		}
 	}
-	// If PASSTHROUGH mode is being used, TLSSecretPath and TLSSecretRef are currently not supported.
-	if tlsMode == networkv3.Server_TLSOptions_PASSTHROUGH {
-		if gatewayservice.Spec.TLSOptions != nil {
-			if gatewayservice.Spec.TLSOptions.TLSSecret != nil {
-				return &networkv3.Server_TLSOptions{
-					CredentialName: fmt.Sprintf("%s-%s-secret", gatewayservice.ObjectMeta.Name, gatewayservice.ObjectMeta.Namespace),
-
-					// Optional: Indicates whether connections to this port should be
-					// secured using TLS. The value of this field determines how TLS is
-					// enforced.
-					Mode: tlsMode,
-				}
-
+	if tlsMode != networkv3.Server_TLSOptions_SIMPLE && !Mutual(gatewayservice.Spec.Mode) && tlsMode != networkv3.Server_TLSOptions_PASSTHROUGH {
+		return nil
+	}
+	_, p := provider.For(gatewayservice.Spec.TLSOptions)
+	var credential *provider.Credential
+	if p != nil {
+		credential = p.Credential(gatewayservice, tlsMode)
+	}
+	if credential == nil {
+		if tlsMode == networkv3.Server_TLSOptions_PASSTHROUGH {
+			// The application terminates TLS with its own secret, which is never added to the Gateway.
+			return &networkv3.Server_TLSOptions{
+				Mode: tlsMode,
 			}
 		}
+		return nil
+	}
+	var caCertificates string
+	if Mutual(gatewayservice.Spec.Mode) && gatewayservice.Spec.CaCertificates != nil {
+		caCertificates = *gatewayservice.Spec.CaCertificates
+	}
+	return &networkv3.Server_TLSOptions{
+		// REQUIRED if mode is "SIMPLE" or "MUTUAL" and no credentialName is set. The path to the file
+		// holding the server-side TLS certificate to use.
+		ServerCertificate: credential.CertPath,
+
+		// REQUIRED if mode is "SIMPLE" or "MUTUAL" and no credentialName is set. The path to the file
+		// holding the server's private key.
+		PrivateKey: credential.KeyPath,
+
+		// REQUIRED if mode is "MUTUAL" or "OPTIONAL_MUTUAL" and no credentialName is set. The path to
+		// a file containing certificate authority certificates to use in verifying a presented client
+		// side certificate.
+		CaCertificates: caCertificates,
+
+		// The credentialName stands for a unique identifier that can be used
+		// to identify the serverCertificate and the privateKey. The
+		// credentialName appended with suffix "-cacert" is used to identify
+		// the CaCertificates associated with this server. Gateway workloads
+		// capable of fetching credentials from a remote credential store such
+		// as Kubernetes secrets, will be configured to retrieve the
+		// serverCertificate and the privateKey using credentialName, instead
+		// of using the file system paths specified above. If using mutual TLS,
+		// gateway workload instances will retrieve the CaCertificates using
+		// credentialName-cacert. The semantics of the name are platform
+		// dependent.  In Kubernetes, the default Istio supplied credential
+		// server expects the credentialName to match the name of the
+		// Kubernetes secret that holds the server certificate, the private
+		// key, and the CA certificate (if using mutual TLS). Set the
+		// `ISTIO_META_USER_SDS` metadata variable in the gateway's proxy to
+		// enable the dynamic credential fetching feature.
+		CredentialName: credential.Name,
+
+		// Optional: Indicates whether connections to this port should be
+		// secured using TLS. The value of this field determines how TLS is
+		// enforced.
+		Mode: tlsMode,
 	}
-	return nil
 }
@@ -0,0 +1,147 @@
+package host
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/idna"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Host is a host of a GatewayService, optionally qualified by the namespace of the VirtualServices it is exposed to
+// using Istio's "namespace/host" syntax.
+type Host struct {
+	// Namespace is empty when the host is exposed to every namespace, "." for the namespace of the Gateway, or the
+	// name of a namespace.
+	Namespace string
+	// Name is the canonical domain name, which may have a wildcard prefix.
+	Name string
+}
+
+// String returns the host in the form rendered into the Gateway.
+func (h Host) String() string {
+	if h.Namespace == "" {
+		return h.Name
+	}
+	return h.Namespace + "/" + h.Name
+}
+
+// Parse returns the canonical form of a host. The name is lowercased, stripped of a trailing dot and converted to
+// punycode when internationalized, then checked against RFC 1123. A wildcard is only permitted as the whole name or as
+// the "*." prefix. The "*/" qualifier exposes the host to every namespace, the same as no qualifier, so it is dropped.
+func Parse(s string) (Host, error) {
+	h := Host{Name: s}
+	if i := strings.Index(s, "/"); i >= 0 {
+		h.Namespace, h.Name = s[:i], s[i+1:]
+		switch h.Namespace {
+		case "*":
+			h.Namespace = ""
+		case ".":
+		default:
+			if errs := validation.IsDNS1123Label(h.Namespace); len(errs) > 0 {
+				return Host{}, fmt.Errorf("host %q names an invalid namespace: %s", s, strings.Join(errs, ", "))
+			}
+		}
+	}
+	name := strings.ToLower(strings.TrimSuffix(h.Name, "."))
+	if name == "*" {
+		h.Name = name
+		return h, nil
+	}
+	wildcard := strings.HasPrefix(name, "*.")
+	name = strings.TrimPrefix(name, "*.")
+	ascii, err := idna.Lookup.ToASCII(name)
+	if err != nil {
+		return Host{}, fmt.Errorf("host %q is not a valid domain name: %v", s, err)
+	}
+	if len(ascii) > validation.DNS1123SubdomainMaxLength {
+		return Host{}, fmt.Errorf("host %q must be no more than %d characters", s, validation.DNS1123SubdomainMaxLength)
+	}
+	for _, label := range strings.Split(ascii, ".") {
+		if errs := validation.IsDNS1123Label(label); len(errs) > 0 {
+			return Host{}, fmt.Errorf("host %q is not a valid domain name, wildcards are only permitted as a \"*.\" prefix: %s", s, strings.Join(errs, ", "))
+		}
+	}
+	if wildcard {
+		ascii = "*." + ascii
+	}
+	h.Name = ascii
+	return h, nil
+}
+
+// Canonicalize parses every host, returning their canonical forms in order with duplicates removed.
+func Canonicalize(hosts []string) ([]string, error) {
+	seen := map[string]bool{}
+	result := []string{}
+	for _, s := range hosts {
+		h, err := Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		if seen[h.String()] {
+			continue
+		}
+		seen[h.String()] = true
+		result = append(result, h.String())
+	}
+	return result, nil
+}
+
+// Names returns the domain names of the hosts without their namespaces, with duplicates removed.
+func Names(hosts []string) []string {
+	seen := map[string]bool{}
+	result := []string{}
+	for _, s := range hosts {
+		name := s
+		if i := strings.Index(s, "/"); i >= 0 {
+			name = s[i+1:]
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, name)
+	}
+	return result
+}
+
+// Policy decides which namespaces the hosts of a GatewayService may be qualified with. Hosts may always be exposed to
+// every namespace, or to the namespace of the GatewayService, which is also the namespace of its Gateway.
+type Policy struct {
+	// Namespaces which may be named by the hosts of any GatewayService, "*" allows every namespace.
+	Namespaces []string
+}
+
+// Allows reports an error when a host of a GatewayService within the namespace names a namespace which is not allowed.
+func (p Policy) Allows(h Host, namespace string) error {
+	switch h.Namespace {
+	case "", ".", namespace:
+		return nil
+	}
+	for _, n := range p.Namespaces {
+		if n == "*" || n == h.Namespace {
+			return nil
+		}
+	}
+	return fmt.Errorf("host %s names namespace %s, which is not allowed by the operator config", h, h.Namespace)
+}
+
+// Normalize canonicalizes the hosts of a GatewayService within the namespace, reporting an error when a host is invalid
+// or names a namespace the policy does not allow.
+func Normalize(hosts []string, namespace string, policy Policy) ([]string, error) {
+	canonical, err := Canonicalize(hosts)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range canonical {
+		h, err := Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		err = policy.Allows(h, namespace)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return canonical, nil
+}
@@ -0,0 +1,89 @@
+package host_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/host"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]host.Host{
+		"Example.COM":             {Name: "example.com"},
+		"example.com.":            {Name: "example.com"},
+		"*":                       {Name: "*"},
+		"*.Example.com":           {Name: "*.example.com"},
+		"bücher.example":          {Name: "xn--bcher-kva.example"},
+		"xn--bcher-kva.example":   {Name: "xn--bcher-kva.example"},
+		"*/example.com":           {Name: "example.com"},
+		"./example.com":           {Namespace: ".", Name: "example.com"},
+		"application/example.com": {Namespace: "application", Name: "example.com"},
+		"shared/*.example.com":    {Namespace: "shared", Name: "*.example.com"},
+	}
+	for s, expected := range tests {
+		found, err := host.Parse(s)
+		if err != nil {
+			t.Fatalf("parse (%+v): (%v)", s, err)
+		}
+		if !reflect.DeepEqual(found, expected) {
+			t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, found)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"example..com",
+		"-example.com",
+		"example_app.com",
+		"foo.*.example.com",
+		"*example.com",
+		"Shared/example.com",
+		"/example.com",
+		"a/b/example.com",
+		"this-label-is-longer-than-sixty-three-characters-which-rfc-1123-forbids.example.com",
+	}
+	for _, s := range tests {
+		_, err := host.Parse(s)
+		if err == nil {
+			t.Fatalf("expected host (%+v) to be invalid", s)
+		}
+	}
+}
+
+func TestCanonicalize(t *testing.T) {
+	hosts := []string{"Example.com", "example.com.", "*/example.com", "./example.com", "bücher.example"}
+	expected := []string{"example.com", "./example.com", "xn--bcher-kva.example"}
+	found, err := host.Canonicalize(hosts)
+	if err != nil {
+		t.Fatalf("canonicalize: (%v)", err)
+	}
+	if !reflect.DeepEqual(found, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, found)
+	}
+	if names := host.Names(found); !reflect.DeepEqual(names, []string{"example.com", "xn--bcher-kva.example"}) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", []string{"example.com", "xn--bcher-kva.example"}, names)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	hosts := []string{"application/example.com", "./example.com", "example.com"}
+	_, err := host.Normalize(hosts, "application", host.Policy{})
+	if err != nil {
+		t.Fatalf("expected the namespace of the GatewayService to be allowed, found (%v)", err)
+	}
+
+	hosts = []string{"shared/example.com"}
+	_, err = host.Normalize(hosts, "application", host.Policy{})
+	expected := "host shared/example.com names namespace shared, which is not allowed by the operator config"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, err)
+	}
+	for _, policy := range []host.Policy{{Namespaces: []string{"shared"}}, {Namespaces: []string{"*"}}} {
+		_, err = host.Normalize(hosts, "application", policy)
+		if err != nil {
+			t.Fatalf("expected policy (%+v) to allow namespace shared, found (%v)", policy, err)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package index
+
+import (
+	"context"
+
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/config"
+	appv1alpha1 "github.com/xunholy/k8s-istio-gateway-service-operator/pkg/apis/crd/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// SecretNameField indexes GatewayServices by the names of the secrets they reference.
+	SecretNameField = "spec.secretName"
+	// GatewayField indexes GatewayServices by the name of the Gateway recorded as rendering them within their namespace,
+	// empty until it is recorded.
+	GatewayField = "status.gateway"
+)
+
+type entry struct {
+	field   string
+	indexer client.IndexerFunc
+}
+
+var indexes = []entry{
+	{field: SecretNameField, indexer: SecretNames},
+	{field: GatewayField, indexer: Gateway},
+}
+
+// Register adds every GatewayService index to the cache of the manager. Indexes must be registered before the cache
+// is started.
+func Register(i client.FieldIndexer) error {
+	for _, e := range indexes {
+		err := i.IndexField(&appv1alpha1.GatewayService{}, e.field, e.indexer)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Gateway returns the name of the Gateway recorded as rendering the GatewayService, which is empty until the spec passes
+// validation.
+func Gateway(obj runtime.Object) []string {
+	gs, ok := obj.(*appv1alpha1.GatewayService)
+	if !ok {
+		return nil
+	}
+	return []string{gs.Status.Gateway}
+}
+
+// GatewayName returns the name of the Gateway rendering the GatewayService. GatewayServices reconciled before the
+// name was recorded are rendered by the Gateway the operator config names for their trafficType, until the name is
+// recorded by their next reconcile.
+func GatewayName(cfg config.Config, gs *appv1alpha1.GatewayService) string {
+	if gs.Status.Gateway != "" {
+		return gs.Status.Gateway
+	}
+	trafficType := gs.Spec.TrafficType
+	if gs.Status.ValidSpec != nil {
+		trafficType = gs.Status.ValidSpec.TrafficType
+	}
+	if trafficType == "" {
+		return ""
+	}
+	return cfg.GatewayName(gs.Namespace, trafficType)
+}
+
+// Rendered returns the GatewayServices within the namespace rendered by the Gateway named name, as returned by
+// GatewayName.
+func Rendered(c client.Client, cfg config.Config, namespace string, name string) (*appv1alpha1.GatewayServiceList, error) {
+	gatewayservices, err := List(c, namespace, GatewayField, name)
+	if err != nil {
+		return nil, err
+	}
+	unrecorded, err := List(c, namespace, GatewayField, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, gs := range unrecorded.Items {
+		if GatewayName(cfg, &gs) == name {
+			gatewayservices.Items = append(gatewayservices.Items, gs)
+		}
+	}
+	return gatewayservices, nil
+}
+
+// SecretNames returns the names of the secrets referenced by the GatewayService, which are created by someone else.
+func SecretNames(obj runtime.Object) []string {
+	gs, ok := obj.(*appv1alpha1.GatewayService)
+	if !ok || gs.Spec.TLSOptions == nil {
+		return nil
+	}
+	names := []string{}
+	if ref := gs.Spec.TLSOptions.TLSSecretRef; ref != nil && ref.SecretName != "" {
+		names = append(names, ref.SecretName)
+	}
+	if path := gs.Spec.TLSOptions.TLSSecretPath; path != nil && path.SecretName != "" {
+		names = append(names, path.SecretName)
+	}
+	return names
+}
+
+// List returns the GatewayServices within the namespace, or every namespace if empty, with the value for the indexed
+// field. The items are filtered again as clients without the index, such as the fake client, ignore the selector.
+func List(c client.Client, namespace string, field string, value string) (*appv1alpha1.GatewayServiceList, error) {
+	gatewayservices := &appv1alpha1.GatewayServiceList{}
+	listOps := client.MatchingField(field, value)
+	listOps.Namespace = namespace
+	err := c.List(context.TODO(), listOps, gatewayservices)
+	if err != nil {
+		return nil, err
+	}
+	items := []appv1alpha1.GatewayService{}
+	for _, gs := range gatewayservices.Items {
+		if matches(&gs, field, value) {
+			items = append(items, gs)
+		}
+	}
+	gatewayservices.Items = items
+	return gatewayservices, nil
+}
+
+func matches(gs *appv1alpha1.GatewayService, field string, value string) bool {
+	for _, e := range indexes {
+		if e.field != field {
+			continue
+		}
+		for _, v := range e.indexer(gs) {
+			if v == value {
+				return true
+			}
+		}
+	}
+	return false
+}
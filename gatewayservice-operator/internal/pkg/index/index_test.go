@@ -0,0 +1,134 @@
+package index_test
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/config"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/index"
+	appv1alpha1 "github.com/xunholy/k8s-istio-gateway-service-operator/pkg/apis/crd/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var (
+	name      = "example-app"
+	namespace = "application"
+)
+
+// indexer records the fields registered.
+type indexer struct {
+	fields []string
+}
+
+func (i *indexer) IndexField(obj runtime.Object, field string, extractValue client.IndexerFunc) error {
+	i.fields = append(i.fields, field)
+	return nil
+}
+
+func newGatewayService(name string, trafficType string, tlsOptions *appv1alpha1.TLSOptions) *appv1alpha1.GatewayService {
+	return &appv1alpha1.GatewayService{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: appv1alpha1.GatewayServiceSpec{
+			TrafficType: trafficType,
+			TLSOptions:  tlsOptions,
+		},
+	}
+}
+
+func TestIndexRegister(t *testing.T) {
+	i := &indexer{}
+	err := index.Register(i)
+	if err != nil {
+		t.Fatalf("register: (%v)", err)
+	}
+	expected := []string{index.SecretNameField, index.GatewayField}
+	if !reflect.DeepEqual(i.fields, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, i.fields)
+	}
+}
+
+func TestIndexValues(t *testing.T) {
+	gs := newGatewayService(name, "ingress", &appv1alpha1.TLSOptions{
+		TLSSecretRef: &appv1alpha1.TLSSecretRef{SecretName: "example-secret"},
+	})
+	if found := index.SecretNames(gs); !reflect.DeepEqual(found, []string{"example-secret"}) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", []string{"example-secret"}, found)
+	}
+	if found := index.SecretNames(newGatewayService(name, "ingress", nil)); len(found) != 0 {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", []string{}, found)
+	}
+
+	// GatewayServices are indexed by the Gateway recorded, empty until it is recorded.
+	if found := index.Gateway(gs); !reflect.DeepEqual(found, []string{""}) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", []string{""}, found)
+	}
+	gs.Status.Gateway = "ingress-application"
+	if found := index.Gateway(gs); !reflect.DeepEqual(found, []string{"ingress-application"}) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", []string{"ingress-application"}, found)
+	}
+}
+
+func TestIndexGatewayName(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.GatewayNameTemplate = "{{.TrafficType}}-{{.Namespace}}"
+	gs := newGatewayService(name, "ingress", nil)
+
+	// GatewayServices reconciled before the Gateway was recorded are rendered by the Gateway named by the config.
+	if found := index.GatewayName(cfg, gs); found != "ingress-application" {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", "ingress-application", found)
+	}
+	gs.Status.Gateway = "application-ingress-gateway"
+	if found := index.GatewayName(cfg, gs); found != "application-ingress-gateway" {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", "application-ingress-gateway", found)
+	}
+}
+
+func TestIndexList(t *testing.T) {
+	ingress := newGatewayService(name, "ingress", nil)
+	ingress.Status.Gateway = "ingress-application"
+	egress := newGatewayService("other", "egress", nil)
+	egress.Status.Gateway = "egress-application"
+	s := scheme.Scheme
+	s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, ingress, &appv1alpha1.GatewayServiceList{})
+	cl := fake.NewFakeClient(ingress, egress)
+
+	// The fake client ignores the field selector, the GatewayServices are filtered by the index.
+	found, err := index.List(cl, namespace, index.GatewayField, "ingress-application")
+	if err != nil {
+		t.Fatalf("list: (%v)", err)
+	}
+	if len(found.Items) != 1 || found.Items[0].Name != name {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", name, found.Items)
+	}
+}
+
+func TestIndexRendered(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.GatewayNameTemplate = "{{.TrafficType}}-{{.Namespace}}"
+	recorded := newGatewayService(name, "ingress", nil)
+	recorded.Status.Gateway = "ingress-application"
+	unrecorded := newGatewayService("unrecorded", "ingress", nil)
+	egress := newGatewayService("other", "egress", nil)
+	s := scheme.Scheme
+	s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, recorded, &appv1alpha1.GatewayServiceList{})
+	cl := fake.NewFakeClient(recorded, unrecorded, egress)
+
+	found, err := index.Rendered(cl, cfg, namespace, "ingress-application")
+	if err != nil {
+		t.Fatalf("list: (%v)", err)
+	}
+	names := []string{}
+	for _, gs := range found.Items {
+		names = append(names, gs.Name)
+	}
+	sort.Strings(names)
+	expected := []string{name, "unrecorded"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, names)
+	}
+}
@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// CertificateExpiry exports the time at which the certificate served for each GatewayService host expires.
+	CertificateExpiry = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gatewayservice_certificate_expiry_seconds",
+			Help: "Unix time in seconds at which the certificate served for a GatewayService host expires.",
+		},
+		[]string{"namespace", "name", "host"},
+	)
+
+	// ReconcileTotal counts the reconciles of GatewayServices by outcome.
+	ReconcileTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gatewayservice_reconcile_total",
+			Help: "Number of GatewayService reconciles by result: success, permanent_error or transient_error.",
+		},
+		[]string{"result"},
+	)
+
+	// certificateHosts tracks the hosts exported for each GatewayService so series for removed hosts are deleted.
+	certificateHosts = map[string][]string{}
+	mu               sync.Mutex
+)
+
+func init() {
+	// Register custom metrics with the global prometheus registry served by the manager.
+	ctrlmetrics.Registry.MustRegister(CertificateExpiry, ReconcileTotal)
+}
+
+const (
+	// ResultSuccess is a reconcile which completed, the GatewayService is reconciled again on the resync interval.
+	ResultSuccess = "success"
+	// ResultPermanentError is a reconcile which failed due to the GatewayService spec and is not retried.
+	ResultPermanentError = "permanent_error"
+	// ResultTransientError is a reconcile which failed and is retried with exponential backoff.
+	ResultTransientError = "transient_error"
+)
+
+// RecordReconcile counts a reconcile with the given result.
+func RecordReconcile(result string) {
+	ReconcileTotal.WithLabelValues(result).Inc()
+}
+
+// SetCertificateExpiry exports the certificate expiry for every host of the GatewayService.
+func SetCertificateExpiry(namespace string, name string, hosts []string, notAfter time.Time) {
+	mu.Lock()
+	defer mu.Unlock()
+	deleteCertificateExpiry(namespace, name)
+	for _, host := range hosts {
+		CertificateExpiry.WithLabelValues(namespace, name, host).Set(float64(notAfter.Unix()))
+	}
+	certificateHosts[namespace+"/"+name] = append([]string{}, hosts...)
+}
+
+// DeleteCertificateExpiry removes the certificate expiry exported for the GatewayService.
+func DeleteCertificateExpiry(namespace string, name string) {
+	mu.Lock()
+	defer mu.Unlock()
+	deleteCertificateExpiry(namespace, name)
+}
+
+func deleteCertificateExpiry(namespace string, name string) {
+	for _, host := range certificateHosts[namespace+"/"+name] {
+		CertificateExpiry.DeleteLabelValues(namespace, name, host)
+	}
+	delete(certificateHosts, namespace+"/"+name)
+}
@@ -0,0 +1,43 @@
+package metrics_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	m "github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/metrics"
+)
+
+var (
+	name      = "example-app"
+	namespace = "application"
+)
+
+func TestCertificateExpiry(t *testing.T) {
+	notAfter := time.Now().Add(24 * time.Hour)
+	m.SetCertificateExpiry(namespace, name, []string{"a.example.com", "b.example.com"}, notAfter)
+	value := testutil.ToFloat64(m.CertificateExpiry.WithLabelValues(namespace, name, "a.example.com"))
+	if value != float64(notAfter.Unix()) {
+		t.Fatalf("Expected: (%v) \n Found: (%v)", float64(notAfter.Unix()), value)
+	}
+
+	// Hosts removed from the GatewayService are no longer exported.
+	m.SetCertificateExpiry(namespace, name, []string{"a.example.com"}, notAfter)
+	if m.CertificateExpiry.DeleteLabelValues(namespace, name, "b.example.com") {
+		t.Fatalf("expected series for removed host b.example.com to be deleted")
+	}
+
+	m.DeleteCertificateExpiry(namespace, name)
+	if m.CertificateExpiry.DeleteLabelValues(namespace, name, "a.example.com") {
+		t.Fatalf("expected series for host a.example.com to be deleted")
+	}
+}
+
+func TestRecordReconcile(t *testing.T) {
+	before := testutil.ToFloat64(m.ReconcileTotal.WithLabelValues(m.ResultPermanentError))
+	m.RecordReconcile(m.ResultPermanentError)
+	value := testutil.ToFloat64(m.ReconcileTotal.WithLabelValues(m.ResultPermanentError))
+	if value != before+1 {
+		t.Fatalf("Expected: (%v) \n Found: (%v)", before+1, value)
+	}
+}
@@ -0,0 +1,194 @@
+package mount
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/names"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// VolumePrefix identifies the volumes on the gateway Deployment which were managed by the operator before ownership
+// was recorded by the ManagedVolumesAnnotation.
+const VolumePrefix = "gatewayservice-"
+
+// ManagedVolumesAnnotation lists the names of the volumes on the gateway Deployment owned by the operator. Volumes not
+// listed were added by someone else and are left untouched.
+const ManagedVolumesAnnotation = "crd.xunholy.github.com/managed-volumes"
+
+// Mount is a secret mounted into the gateway pods for a GatewayService using TLSSecretPath.
+type Mount struct {
+	// Name of the GatewayService, used to name the volume.
+	Name       string
+	Namespace  string
+	SecretName string
+	MountPath  string
+}
+
+type MountConfig struct {
+	Deployment *appsv1.Deployment
+	Mounts     []Mount
+}
+
+// ForPaths returns the mount for the cert and key paths, the files must share a directory as the secret is mounted
+// at that directory.
+func ForPaths(name string, namespace string, secretName string, certPath string, keyPath string) (Mount, error) {
+	if filepath.Dir(certPath) != filepath.Dir(keyPath) {
+		return Mount{}, fmt.Errorf("certPath %s and keyPath %s must be within the same directory to mount secret %s", certPath, keyPath, secretName)
+	}
+	if !filepath.IsAbs(certPath) || filepath.Dir(certPath) == "/" {
+		return Mount{}, fmt.Errorf("certPath %s must be an absolute path within a directory to mount secret %s", certPath, secretName)
+	}
+	return Mount{Name: name, Namespace: namespace, SecretName: secretName, MountPath: filepath.Dir(certPath)}, nil
+}
+
+// VolumeName returns the name of the volume managed for the mount, which is unique to the GatewayService.
+func VolumeName(m Mount) string {
+	return names.Volume(m.Name, m.Namespace)
+}
+
+// Unique returns the mounts with distinct mount paths, keeping the first mount of each path. An error is returned for
+// every other mount, keyed by its GatewayService, as a path can only be mounted once within the gateway pods.
+func Unique(mounts []Mount) ([]Mount, map[types.NamespacedName]error) {
+	mounted := map[string]Mount{}
+	unique := []Mount{}
+	rejected := map[types.NamespacedName]error{}
+	for _, m := range mounts {
+		if first, ok := mounted[m.MountPath]; ok {
+			rejected[types.NamespacedName{Name: m.Name, Namespace: m.Namespace}] = fmt.Errorf(
+				"mountPath %s of secret %s is already mounted for GatewayService %s/%s", m.MountPath, m.SecretName, first.Namespace, first.Name)
+			continue
+		}
+		mounted[m.MountPath] = m
+		unique = append(unique, m)
+	}
+	return unique, rejected
+}
+
+// Reconcile returns a strategic merge patch which adds the volume and volumeMount of every mount to the gateway
+// Deployment and removes the managed volumes which are no longer required. The mounts must cover every GatewayService
+// served by the Deployment, as the owned volumes of the others are otherwise removed. Fields not managed by the
+// operator are left untouched. A nil patch is returned when the Deployment is up to date.
+func Reconcile(m MountConfig) ([]byte, error) {
+	podSpec := m.Deployment.Spec.Template.Spec
+	container := gatewayContainer(podSpec)
+	if container == nil {
+		return nil, fmt.Errorf("deployment %s/%s has no containers", m.Deployment.Namespace, m.Deployment.Name)
+	}
+	owned := ownedVolumes(m.Deployment)
+
+	existingVolumes := map[string]corev1.Volume{}
+	for _, v := range podSpec.Volumes {
+		existingVolumes[v.Name] = v
+	}
+	existingMounts := map[string]corev1.VolumeMount{}
+	for _, vm := range container.VolumeMounts {
+		existingMounts[vm.MountPath] = vm
+	}
+
+	volumes := []interface{}{}
+	volumeMounts := []interface{}{}
+	// desired maps the name of each managed volume to its mount path.
+	desired := map[string]string{}
+	desiredPaths := map[string]bool{}
+	sort.Slice(m.Mounts, func(i, j int) bool { return VolumeName(m.Mounts[i]) < VolumeName(m.Mounts[j]) })
+	for _, mount := range m.Mounts {
+		name := VolumeName(mount)
+		// Paths already mounted by hand are left as they are.
+		if vm, ok := existingMounts[mount.MountPath]; ok && !owned(vm.Name) {
+			continue
+		}
+		desired[name] = mount.MountPath
+		desiredPaths[mount.MountPath] = true
+		v, ok := existingVolumes[name]
+		if !ok || v.Secret == nil || v.Secret.SecretName != mount.SecretName {
+			volumes = append(volumes, map[string]interface{}{
+				"name":   name,
+				"secret": map[string]interface{}{"secretName": mount.SecretName},
+			})
+		}
+		vm, ok := existingMounts[mount.MountPath]
+		if !ok || vm.Name != name {
+			volumeMounts = append(volumeMounts, map[string]interface{}{
+				"name":      name,
+				"mountPath": mount.MountPath,
+				"readOnly":  true,
+			})
+		}
+	}
+	for _, vm := range container.VolumeMounts {
+		// Mounts replaced by another managed volume at the same path are updated in place above.
+		if owned(vm.Name) && desired[vm.Name] != vm.MountPath && !desiredPaths[vm.MountPath] {
+			volumeMounts = append(volumeMounts, map[string]interface{}{"$patch": "delete", "mountPath": vm.MountPath})
+		}
+	}
+	for _, v := range podSpec.Volumes {
+		if _, ok := desired[v.Name]; owned(v.Name) && !ok {
+			volumes = append(volumes, map[string]interface{}{"$patch": "delete", "name": v.Name})
+		}
+	}
+	names := []string{}
+	for name := range desired {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	// The owned volumes are recorded whenever they change, Deployments without any are left unannotated.
+	annotated := m.Deployment.Annotations[ManagedVolumesAnnotation] == strings.Join(names, ",")
+	if len(volumes) == 0 && len(volumeMounts) == 0 && annotated {
+		return nil, nil
+	}
+
+	spec := map[string]interface{}{}
+	if len(volumes) > 0 {
+		spec["volumes"] = volumes
+	}
+	if len(volumeMounts) > 0 {
+		spec["containers"] = []interface{}{
+			map[string]interface{}{"name": container.Name, "volumeMounts": volumeMounts},
+		}
+	}
+	return json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{ManagedVolumesAnnotation: strings.Join(names, ",")},
+		},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": spec,
+			},
+		},
+	})
+}
+
+// ownedVolumes returns whether a volume of the Deployment is owned by the operator. Deployments mounted before
+// ownership was recorded only contain managed volumes named with the VolumePrefix.
+func ownedVolumes(d *appsv1.Deployment) func(string) bool {
+	annotation, recorded := d.Annotations[ManagedVolumesAnnotation]
+	if !recorded {
+		return func(name string) bool { return strings.HasPrefix(name, VolumePrefix) }
+	}
+	owned := map[string]bool{}
+	for _, name := range strings.Split(annotation, ",") {
+		if name != "" {
+			owned[name] = true
+		}
+	}
+	return func(name string) bool { return owned[name] }
+}
+
+// gatewayContainer returns the istio-proxy container of the gateway pods, or the first container if there is none.
+func gatewayContainer(podSpec corev1.PodSpec) *corev1.Container {
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name == "istio-proxy" {
+			return &podSpec.Containers[i]
+		}
+	}
+	if len(podSpec.Containers) > 0 {
+		return &podSpec.Containers[0]
+	}
+	return nil
+}
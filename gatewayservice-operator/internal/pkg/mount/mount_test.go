@@ -0,0 +1,188 @@
+package mount_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/mount"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/names"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+func newDeployment() *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "istio-ingressgateway", Namespace: "istio-system"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:         "istio-proxy",
+						Image:        "istio/proxyv2",
+						VolumeMounts: []corev1.VolumeMount{{Name: "istio-envoy", MountPath: "/etc/istio/proxy"}},
+					}},
+					Volumes: []corev1.Volume{
+						{Name: "istio-envoy", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+					},
+				},
+			},
+		},
+	}
+}
+
+// apply applies the strategic merge patch to the Deployment in the same way as the API server.
+func apply(t *testing.T, d *appsv1.Deployment, patch []byte) *appsv1.Deployment {
+	original, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("marshal deployment: (%v)", err)
+	}
+	patched, err := strategicpatch.StrategicMergePatch(original, patch, appsv1.Deployment{})
+	if err != nil {
+		t.Fatalf("apply patch: (%v)", err)
+	}
+	result := &appsv1.Deployment{}
+	err = json.Unmarshal(patched, result)
+	if err != nil {
+		t.Fatalf("unmarshal deployment: (%v)", err)
+	}
+	// The order of merged list entries is not significant.
+	podSpec := result.Spec.Template.Spec
+	sort.Slice(podSpec.Volumes, func(i, j int) bool { return podSpec.Volumes[i].Name < podSpec.Volumes[j].Name })
+	for _, c := range podSpec.Containers {
+		sort.Slice(c.VolumeMounts, func(i, j int) bool { return c.VolumeMounts[i].Name < c.VolumeMounts[j].Name })
+	}
+	return result
+}
+
+func TestMountReconcile(t *testing.T) {
+	m, err := mount.ForPaths("example", "application", "example-certs", "/etc/certs/example/tls.crt", "/etc/certs/example/tls.key")
+	if err != nil {
+		t.Fatalf("mount: (%v)", err)
+	}
+	d := newDeployment()
+	patch, err := mount.Reconcile(mount.MountConfig{Deployment: d, Mounts: []mount.Mount{m}})
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	d = apply(t, d, patch)
+
+	expectedVolumes := []corev1.Volume{
+		{Name: names.Volume("example", "application"), VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "example-certs"}}},
+		{Name: "istio-envoy", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+	}
+	if !reflect.DeepEqual(d.Spec.Template.Spec.Volumes, expectedVolumes) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expectedVolumes, d.Spec.Template.Spec.Volumes)
+	}
+	expectedMounts := []corev1.VolumeMount{
+		{Name: names.Volume("example", "application"), MountPath: "/etc/certs/example", ReadOnly: true},
+		{Name: "istio-envoy", MountPath: "/etc/istio/proxy"},
+	}
+	if !reflect.DeepEqual(d.Spec.Template.Spec.Containers[0].VolumeMounts, expectedMounts) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expectedMounts, d.Spec.Template.Spec.Containers[0].VolumeMounts)
+	}
+	if d.Spec.Template.Spec.Containers[0].Image != "istio/proxyv2" {
+		t.Fatalf("expected unrelated fields to be left untouched, found (%+v)", d.Spec.Template.Spec.Containers[0])
+	}
+
+	// The Deployment is up to date.
+	patch, err = mount.Reconcile(mount.MountConfig{Deployment: d, Mounts: []mount.Mount{m}})
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if patch != nil {
+		t.Fatalf("expected no patch, found (%s)", patch)
+	}
+
+	// The managed entries are removed once no longer required.
+	patch, err = mount.Reconcile(mount.MountConfig{Deployment: d})
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	d = apply(t, d, patch)
+	expected := newDeployment()
+	expected.Annotations = map[string]string{mount.ManagedVolumesAnnotation: ""}
+	if !reflect.DeepEqual(d, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, d)
+	}
+}
+
+func TestMountReconcileUnowned(t *testing.T) {
+	// A volume named with the prefix but not owned by the operator is left untouched.
+	d := newDeployment()
+	d.Annotations = map[string]string{mount.ManagedVolumesAnnotation: ""}
+	podSpec := &d.Spec.Template.Spec
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name:         "gatewayservice-custom",
+		VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "custom-certs"}},
+	})
+	podSpec.Containers[0].VolumeMounts = append(podSpec.Containers[0].VolumeMounts, corev1.VolumeMount{Name: "gatewayservice-custom", MountPath: "/etc/certs/custom"})
+	patch, err := mount.Reconcile(mount.MountConfig{Deployment: d})
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if patch != nil {
+		t.Fatalf("expected no patch, found (%s)", patch)
+	}
+
+	// Deployments mounted before ownership was recorded own every volume named with the prefix.
+	d.Annotations = nil
+	patch, err = mount.Reconcile(mount.MountConfig{Deployment: d})
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	d = apply(t, d, patch)
+	expected := newDeployment()
+	expected.Annotations = map[string]string{mount.ManagedVolumesAnnotation: ""}
+	if !reflect.DeepEqual(d, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, d)
+	}
+}
+
+func TestMountReconcileManualMount(t *testing.T) {
+	m, err := mount.ForPaths("example", "application", "example-certs", "/etc/istio/proxy/tls.crt", "/etc/istio/proxy/tls.key")
+	if err != nil {
+		t.Fatalf("mount: (%v)", err)
+	}
+	// Paths mounted by hand are left untouched.
+	patch, err := mount.Reconcile(mount.MountConfig{Deployment: newDeployment(), Mounts: []mount.Mount{m}})
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if patch != nil {
+		t.Fatalf("expected no patch, found (%s)", patch)
+	}
+}
+
+func TestMountUnique(t *testing.T) {
+	first, err := mount.ForPaths("example", "application", "example-certs", "/etc/certs/tls.crt", "/etc/certs/tls.key")
+	if err != nil {
+		t.Fatalf("mount: (%v)", err)
+	}
+	second, err := mount.ForPaths("example", "other", "other-certs", "/etc/certs/tls.crt", "/etc/certs/tls.key")
+	if err != nil {
+		t.Fatalf("mount: (%v)", err)
+	}
+	unique, rejected := mount.Unique([]mount.Mount{first, second})
+	if !reflect.DeepEqual(unique, []mount.Mount{first}) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", []mount.Mount{first}, unique)
+	}
+	if _, ok := rejected[types.NamespacedName{Name: "example", Namespace: "other"}]; !ok || len(rejected) != 1 {
+		t.Fatalf("expected the second mount of the path to be rejected, found (%+v)", rejected)
+	}
+}
+
+func TestMountForPaths(t *testing.T) {
+	_, err := mount.ForPaths("example", "application", "example-certs", "/etc/certs/tls.crt", "/etc/keys/tls.key")
+	if err == nil {
+		t.Fatalf("expected cert and key in different directories to be rejected")
+	}
+	_, err = mount.ForPaths("example", "application", "example-certs", "tls.crt", "tls.key")
+	if err == nil {
+		t.Fatalf("expected relative paths to be rejected")
+	}
+}
@@ -0,0 +1,69 @@
+package names
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// hashLength is the number of hex characters of the hash identifying a GatewayService within generated names.
+const hashLength = 8
+
+// Port returns the name of the port of the server rendered for the GatewayService, EG.
+// "https-example-application-1a2b3c4d". The name is unique to the GatewayService and fits within a DNS label.
+func Port(protocol string, name string, namespace string) string {
+	prefix := strings.ToLower(protocol) + "-"
+	return generate(prefix, strings.Replace(name, ".", "-", -1), namespace, "", validation.DNS1123LabelMaxLength)
+}
+
+// Secret returns the name of the secret created for the GatewayService, EG. "example-application-1a2b3c4d-secret".
+// The name is unique to the GatewayService and fits within a DNS subdomain.
+func Secret(name string, namespace string) string {
+	return generate("", name, namespace, "-secret", validation.DNS1123SubdomainMaxLength)
+}
+
+// Volume returns the name of the volume mounting the secret of the GatewayService into the gateway pods, EG.
+// "gatewayservice-example-application-1a2b3c4d". The name is unique to the GatewayService and fits within a DNS label.
+func Volume(name string, namespace string) string {
+	return generate("gatewayservice-", strings.Replace(name, ".", "-", -1), namespace, "", validation.DNS1123LabelMaxLength)
+}
+
+// Label returns the value labelling resources with the name of a GatewayService. Names which fit within a label value
+// are kept, longer names are truncated and joined with a hash of the full name, which is recorded by an annotation.
+func Label(name string) string {
+	if len(name) <= validation.LabelValueMaxLength {
+		return name
+	}
+	sum := sha256.Sum256([]byte(name))
+	hash := hex.EncodeToString(sum[:])[:hashLength]
+	base := strings.TrimRight(name[:validation.LabelValueMaxLength-len(hash)-1], "-.")
+	return base + "-" + hash
+}
+
+// LegacyPort returns the port name rendered before names were made unique, which is kept by existing Gateways.
+func LegacyPort(protocol string, name string, namespace string) string {
+	return fmt.Sprintf("%s-%s-%s", strings.ToLower(protocol), name, namespace)
+}
+
+// LegacySecret returns the secret name created before names were made unique, which is kept by existing
+// GatewayServices.
+func LegacySecret(name string, namespace string) string {
+	return fmt.Sprintf("%s-%s-secret", name, namespace)
+}
+
+// generate joins the name and namespace of a GatewayService, truncated to fit within the limit, with a hash of both.
+// The hash keeps names unique where joining alone is ambiguous, EG. "a-b" in namespace "c" and "a" in namespace "b-c",
+// or where the names were truncated.
+func generate(prefix string, name string, namespace string, suffix string, limit int) string {
+	sum := sha256.Sum256([]byte(namespace + "/" + name))
+	hash := hex.EncodeToString(sum[:])[:hashLength]
+	base := name + "-" + namespace
+	max := limit - len(prefix) - len(suffix) - len(hash) - 1
+	if len(base) > max {
+		base = strings.TrimRight(base[:max], "-.")
+	}
+	return prefix + base + "-" + hash + suffix
+}
@@ -0,0 +1,71 @@
+package names_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/names"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func TestUnique(t *testing.T) {
+	// The legacy names of these GatewayServices collide.
+	if names.LegacySecret("a-b", "c") != names.LegacySecret("a", "b-c") {
+		t.Fatal("expected the legacy secret names to collide")
+	}
+	if names.Secret("a-b", "c") == names.Secret("a", "b-c") {
+		t.Fatalf("expected secret names to be unique, found (%+v)", names.Secret("a", "b-c"))
+	}
+	if names.Port("HTTPS", "a-b", "c") == names.Port("HTTPS", "a", "b-c") {
+		t.Fatalf("expected port names to be unique, found (%+v)", names.Port("HTTPS", "a", "b-c"))
+	}
+	if names.Volume("a-b", "c") == names.Volume("a", "b-c") {
+		t.Fatalf("expected volume names to be unique, found (%+v)", names.Volume("a", "b-c"))
+	}
+}
+
+func TestFormat(t *testing.T) {
+	port := names.Port("HTTPS", "example", "application")
+	if !strings.HasPrefix(port, "https-example-application-") || len(port) != len("https-example-application-")+8 {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", "https-example-application-<hash>", port)
+	}
+	secret := names.Secret("example", "application")
+	if !strings.HasPrefix(secret, "example-application-") || !strings.HasSuffix(secret, "-secret") {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", "example-application-<hash>-secret", secret)
+	}
+	if port != names.Port("HTTPS", "example", "application") {
+		t.Fatal("expected port names to be stable")
+	}
+}
+
+func TestLimits(t *testing.T) {
+	name := strings.Repeat("a.", 126) + "a"
+	namespace := strings.Repeat("b", 63)
+
+	port := names.Port("HTTPS", name, namespace)
+	if errs := validation.IsDNS1123Label(port); len(errs) > 0 {
+		t.Fatalf("expected port name (%+v) to be a DNS label: %v", port, errs)
+	}
+	secret := names.Secret(name, namespace)
+	if errs := validation.IsDNS1123Subdomain(secret); len(errs) > 0 {
+		t.Fatalf("expected secret name (%+v) to be a DNS subdomain: %v", secret, errs)
+	}
+	volume := names.Volume(name, namespace)
+	if errs := validation.IsDNS1123Label(volume); len(errs) > 0 {
+		t.Fatalf("expected volume name (%+v) to be a DNS label: %v", volume, errs)
+	}
+	// Names truncated to the same prefix remain unique.
+	if names.Port("HTTPS", name, namespace) == names.Port("HTTPS", name+"a", namespace) {
+		t.Fatal("expected truncated port names to be unique")
+	}
+	label := names.Label(name)
+	if errs := validation.IsValidLabelValue(label); len(errs) > 0 {
+		t.Fatalf("expected label (%+v) to be a label value: %v", label, errs)
+	}
+	if names.Label(name) == names.Label(name+"a") {
+		t.Fatal("expected truncated labels to be unique")
+	}
+	if names.Label("example") != "example" {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", "example", names.Label("example"))
+	}
+}
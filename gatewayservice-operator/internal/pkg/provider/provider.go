@@ -0,0 +1,336 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/names"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/secret"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/vault"
+	appv1alpha1 "github.com/xunholy/k8s-istio-gateway-service-operator/pkg/apis/crd/v1alpha1"
+	networkv3 "istio.io/api/networking/v1alpha3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CertificateProvider sources the credential served by the Gateway for a GatewayService from one of the TLSOptions.
+type CertificateProvider interface {
+	// Validate returns an error if the credential cannot be served for the GatewayService.
+	Validate(c ProviderConfig) error
+
+	// EnsureCredential creates or updates the secret holding the credential, if the provider manages one. The
+	// duration after which the credential must be ensured again is returned, zero if it is never refreshed.
+	EnsureCredential(c ProviderConfig) (time.Duration, error)
+
+	// Credential returns how the Gateway references the credential, nil if the mode is not supported.
+	Credential(gatewayservice appv1alpha1.GatewayService, mode networkv3.Server_TLSOptions_TLSmode) *Credential
+
+	// Cleanup deletes every secret created by the provider which the GatewayService no longer requires.
+	Cleanup(c ProviderConfig) error
+}
+
+type ProviderConfig struct {
+	Client         client.Client
+	Scheme         *runtime.Scheme
+	GatewayService *appv1alpha1.GatewayService
+	Mode           networkv3.Server_TLSOptions_TLSmode
+
+	// SecretNamespace is the namespace the credential secret is created within or referenced from.
+	SecretNamespace string
+
+	// CopyNamespaces are the other namespaces serving the Gateway, which receive a copy of the secret created
+	// within SecretNamespace.
+	CopyNamespaces []string
+
+	// Hosts are the hosts of the GatewayService with their templates expanded, defaulting to the hosts of the spec.
+	Hosts []string
+
+	// Vault is the Vault server of the operator config and the paths and roles each namespace may use.
+	Vault vault.Config
+}
+
+// hosts returns the hosts the credential is issued for.
+func (c ProviderConfig) hosts() []string {
+	if len(c.Hosts) > 0 {
+		return c.Hosts
+	}
+	return c.GatewayService.Spec.Hosts
+}
+
+// Credential is referenced by the Gateway server, either by the name of a secret or by the file paths mounted
+// within the gateway pods.
+type Credential struct {
+	Name     string
+	CertPath string
+	KeyPath  string
+
+	// MountSecretName is the secret the operator mounts within the gateway pods at the directory of the paths, empty
+	// if the secret is mounted by hand.
+	MountSecretName string
+}
+
+// MissingSecretError is returned when a secret referenced by the GatewayService does not exist. Unlike other
+// validation errors it is resolved once the secret is created, without changing the GatewayService.
+type MissingSecretError struct {
+	error
+}
+
+// IsMissingSecret reports whether the error is a MissingSecretError.
+func IsMissingSecret(err error) bool {
+	_, ok := err.(MissingSecretError)
+	return ok
+}
+
+type entry struct {
+	field    string
+	provider CertificateProvider
+}
+
+// providers is keyed by the json name of the TLSOptions field. When several fields are set the first provider takes
+// precedence.
+var providers = []entry{
+	{field: "tlsSecretPath", provider: tlsSecretPath{}},
+	{field: "tlsSecretRef", provider: tlsSecretRef{}},
+	{field: "tlsSecret", provider: tlsSecret{}},
+	{field: "vault", provider: vaultProvider{}},
+}
+
+// Register adds a provider for the TLSOptions field with the given json name, replacing any existing provider.
+func Register(field string, p CertificateProvider) {
+	for i := range providers {
+		if providers[i].field == field {
+			providers[i].provider = p
+			return
+		}
+	}
+	providers = append(providers, entry{field: field, provider: p})
+}
+
+// For returns the field and provider configured by the TLSOptions, nil if there is none.
+func For(options *appv1alpha1.TLSOptions) (string, CertificateProvider) {
+	if options == nil {
+		return "", nil
+	}
+	for _, e := range providers {
+		if configured(options, e.field) {
+			return e.field, e.provider
+		}
+	}
+	return "", nil
+}
+
+// Exactly returns an error unless the TLSOptions configure exactly one provider.
+func Exactly(options *appv1alpha1.TLSOptions) error {
+	fields := []string{}
+	found := []string{}
+	for _, e := range providers {
+		fields = append(fields, e.field)
+		if options != nil && configured(options, e.field) {
+			found = append(found, e.field)
+		}
+	}
+	if _, p := For(options); p == nil {
+		return fmt.Errorf("TLSOption must contain one of %s", strings.Join(fields, ", "))
+	}
+	if len(found) > 1 {
+		return fmt.Errorf("TLSOption must contain only one of %s, found %s", strings.Join(fields, ", "), strings.Join(found, ", "))
+	}
+	return nil
+}
+
+// Cleanup deletes the secrets created by every provider which the GatewayService no longer requires. This covers
+// TLSOptions and Mode changes as well as deletion of the GatewayService itself.
+func Cleanup(c ProviderConfig) error {
+	for _, e := range providers {
+		err := e.provider.Cleanup(c)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SecretName is the name of the secret created for the GatewayService by providers which manage the credential.
+// GatewayServices which recorded the name used before names were made unique keep it, so their secret is not
+// recreated. The recorded name is verified by VerifySecretName.
+func SecretName(gatewayservice appv1alpha1.GatewayService) string {
+	legacy := names.LegacySecret(gatewayservice.Name, gatewayservice.Namespace)
+	if gatewayservice.Status.Condition.CreatedSecretDetails.SecretName == legacy {
+		return legacy
+	}
+	return names.Secret(gatewayservice.Name, gatewayservice.Namespace)
+}
+
+func configured(options *appv1alpha1.TLSOptions, field string) bool {
+	v := reflect.ValueOf(options).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0] == field {
+			return v.Field(i).Kind() == reflect.Ptr && !v.Field(i).IsNil()
+		}
+	}
+	return false
+}
+
+// required reports whether the provider for the field is responsible for the credential of the GatewayService.
+func required(c ProviderConfig, field string) bool {
+	if c.GatewayService.DeletionTimestamp != nil {
+		return false
+	}
+	active, _ := For(c.GatewayService.Spec.TLSOptions)
+	return active == field
+}
+
+// labels returns the labels applied to secrets created by the provider for the field.
+func labels(gatewayservice *appv1alpha1.GatewayService, field string) map[string]string {
+	l := secret.Labels(gatewayservice)
+	l[secret.ProviderLabel] = field
+	return l
+}
+
+// createdBy returns the field of the provider which created the secret. Secrets created before the provider label
+// was introduced are attributed by their annotations.
+func createdBy(secretObj *corev1.Secret) string {
+	if field, ok := secretObj.Labels[secret.ProviderLabel]; ok {
+		return field
+	}
+	if _, ok := secretObj.Annotations[vault.HashAnnotation]; ok {
+		return "vault"
+	}
+	return "tlsSecret"
+}
+
+// VerifySecretName forgets the legacy secret name recorded by the status of the GatewayService unless the secret of
+// that name within SecretNamespace was created for the GatewayService, as the legacy names of GatewayServices may
+// collide. Like the port names of the Gateway, the GatewayService otherwise falls back to its unique secret name.
+func VerifySecretName(c ProviderConfig) error {
+	gatewayservice := c.GatewayService
+	legacy := names.LegacySecret(gatewayservice.Name, gatewayservice.Namespace)
+	if gatewayservice.Status.Condition.CreatedSecretDetails.SecretName != legacy {
+		return nil
+	}
+	secretObj := &corev1.Secret{}
+	err := c.Client.Get(context.TODO(), types.NamespacedName{Name: legacy, Namespace: c.SecretNamespace}, secretObj)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	// Secrets created by previous versions of the operator are adopted, other secrets must carry the UID of the
+	// GatewayService.
+	if err == nil && (secret.IsLegacy(secretObj, gatewayservice) || secret.HasLabels(secretObj.Labels, secret.Labels(gatewayservice))) {
+		return nil
+	}
+	gatewayservice.Status.Condition.CreatedSecretDetails.SecretName = ""
+	return nil
+}
+
+// created returns every secret created for the GatewayService within any namespace, including the secrets created by
+// previous versions of the operator which only carry the legacy Namespace label.
+func created(c ProviderConfig) ([]corev1.Secret, error) {
+	secrets := &corev1.SecretList{}
+	err := c.Client.List(context.TODO(), client.MatchingLabels(secret.OwnerLabels(c.GatewayService)), secrets)
+	if err != nil {
+		return nil, err
+	}
+	legacy := &corev1.SecretList{}
+	err = c.Client.List(context.TODO(), client.MatchingLabels(map[string]string{secret.LegacyNamespaceLabel: c.GatewayService.Namespace}), legacy)
+	if err != nil {
+		return nil, err
+	}
+	items := secrets.Items
+	for i := range legacy.Items {
+		// Adopted secrets no longer carry the legacy label, so no secret is listed twice.
+		if secret.IsLegacy(&legacy.Items[i], c.GatewayService) && !secret.HasLabels(legacy.Items[i].Labels, secret.OwnerLabels(c.GatewayService)) {
+			items = append(items, legacy.Items[i])
+		}
+	}
+	return items, nil
+}
+
+// sweep deletes the secrets created by the provider for the field except the secret which is still required, along
+// with its copies.
+func sweep(c ProviderConfig, field string, keep string) error {
+	namespaces := map[string]bool{c.SecretNamespace: true}
+	for _, namespace := range c.CopyNamespaces {
+		namespaces[namespace] = true
+	}
+	secrets, err := created(c)
+	if err != nil {
+		return err
+	}
+	for i := range secrets {
+		secretObj := &secrets[i]
+		if createdBy(secretObj) != field {
+			continue
+		}
+		if required(c, field) && secretObj.Name == keep && namespaces[secretObj.Namespace] {
+			continue
+		}
+		err = c.Client.Delete(context.TODO(), secretObj)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Copy copies the secret created for the GatewayService within SecretNamespace into each of the CopyNamespaces.
+// Secrets which are referenced rather than created by the operator are not copied.
+func Copy(c ProviderConfig) error {
+	source := &corev1.Secret{}
+	err := c.Client.Get(context.TODO(), types.NamespacedName{Name: SecretName(*c.GatewayService), Namespace: c.SecretNamespace}, source)
+	if err != nil {
+		// The GatewayService is reconciled again once the created secret is observed.
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	field, _ := For(c.GatewayService.Spec.TLSOptions)
+	if !secret.HasLabels(source.Labels, secret.OwnerLabels(c.GatewayService)) || createdBy(source) != field {
+		return nil
+	}
+	for _, namespace := range c.CopyNamespaces {
+		secretObj := &corev1.Secret{}
+		err := c.Client.Get(context.TODO(), types.NamespacedName{Name: source.Name, Namespace: namespace}, secretObj)
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				return err
+			}
+			secretObj = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        source.Name,
+					Namespace:   namespace,
+					Labels:      source.Labels,
+					Annotations: source.Annotations,
+				},
+				Type: source.Type,
+				Data: source.Data,
+			}
+			err = create(c, secretObj)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if !secret.HasLabels(secretObj.Labels, secret.OwnerLabels(c.GatewayService)) {
+			return fmt.Errorf("secret %s in namespace %s is not managed by the operator", secretObj.Name, secretObj.Namespace)
+		}
+		if reflect.DeepEqual(secretObj.Data, source.Data) && reflect.DeepEqual(secretObj.Labels, source.Labels) && reflect.DeepEqual(secretObj.Annotations, source.Annotations) {
+			continue
+		}
+		secretObj.Labels = source.Labels
+		secretObj.Annotations = source.Annotations
+		secretObj.Data = source.Data
+		err = c.Client.Update(context.TODO(), secretObj)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
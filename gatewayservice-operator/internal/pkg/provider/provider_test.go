@@ -0,0 +1,515 @@
+package provider_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/gateway"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/names"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/provider"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/secret"
+	appv1alpha1 "github.com/xunholy/k8s-istio-gateway-service-operator/pkg/apis/crd/v1alpha1"
+	networkv3 "istio.io/api/networking/v1alpha3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var (
+	cert       = "Q2VydAo="
+	key        = "S2V5Cg=="
+	secretName = names.Secret("example", "application")
+)
+
+func TestFor(t *testing.T) {
+	tests := []struct {
+		options *appv1alpha1.TLSOptions
+		field   string
+	}{
+		{options: nil, field: ""},
+		{options: &appv1alpha1.TLSOptions{}, field: ""},
+		{options: &appv1alpha1.TLSOptions{TLSSecret: &appv1alpha1.TLSSecret{}}, field: "tlsSecret"},
+		{options: &appv1alpha1.TLSOptions{TLSSecretRef: &appv1alpha1.TLSSecretRef{}}, field: "tlsSecretRef"},
+		{options: &appv1alpha1.TLSOptions{TLSSecretPath: &appv1alpha1.TLSSecretPath{}}, field: "tlsSecretPath"},
+		{options: &appv1alpha1.TLSOptions{Vault: &appv1alpha1.Vault{}}, field: "vault"},
+		// TLSSecretPath takes precedence when several options are set.
+		{options: &appv1alpha1.TLSOptions{TLSSecret: &appv1alpha1.TLSSecret{}, TLSSecretPath: &appv1alpha1.TLSSecretPath{}}, field: "tlsSecretPath"},
+	}
+	for _, tt := range tests {
+		field, p := provider.For(tt.options)
+		if field != tt.field {
+			t.Fatalf("Expected: (%+v) \n Found: (%+v)", tt.field, field)
+		}
+		if (p == nil) != (tt.field == "") {
+			t.Fatalf("expected provider for field (%s), found (%+v)", tt.field, p)
+		}
+	}
+}
+
+func TestExactly(t *testing.T) {
+	tests := []struct {
+		options *appv1alpha1.TLSOptions
+		valid   bool
+	}{
+		{options: nil, valid: false},
+		{options: &appv1alpha1.TLSOptions{}, valid: false},
+		{options: &appv1alpha1.TLSOptions{TLSSecret: &appv1alpha1.TLSSecret{}}, valid: true},
+		{options: &appv1alpha1.TLSOptions{Vault: &appv1alpha1.Vault{}}, valid: true},
+		{options: &appv1alpha1.TLSOptions{TLSSecret: &appv1alpha1.TLSSecret{}, TLSSecretPath: &appv1alpha1.TLSSecretPath{}}, valid: false},
+	}
+	for _, tt := range tests {
+		err := provider.Exactly(tt.options)
+		if (err == nil) != tt.valid {
+			t.Fatalf("Expected: (%+v) \n Found: (%+v)", tt.valid, err)
+		}
+	}
+}
+
+func TestCredential(t *testing.T) {
+	gatewayservice := appv1alpha1.GatewayService{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "application"},
+	}
+	tests := []struct {
+		options  *appv1alpha1.TLSOptions
+		mode     networkv3.Server_TLSOptions_TLSmode
+		expected *provider.Credential
+	}{
+		{
+			options:  &appv1alpha1.TLSOptions{TLSSecret: &appv1alpha1.TLSSecret{}},
+			mode:     networkv3.Server_TLSOptions_SIMPLE,
+			expected: &provider.Credential{Name: secretName},
+		},
+		{
+			options:  &appv1alpha1.TLSOptions{TLSSecretRef: &appv1alpha1.TLSSecretRef{SecretName: "existing-secret"}},
+			mode:     networkv3.Server_TLSOptions_SIMPLE,
+			expected: &provider.Credential{Name: "existing-secret"},
+		},
+		{
+			options:  &appv1alpha1.TLSOptions{TLSSecretRef: &appv1alpha1.TLSSecretRef{SecretName: "existing-secret"}},
+			mode:     networkv3.Server_TLSOptions_PASSTHROUGH,
+			expected: nil,
+		},
+		{
+			options:  &appv1alpha1.TLSOptions{TLSSecretPath: &appv1alpha1.TLSSecretPath{CertPath: "/etc/certs/tls.crt", KeyPath: "/etc/certs/tls.key"}},
+			mode:     networkv3.Server_TLSOptions_MUTUAL,
+			expected: &provider.Credential{CertPath: "/etc/certs/tls.crt", KeyPath: "/etc/certs/tls.key"},
+		},
+		{
+			options:  &appv1alpha1.TLSOptions{TLSSecretPath: &appv1alpha1.TLSSecretPath{CertPath: "/etc/certs/tls.crt", KeyPath: "/etc/certs/tls.key"}},
+			mode:     gateway.OptionalMutual,
+			expected: &provider.Credential{CertPath: "/etc/certs/tls.crt", KeyPath: "/etc/certs/tls.key"},
+		},
+		{
+			options:  &appv1alpha1.TLSOptions{Vault: &appv1alpha1.Vault{}},
+			mode:     networkv3.Server_TLSOptions_PASSTHROUGH,
+			expected: &provider.Credential{Name: secretName},
+		},
+	}
+	for _, tt := range tests {
+		gatewayservice.Spec.TLSOptions = tt.options
+		_, p := provider.For(tt.options)
+		credential := p.Credential(gatewayservice, tt.mode)
+		if !reflect.DeepEqual(credential, tt.expected) {
+			t.Fatalf("Expected: (%+v) \n Found: (%+v)", tt.expected, credential)
+		}
+	}
+}
+
+func TestSecretName(t *testing.T) {
+	gatewayservice := appv1alpha1.GatewayService{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "application"},
+	}
+	if found := provider.SecretName(gatewayservice); found != secretName {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", secretName, found)
+	}
+	// GatewayServices which recorded the legacy name keep their secret.
+	legacy := names.LegacySecret("example", "application")
+	gatewayservice.Status.Condition.CreatedSecretDetails.SecretName = legacy
+	if found := provider.SecretName(gatewayservice); found != legacy {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", legacy, found)
+	}
+	// Any other recorded name is ignored.
+	gatewayservice.Status.Condition.CreatedSecretDetails.SecretName = "other-secret"
+	if found := provider.SecretName(gatewayservice); found != secretName {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", secretName, found)
+	}
+}
+
+func TestVerifySecretName(t *testing.T) {
+	legacy := names.LegacySecret("example", "application")
+	newGatewayService := func() *appv1alpha1.GatewayService {
+		gatewayservice := &appv1alpha1.GatewayService{
+			ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "application", UID: "1234"},
+		}
+		gatewayservice.Status.Condition.CreatedSecretDetails.SecretName = legacy
+		return gatewayservice
+	}
+	owned := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: legacy, Namespace: "istio-system", Labels: secret.Labels(newGatewayService())},
+	}
+	// Secret created for another GatewayService, a previous GatewayService of the same name.
+	other := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: legacy, Namespace: "istio-system", Labels: secret.Labels(&appv1alpha1.GatewayService{
+			ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "application", UID: "5678"},
+		})},
+	}
+	created := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: legacy, Namespace: "istio-system", Labels: map[string]string{secret.LegacyNamespaceLabel: "application"}},
+	}
+	for _, tt := range []struct {
+		name     string
+		objs     []runtime.Object
+		expected string
+	}{
+		{name: "owned", objs: []runtime.Object{owned}, expected: legacy},
+		{name: "created by a previous version", objs: []runtime.Object{created}, expected: legacy},
+		{name: "owned by another GatewayService", objs: []runtime.Object{other}, expected: secretName},
+		{name: "missing", objs: []runtime.Object{}, expected: secretName},
+	} {
+		gatewayservice := newGatewayService()
+		err := provider.VerifySecretName(provider.ProviderConfig{
+			Client:          fake.NewFakeClient(tt.objs...),
+			GatewayService:  gatewayservice,
+			SecretNamespace: "istio-system",
+		})
+		if err != nil {
+			t.Fatalf("%s: verify secret name: (%v)", tt.name, err)
+		}
+		if found := provider.SecretName(*gatewayservice); found != tt.expected {
+			t.Fatalf("%s: Expected: (%+v) \n Found: (%+v)", tt.name, tt.expected, found)
+		}
+	}
+}
+
+func TestEnsureCredentialTLSSecret(t *testing.T) {
+	gatewayservice := &appv1alpha1.GatewayService{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "application"},
+		Spec: appv1alpha1.GatewayServiceSpec{
+			Mode: "SIMPLE",
+			TLSOptions: &appv1alpha1.TLSOptions{
+				TLSSecret: &appv1alpha1.TLSSecret{Cert: &cert, Key: &key},
+			},
+		},
+	}
+	// Secret previously created by the vault provider which must be replaced.
+	vaultSecretObj := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: "istio-system",
+			Labels:    map[string]string{secret.ProviderLabel: "vault"},
+		},
+		Data: map[string][]byte{"tls.crt": []byte("vault-cert"), "tls.key": []byte("vault-key")},
+	}
+	for k, v := range secret.Labels(gatewayservice) {
+		vaultSecretObj.Labels[k] = v
+	}
+	c := provider.ProviderConfig{
+		Client:          fake.NewFakeClient(vaultSecretObj),
+		Scheme:          scheme.Scheme,
+		GatewayService:  gatewayservice,
+		Mode:            networkv3.Server_TLSOptions_SIMPLE,
+		SecretNamespace: "istio-system",
+	}
+	_, p := provider.For(gatewayservice.Spec.TLSOptions)
+	err := p.Validate(c)
+	if err != nil {
+		t.Fatalf("validate: (%v)", err)
+	}
+	refreshAfter, err := p.EnsureCredential(c)
+	if err != nil {
+		t.Fatalf("ensure credential: (%v)", err)
+	}
+	if refreshAfter != 0 {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", time.Duration(0), refreshAfter)
+	}
+	secretObj := &corev1.Secret{}
+	err = c.Client.Get(context.TODO(), types.NamespacedName{Name: secretName, Namespace: "istio-system"}, secretObj)
+	if err != nil {
+		t.Fatalf("get secret: (%v)", err)
+	}
+	expected := map[string][]byte{"tls.crt": []byte(cert), "tls.key": []byte(key)}
+	if !reflect.DeepEqual(secretObj.Data, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, secretObj.Data)
+	}
+	if secretObj.Labels[secret.ProviderLabel] != "tlsSecret" {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", "tlsSecret", secretObj.Labels[secret.ProviderLabel])
+	}
+}
+
+func TestEnsureCredentialTLSSecretRotated(t *testing.T) {
+	gatewayservice := &appv1alpha1.GatewayService{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "application"},
+		Spec: appv1alpha1.GatewayServiceSpec{
+			Mode: "SIMPLE",
+			TLSOptions: &appv1alpha1.TLSOptions{
+				TLSSecret: &appv1alpha1.TLSSecret{Cert: &cert, Key: &key},
+			},
+		},
+	}
+	// Secret created by the operator before the cert and key of the spec were rotated.
+	secretObj := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: "istio-system",
+			Labels:    map[string]string{secret.ProviderLabel: "tlsSecret"},
+		},
+		Data: map[string][]byte{"tls.crt": []byte("expired-cert"), "tls.key": []byte("expired-key")},
+	}
+	for k, v := range secret.Labels(gatewayservice) {
+		secretObj.Labels[k] = v
+	}
+	c := provider.ProviderConfig{
+		Client:          fake.NewFakeClient(secretObj),
+		Scheme:          scheme.Scheme,
+		GatewayService:  gatewayservice,
+		Mode:            networkv3.Server_TLSOptions_SIMPLE,
+		SecretNamespace: "istio-system",
+	}
+	_, p := provider.For(gatewayservice.Spec.TLSOptions)
+	_, err := p.EnsureCredential(c)
+	if err != nil {
+		t.Fatalf("ensure credential: (%v)", err)
+	}
+	err = c.Client.Get(context.TODO(), types.NamespacedName{Name: secretName, Namespace: "istio-system"}, secretObj)
+	if err != nil {
+		t.Fatalf("get secret: (%v)", err)
+	}
+	expected := map[string][]byte{"tls.crt": []byte(cert), "tls.key": []byte(key)}
+	if !reflect.DeepEqual(secretObj.Data, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, secretObj.Data)
+	}
+}
+
+func TestEnsureCredentialTLSSecretUnmanaged(t *testing.T) {
+	gatewayservice := &appv1alpha1.GatewayService{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "application"},
+		Spec: appv1alpha1.GatewayServiceSpec{
+			Mode: "SIMPLE",
+			TLSOptions: &appv1alpha1.TLSOptions{
+				TLSSecret: &appv1alpha1.TLSSecret{Cert: &cert, Key: &key},
+			},
+		},
+	}
+	// Secret of the same name not created by the operator.
+	unmanaged := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: "istio-system"}}
+	c := provider.ProviderConfig{
+		Client:          fake.NewFakeClient(unmanaged),
+		Scheme:          scheme.Scheme,
+		GatewayService:  gatewayservice,
+		Mode:            networkv3.Server_TLSOptions_SIMPLE,
+		SecretNamespace: "istio-system",
+	}
+	_, p := provider.For(gatewayservice.Spec.TLSOptions)
+	_, err := p.EnsureCredential(c)
+	if err == nil {
+		t.Fatalf("expected a secret not managed by the operator to be rejected")
+	}
+}
+
+func TestCleanup(t *testing.T) {
+	gatewayservice := &appv1alpha1.GatewayService{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "application"},
+		Spec: appv1alpha1.GatewayServiceSpec{
+			Mode: "SIMPLE",
+			TLSOptions: &appv1alpha1.TLSOptions{
+				TLSSecret: &appv1alpha1.TLSSecret{Cert: &cert, Key: &key},
+			},
+		},
+	}
+	newSecret := func(name string, namespace string, field string) *corev1.Secret {
+		secretObj := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: secret.Labels(gatewayservice)},
+		}
+		if field != "" {
+			secretObj.Labels[secret.ProviderLabel] = field
+		}
+		return secretObj
+	}
+	required := newSecret(secretName, "istio-system", "tlsSecret")
+	// Secret created in the GatewayService namespace while the Mode was PASSTHROUGH.
+	staleMode := newSecret(secretName, "application", "")
+	// Secret created by the vault provider before the TLSOptions changed.
+	staleProvider := newSecret("example-application-vault", "istio-system", "vault")
+	// Secret not created by the operator.
+	unmanaged := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "unmanaged", Namespace: "istio-system"}}
+
+	c := provider.ProviderConfig{
+		Client:          fake.NewFakeClient([]runtime.Object{required, staleMode, staleProvider, unmanaged}...),
+		Scheme:          scheme.Scheme,
+		GatewayService:  gatewayservice,
+		Mode:            networkv3.Server_TLSOptions_SIMPLE,
+		SecretNamespace: "istio-system",
+	}
+	err := provider.Cleanup(c)
+	if err != nil {
+		t.Fatalf("cleanup: (%v)", err)
+	}
+	for _, tt := range []struct {
+		secret  *corev1.Secret
+		deleted bool
+	}{
+		{secret: required, deleted: false},
+		{secret: staleMode, deleted: true},
+		{secret: staleProvider, deleted: true},
+		{secret: unmanaged, deleted: false},
+	} {
+		err := c.Client.Get(context.TODO(), types.NamespacedName{Name: tt.secret.Name, Namespace: tt.secret.Namespace}, &corev1.Secret{})
+		if errors.IsNotFound(err) != tt.deleted {
+			t.Fatalf("expected secret %s/%s deleted to be (%v): (%v)", tt.secret.Namespace, tt.secret.Name, tt.deleted, err)
+		}
+	}
+
+	// Every secret is deleted once the GatewayService is being deleted.
+	now := metav1.Now()
+	gatewayservice.DeletionTimestamp = &now
+	err = provider.Cleanup(c)
+	if err != nil {
+		t.Fatalf("cleanup: (%v)", err)
+	}
+	err = c.Client.Get(context.TODO(), types.NamespacedName{Name: required.Name, Namespace: required.Namespace}, &corev1.Secret{})
+	if !errors.IsNotFound(err) {
+		t.Fatalf("expected secret %s/%s to be deleted: (%v)", required.Namespace, required.Name, err)
+	}
+}
+
+func TestCleanupLegacy(t *testing.T) {
+	// The GatewayService changed from SIMPLE to PASSTHROUGH after a previous version of the operator created its
+	// secret in istio-system.
+	// https://github.com/xUnholy/k8s-istio-gateway-service-operator/issues/16
+	gatewayservice := &appv1alpha1.GatewayService{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "application"},
+		Spec: appv1alpha1.GatewayServiceSpec{
+			Mode: "PASSTHROUGH",
+			TLSOptions: &appv1alpha1.TLSOptions{
+				TLSSecretRef: &appv1alpha1.TLSSecretRef{SecretName: "example-certs"},
+			},
+		},
+	}
+	legacyLabels := map[string]string{secret.LegacyNamespaceLabel: "application"}
+	legacy := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: names.LegacySecret("example", "application"), Namespace: "istio-system", Labels: legacyLabels},
+	}
+	// Secret created by a previous version of the operator for another GatewayService in the namespace.
+	other := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: names.LegacySecret("other", "application"), Namespace: "istio-system", Labels: legacyLabels},
+	}
+
+	c := provider.ProviderConfig{
+		Client:          fake.NewFakeClient([]runtime.Object{legacy, other}...),
+		Scheme:          scheme.Scheme,
+		GatewayService:  gatewayservice,
+		Mode:            networkv3.Server_TLSOptions_PASSTHROUGH,
+		SecretNamespace: "application",
+	}
+	err := provider.Cleanup(c)
+	if err != nil {
+		t.Fatalf("cleanup: (%v)", err)
+	}
+	err = c.Client.Get(context.TODO(), types.NamespacedName{Name: legacy.Name, Namespace: legacy.Namespace}, &corev1.Secret{})
+	if !errors.IsNotFound(err) {
+		t.Fatalf("expected secret %s/%s to be deleted: (%v)", legacy.Namespace, legacy.Name, err)
+	}
+	err = c.Client.Get(context.TODO(), types.NamespacedName{Name: other.Name, Namespace: other.Namespace}, &corev1.Secret{})
+	if err != nil {
+		t.Fatalf("expected secret %s/%s to be kept: (%v)", other.Namespace, other.Name, err)
+	}
+}
+
+func TestValidateTLSSecretRefPassthrough(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: (%v)", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatalf("create certificate: (%v)", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		t.Fatalf("marshal key: (%v)", err)
+	}
+	tlsCrt := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	tlsKey := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+
+	tests := []struct {
+		name    string
+		secret  *corev1.Secret
+		valid   bool
+		missing bool
+	}{
+		{name: "valid", secret: &corev1.Secret{Data: map[string][]byte{"tls.crt": tlsCrt, "tls.key": tlsKey}}, valid: true},
+		{name: "missing", missing: true},
+		{name: "no key", secret: &corev1.Secret{Data: map[string][]byte{"tls.crt": tlsCrt}}},
+		{name: "invalid cert", secret: &corev1.Secret{Data: map[string][]byte{"tls.crt": []byte("cert"), "tls.key": tlsKey}}},
+	}
+	for _, tt := range tests {
+		gatewayservice := &appv1alpha1.GatewayService{
+			ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "application"},
+			Spec: appv1alpha1.GatewayServiceSpec{
+				Mode: "PASSTHROUGH",
+				TLSOptions: &appv1alpha1.TLSOptions{
+					TLSSecretRef: &appv1alpha1.TLSSecretRef{SecretName: "app-secret"},
+				},
+			},
+		}
+		objs := []runtime.Object{}
+		if tt.secret != nil {
+			// The secret is owned by the application in the namespace of the GatewayService.
+			tt.secret.ObjectMeta = metav1.ObjectMeta{Name: "app-secret", Namespace: "application"}
+			objs = append(objs, tt.secret.DeepCopy())
+		}
+		c := provider.ProviderConfig{
+			Client:          fake.NewFakeClient(objs...),
+			Scheme:          scheme.Scheme,
+			GatewayService:  gatewayservice,
+			Mode:            networkv3.Server_TLSOptions_PASSTHROUGH,
+			SecretNamespace: "application",
+		}
+		_, p := provider.For(gatewayservice.Spec.TLSOptions)
+		err := p.Validate(c)
+		if tt.valid && err != nil {
+			t.Fatalf("%s: expected secret to be valid: (%v)", tt.name, err)
+		}
+		if !tt.valid && err == nil {
+			t.Fatalf("%s: expected secret to be invalid", tt.name)
+		}
+		if provider.IsMissingSecret(err) != tt.missing {
+			t.Fatalf("%s: Expected: (%+v) \n Found: (%+v)", tt.name, tt.missing, err)
+		}
+		if tt.secret == nil {
+			continue
+		}
+		// The secret is never modified by the operator.
+		_, err = p.EnsureCredential(c)
+		if err != nil {
+			t.Fatalf("%s: ensure credential: (%v)", tt.name, err)
+		}
+		found := &corev1.Secret{}
+		err = c.Client.Get(context.TODO(), types.NamespacedName{Name: "app-secret", Namespace: "application"}, found)
+		if err != nil {
+			t.Fatalf("%s: get secret: (%v)", tt.name, err)
+		}
+		if !reflect.DeepEqual(found.Data, tt.secret.Data) || len(found.Labels) != 0 {
+			t.Fatalf("%s: Expected: (%+v) \n Found: (%+v)", tt.name, tt.secret, found)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/secret"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/validate"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/vault"
+	appv1alpha1 "github.com/xunholy/k8s-istio-gateway-service-operator/pkg/apis/crd/v1alpha1"
+	networkv3 "istio.io/api/networking/v1alpha3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// tlsSecret creates a secret holding the cert and key provided by the GatewayService.
+type tlsSecret struct{}
+
+func (tlsSecret) Validate(c ProviderConfig) error {
+	options := c.GatewayService.Spec.TLSOptions.TLSSecret
+	if options.Cert == nil || options.Key == nil {
+		return fmt.Errorf("cert and/or key cannot be nil")
+	}
+	err := validate.ValidateSecretEncoding(*options)
+	if err != nil {
+		return fmt.Errorf("cert and/or key are not base64 encoded")
+	}
+	return nil
+}
+
+func (tlsSecret) EnsureCredential(c ProviderConfig) (time.Duration, error) {
+	gatewayservice := c.GatewayService
+	secretObj := &corev1.Secret{}
+	key := types.NamespacedName{Name: SecretName(*gatewayservice), Namespace: c.SecretNamespace}
+	err := c.Client.Get(context.TODO(), key, secretObj)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			s := secret.SecretConfig{
+				Name:           key.Name,
+				Namespace:      key.Namespace,
+				Labels:         labels(gatewayservice, "tlsSecret"),
+				Annotations:    secret.Annotations(gatewayservice),
+				GatewayService: gatewayservice,
+			}
+			return 0, create(c, secret.Reconcile(s))
+		}
+		return 0, err
+	}
+	// Secrets created by previous versions of the operator only carry the legacy Namespace label, and secrets
+	// left behind by a previous GatewayService of the same name carry a stale UID. Both are adopted.
+	adopt := secret.IsLegacy(secretObj, gatewayservice) || secret.HasLabels(secretObj.Labels, secret.OwnerLabels(gatewayservice))
+	if !adopt {
+		return 0, fmt.Errorf("secret %s in namespace %s is not managed by the operator", key.Name, key.Namespace)
+	}
+	// The data follows the cert and key of the spec, so rotating them replaces the credential.
+	data := secret.Reconcile(secret.SecretConfig{GatewayService: gatewayservice}).Data
+	if secret.HasLabels(secretObj.Labels, labels(gatewayservice, "tlsSecret")) && secret.HasLabels(secretObj.Annotations, secret.Annotations(gatewayservice)) && reflect.DeepEqual(secretObj.Data, data) {
+		return 0, nil
+	}
+	// The secret was created by another provider before the TLSOptions changed, its refresh is no longer tracked.
+	if createdBy(secretObj) != "tlsSecret" {
+		delete(secretObj.Annotations, vault.HashAnnotation)
+		delete(secretObj.Annotations, vault.RefreshAtAnnotation)
+	}
+	if secretObj.Annotations == nil {
+		secretObj.Annotations = map[string]string{}
+	}
+	for k, v := range secret.Annotations(gatewayservice) {
+		secretObj.Annotations[k] = v
+	}
+	secretObj.Data = data
+	l := labels(gatewayservice, "tlsSecret")
+	for k, v := range secretObj.Labels {
+		if _, ok := l[k]; !ok && k != secret.LegacyNamespaceLabel {
+			l[k] = v
+		}
+	}
+	secretObj.Labels = l
+	return 0, c.Client.Update(context.TODO(), secretObj)
+}
+
+func (tlsSecret) Credential(gatewayservice appv1alpha1.GatewayService, mode networkv3.Server_TLSOptions_TLSmode) *Credential {
+	return &Credential{Name: SecretName(gatewayservice)}
+}
+
+func (tlsSecret) Cleanup(c ProviderConfig) error {
+	return sweep(c, "tlsSecret", SecretName(*c.GatewayService))
+}
+
+// create creates a secret for the GatewayService, owned by the GatewayService when they share a namespace.
+func create(c ProviderConfig, secretObj *corev1.Secret) error {
+	// Owner references cannot cross namespaces, secrets created outside of the GatewayService
+	// namespace are removed by the finalizer instead of garbage collection.
+	if secretObj.Namespace == c.GatewayService.Namespace {
+		// SetControllerReference sets owner as a Controller OwnerReference on owned.
+		// This is used for garbage collection of the owned object.
+		// Since only one OwnerReference can be a controller, it returns an error if
+		// there is another OwnerReference with Controller flag set.
+		err := controllerutil.SetControllerReference(c.GatewayService, secretObj, c.Scheme)
+		if err != nil {
+			return err
+		}
+	}
+	return c.Client.Create(context.TODO(), secretObj)
+}
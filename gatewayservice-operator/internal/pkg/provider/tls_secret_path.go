@@ -0,0 +1,59 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/mount"
+	appv1alpha1 "github.com/xunholy/k8s-istio-gateway-service-operator/pkg/apis/crd/v1alpha1"
+	networkv3 "istio.io/api/networking/v1alpha3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// tlsSecretPath references a cert and key mounted within the gateway pods, used if SDS is not available.
+type tlsSecretPath struct{}
+
+func (tlsSecretPath) Validate(c ProviderConfig) error {
+	options := c.GatewayService.Spec.TLSOptions.TLSSecretPath
+	if options.SecretName == "" {
+		return nil
+	}
+	_, err := mount.ForPaths(c.GatewayService.Name, c.GatewayService.Namespace, options.SecretName, options.CertPath, options.KeyPath)
+	if err != nil {
+		return err
+	}
+	// Secret volumes can only reference secrets within the namespace of the gateway pods.
+	err = c.Client.Get(context.TODO(), types.NamespacedName{Name: options.SecretName, Namespace: c.SecretNamespace}, &corev1.Secret{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return MissingSecretError{fmt.Errorf("secret %v to mount in namespace %v does not exist", options.SecretName, c.SecretNamespace)}
+		}
+		return err
+	}
+	return nil
+}
+
+func (tlsSecretPath) EnsureCredential(c ProviderConfig) (time.Duration, error) {
+	return 0, nil
+}
+
+func (tlsSecretPath) Credential(gatewayservice appv1alpha1.GatewayService, mode networkv3.Server_TLSOptions_TLSmode) *Credential {
+	// PASSTHROUGH secrets are handled by the application.
+	if mode == networkv3.Server_TLSOptions_PASSTHROUGH {
+		return nil
+	}
+	// The gateway pods are rolled by the controller to pick up changes to the mounted files.
+	// https://github.com/xUnholy/k8s-istio-gateway-service-operator/issues/17
+	return &Credential{
+		CertPath:        gatewayservice.Spec.TLSOptions.TLSSecretPath.CertPath,
+		KeyPath:         gatewayservice.Spec.TLSOptions.TLSSecretPath.KeyPath,
+		MountSecretName: gatewayservice.Spec.TLSOptions.TLSSecretPath.SecretName,
+	}
+}
+
+func (tlsSecretPath) Cleanup(c ProviderConfig) error {
+	return nil
+}
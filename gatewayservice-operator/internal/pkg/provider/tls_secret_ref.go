@@ -0,0 +1,66 @@
+package provider
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/certificate"
+	appv1alpha1 "github.com/xunholy/k8s-istio-gateway-service-operator/pkg/apis/crd/v1alpha1"
+	networkv3 "istio.io/api/networking/v1alpha3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// tlsSecretRef references a secret which already exists. There is an assumption the Gateway has access to the secret
+// referenced and that it exists prior to being referenced. In PASSTHROUGH mode the secret is owned by the application,
+// which terminates TLS itself, so it is resolved against the namespace of the GatewayService and only checked.
+type tlsSecretRef struct{}
+
+func (tlsSecretRef) Validate(c ProviderConfig) error {
+	secretName := c.GatewayService.Spec.TLSOptions.TLSSecretRef.SecretName
+	secretObj := &corev1.Secret{}
+	err := c.Client.Get(context.TODO(), types.NamespacedName{Name: secretName, Namespace: c.SecretNamespace}, secretObj)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return MissingSecretError{fmt.Errorf("reference to secret %v in namespace %v does not exist", secretName, c.SecretNamespace)}
+		}
+		return err
+	}
+	if c.Mode == networkv3.Server_TLSOptions_PASSTHROUGH {
+		return wellFormed(secretObj)
+	}
+	return nil
+}
+
+// wellFormed returns an error unless the secret holds a PEM encoded certificate and key. The GatewayService is
+// reconciled again once the secret is changed.
+func wellFormed(secretObj *corev1.Secret) error {
+	_, err := certificate.Parse(secretObj.Data["tls.crt"])
+	if err != nil {
+		return fmt.Errorf("secret %v in namespace %v does not hold a valid tls.crt: %v", secretObj.Name, secretObj.Namespace, err)
+	}
+	block, _ := pem.Decode(secretObj.Data["tls.key"])
+	if block == nil {
+		return fmt.Errorf("secret %v in namespace %v does not hold a PEM encoded tls.key", secretObj.Name, secretObj.Namespace)
+	}
+	return nil
+}
+
+func (tlsSecretRef) EnsureCredential(c ProviderConfig) (time.Duration, error) {
+	return 0, nil
+}
+
+func (tlsSecretRef) Credential(gatewayservice appv1alpha1.GatewayService, mode networkv3.Server_TLSOptions_TLSmode) *Credential {
+	// PASSTHROUGH secrets are handled by the application and never served by the Gateway.
+	if mode == networkv3.Server_TLSOptions_PASSTHROUGH {
+		return nil
+	}
+	return &Credential{Name: gatewayservice.Spec.TLSOptions.TLSSecretRef.SecretName}
+}
+
+func (tlsSecretRef) Cleanup(c ProviderConfig) error {
+	return nil
+}
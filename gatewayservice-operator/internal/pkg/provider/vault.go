@@ -0,0 +1,100 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/secret"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/validate"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/vault"
+	appv1alpha1 "github.com/xunholy/k8s-istio-gateway-service-operator/pkg/apis/crd/v1alpha1"
+	networkv3 "istio.io/api/networking/v1alpha3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// vaultProvider creates a secret holding the cert and key sourced from the Vault server of the operator config. The
+// credential is only sourced again once it is due for refresh or the Vault options have changed.
+type vaultProvider struct{}
+
+func (vaultProvider) Validate(c ProviderConfig) error {
+	err := validate.ValidateVault(*c.GatewayService.Spec.TLSOptions.Vault)
+	if err != nil {
+		return err
+	}
+	return c.Vault.Allows(c.GatewayService.Namespace, c.GatewayService.Spec.TLSOptions.Vault)
+}
+
+func (vaultProvider) EnsureCredential(c ProviderConfig) (time.Duration, error) {
+	gatewayservice := c.GatewayService
+	options := gatewayservice.Spec.TLSOptions.Vault
+	err := c.Vault.Allows(gatewayservice.Namespace, options)
+	if err != nil {
+		return 0, err
+	}
+	hash := vault.Hash(c.Vault, options, c.hosts())
+	secretObj := &corev1.Secret{}
+	key := types.NamespacedName{Name: SecretName(*gatewayservice), Namespace: c.SecretNamespace}
+	err = c.Client.Get(context.TODO(), key, secretObj)
+	if err != nil && !errors.IsNotFound(err) {
+		return 0, err
+	}
+	exists := err == nil
+	if exists {
+		if !secret.HasLabels(secretObj.Labels, secret.OwnerLabels(gatewayservice)) && !secret.IsLegacy(secretObj, gatewayservice) {
+			return 0, fmt.Errorf("secret %s in namespace %s is not managed by the operator", key.Name, key.Namespace)
+		}
+		refreshAt, err := time.Parse(time.RFC3339, secretObj.Annotations[vault.RefreshAtAnnotation])
+		// Secrets holding the issuing CA as ca.crt are sourced again, so clients are no longer verified with it.
+		_, legacyCA := secretObj.Data[vault.LegacyCAKey]
+		if err == nil && secretObj.Annotations[vault.HashAnnotation] == hash && time.Now().Before(refreshAt) && !legacyCA {
+			return time.Until(refreshAt), nil
+		}
+	}
+
+	credential, err := vault.Fetch(c.Vault, options, c.hosts())
+	if err != nil {
+		return 0, err
+	}
+	data := map[string][]byte{
+		"tls.crt": credential.Cert,
+		"tls.key": credential.Key,
+	}
+	if len(credential.CA) > 0 {
+		data[vault.IssuingCAKey] = credential.CA
+	}
+	annotations := secret.Annotations(gatewayservice)
+	annotations[vault.HashAnnotation] = hash
+	annotations[vault.RefreshAtAnnotation] = time.Now().Add(credential.RefreshAfter).UTC().Format(time.RFC3339)
+
+	if exists {
+		if secretObj.Annotations == nil {
+			secretObj.Annotations = map[string]string{}
+		}
+		for k, v := range annotations {
+			secretObj.Annotations[k] = v
+		}
+		secretObj.Labels = labels(gatewayservice, "vault")
+		secretObj.Data = data
+		return credential.RefreshAfter, c.Client.Update(context.TODO(), secretObj)
+	}
+	s := secret.SecretConfig{
+		Name:           key.Name,
+		Namespace:      key.Namespace,
+		Labels:         labels(gatewayservice, "vault"),
+		Annotations:    annotations,
+		GatewayService: gatewayservice,
+		Data:           data,
+	}
+	return credential.RefreshAfter, create(c, secret.Reconcile(s))
+}
+
+func (vaultProvider) Credential(gatewayservice appv1alpha1.GatewayService, mode networkv3.Server_TLSOptions_TLSmode) *Credential {
+	return &Credential{Name: SecretName(gatewayservice)}
+}
+
+func (vaultProvider) Cleanup(c ProviderConfig) error {
+	return sweep(c, "vault", SecretName(*c.GatewayService))
+}
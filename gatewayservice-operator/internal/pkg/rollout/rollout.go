@@ -0,0 +1,233 @@
+package rollout
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+// HashAnnotation is stamped on the gateway pod template, a change of the hash rolls the gateway pods so the
+// certificates mounted for TLSSecretPath are picked up.
+const HashAnnotation = "crd.xunholy.github.com/tls-secret-path-hash"
+
+// StrategyAnnotation records the rollout strategy of the Deployment while the operator rolls the gateway pods, so the
+// strategy set by the owner of the Deployment is restored once the rollout is complete.
+const StrategyAnnotation = "crd.xunholy.github.com/rollout-strategy"
+
+// strategy is the rollout strategy of a Deployment recorded by the StrategyAnnotation.
+type strategy struct {
+	MinReadySeconds int32                     `json:"minReadySeconds"`
+	Strategy        appsv1.DeploymentStrategy `json:"strategy"`
+}
+
+type RolloutConfig struct {
+	Deployment *appsv1.Deployment
+	Hash       string
+
+	// MaxUnavailable is the number or percentage of gateway pods which may be unavailable during the rollout.
+	MaxUnavailable intstr.IntOrString
+
+	// GracePeriod is the time a new gateway pod must be ready before the next batch of pods is rolled.
+	GracePeriod time.Duration
+}
+
+// Reconcile returns a strategic merge patch which stamps the hash on the pod template of the Deployment and
+// configures the rolling update so the pods are rolled in batches. The strategy of the Deployment is recorded first and
+// restored by the patch returned once the rollout is complete. A nil patch is returned when there is nothing to do.
+func Reconcile(r RolloutConfig) ([]byte, error) {
+	if r.Deployment.Spec.Template.Annotations[HashAnnotation] == r.Hash {
+		return restore(r.Deployment)
+	}
+	metadata := map[string]interface{}{}
+	// A rollout started before the previous one completed keeps the strategy recorded by the previous one.
+	if _, recorded := r.Deployment.Annotations[StrategyAnnotation]; !recorded {
+		original, err := json.Marshal(strategy{
+			MinReadySeconds: r.Deployment.Spec.MinReadySeconds,
+			Strategy:        r.Deployment.Spec.Strategy,
+		})
+		if err != nil {
+			return nil, err
+		}
+		metadata["annotations"] = map[string]string{StrategyAnnotation: string(original)}
+	}
+	return json.Marshal(map[string]interface{}{
+		"metadata": metadata,
+		"spec": map[string]interface{}{
+			"minReadySeconds": int32(r.GracePeriod / time.Second),
+			"strategy": map[string]interface{}{
+				"type": appsv1.RollingUpdateDeploymentStrategyType,
+				"rollingUpdate": map[string]interface{}{
+					"maxUnavailable": r.MaxUnavailable,
+				},
+			},
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]string{HashAnnotation: r.Hash},
+				},
+			},
+		},
+	})
+}
+
+// restore returns a strategic merge patch which restores the strategy recorded for the Deployment once its rollout is
+// complete, nil if there is nothing to restore or the rollout is in progress.
+func restore(d *appsv1.Deployment) ([]byte, error) {
+	annotation, recorded := d.Annotations[StrategyAnnotation]
+	if !recorded {
+		return nil, nil
+	}
+	if complete, _ := Progress(d); !complete {
+		return nil, nil
+	}
+	original := strategy{}
+	err := json.Unmarshal([]byte(annotation), &original)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s annotation on deployment %s/%s: %v", StrategyAnnotation, d.Namespace, d.Name, err)
+	}
+	return json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{StrategyAnnotation: nil},
+		},
+		"spec": map[string]interface{}{
+			"minReadySeconds": original.MinReadySeconds,
+			"strategy": map[string]interface{}{
+				"type": original.Strategy.Type,
+				// Recreate Deployments must not keep the rollingUpdate set for the rollout.
+				"rollingUpdate": original.Strategy.RollingUpdate,
+			},
+		},
+	})
+}
+
+// Apply returns a copy of the Deployment with the strategic merge patch applied in the same way as the API server, so
+// the patches of the mounts and the rollout are sent as a single update. The Deployment is returned when the patch is
+// nil.
+func Apply(d *appsv1.Deployment, patch []byte) (*appsv1.Deployment, error) {
+	if patch == nil {
+		return d, nil
+	}
+	original, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	patched, err := strategicpatch.StrategicMergePatch(original, patch, appsv1.Deployment{})
+	if err != nil {
+		return nil, err
+	}
+	result := &appsv1.Deployment{}
+	err = json.Unmarshal(patched, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Diff returns a strategic merge patch which updates the original Deployment to the modified Deployment, nil if they
+// are equal.
+func Diff(original *appsv1.Deployment, modified *appsv1.Deployment) ([]byte, error) {
+	originalJSON, err := json.Marshal(original)
+	if err != nil {
+		return nil, err
+	}
+	modifiedJSON, err := json.Marshal(modified)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := strategicpatch.CreateTwoWayMergePatch(originalJSON, modifiedJSON, appsv1.Deployment{})
+	if err != nil || string(patch) == "{}" {
+		return nil, err
+	}
+	return patch, nil
+}
+
+// Progress reports whether the rollout of the Deployment is complete along with a description of its progress.
+func Progress(d *appsv1.Deployment) (bool, string) {
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	if d.Status.ObservedGeneration < d.Generation {
+		return false, fmt.Sprintf("deployment %s/%s rollout is pending", d.Namespace, d.Name)
+	}
+	if d.Status.UpdatedReplicas < replicas || d.Status.AvailableReplicas < replicas || d.Status.Replicas > d.Status.UpdatedReplicas {
+		return false, fmt.Sprintf("deployment %s/%s rollout in progress, %d of %d replicas updated, %d available",
+			d.Namespace, d.Name, d.Status.UpdatedReplicas, replicas, d.Status.AvailableReplicas)
+	}
+	return true, fmt.Sprintf("deployment %s/%s rolled out %d replicas", d.Namespace, d.Name, replicas)
+}
+
+// Selects returns the first Deployment whose pods are selected by the Gateway selector, nil if there is none.
+func Selects(selector map[string]string, deployments []appsv1.Deployment) *appsv1.Deployment {
+	if len(selector) == 0 {
+		return nil
+	}
+	for i := range deployments {
+		if labels.SelectorFromSet(selector).Matches(labels.Set(deployments[i].Spec.Template.Labels)) {
+			return &deployments[i]
+		}
+	}
+	return nil
+}
+
+// SecretsFor returns the names of the secret volumes of the Deployment which are mounted at or above the paths.
+func SecretsFor(d *appsv1.Deployment, paths []string) []string {
+	volumes := map[string]string{}
+	for _, v := range d.Spec.Template.Spec.Volumes {
+		if v.Secret != nil {
+			volumes[v.Name] = v.Secret.SecretName
+		}
+	}
+	found := map[string]bool{}
+	for _, c := range d.Spec.Template.Spec.Containers {
+		for _, m := range c.VolumeMounts {
+			secretName, ok := volumes[m.Name]
+			if !ok {
+				continue
+			}
+			for _, path := range paths {
+				if strings.HasPrefix(path, strings.TrimSuffix(m.MountPath, "/")+"/") {
+					found[secretName] = true
+				}
+			}
+		}
+	}
+	names := []string{}
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Hash returns a hash of the paths and the data of the secrets mounted for them.
+func Hash(paths []string, secrets []corev1.Secret) string {
+	h := sha256.New()
+	sorted := append([]string{}, paths...)
+	sort.Strings(sorted)
+	for _, path := range sorted {
+		fmt.Fprintf(h, "path:%s\n", path)
+	}
+	sort.Slice(secrets, func(i, j int) bool { return secrets[i].Name < secrets[j].Name })
+	for _, s := range secrets {
+		keys := []string{}
+		for k := range s.Data {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		fmt.Fprintf(h, "secret:%s/%s\n", s.Namespace, s.Name)
+		for _, k := range keys {
+			fmt.Fprintf(h, "%s:%x\n", k, s.Data[k])
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
@@ -0,0 +1,216 @@
+package rollout_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/rollout"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+func newDeployment() *appsv1.Deployment {
+	replicas := int32(3)
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "istio-ingressgateway", Namespace: "istio-system"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"istio": "ingressgateway", "app": "istio-ingressgateway"}},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name: "istio-proxy",
+						VolumeMounts: []corev1.VolumeMount{
+							{Name: "ingressgateway-certs", MountPath: "/etc/istio/ingressgateway-certs"},
+							{Name: "istio-envoy", MountPath: "/etc/istio/proxy"},
+						},
+					}},
+					Volumes: []corev1.Volume{
+						{Name: "ingressgateway-certs", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "istio-ingressgateway-certs"}}},
+						{Name: "istio-envoy", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestRolloutReconcile(t *testing.T) {
+	d := newDeployment()
+	patch, err := rollout.Reconcile(rollout.RolloutConfig{
+		Deployment:     d,
+		Hash:           "abc",
+		MaxUnavailable: intstr.FromString("25%"),
+		GracePeriod:    30 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	original, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("marshal deployment: (%v)", err)
+	}
+	patched, err := strategicpatch.StrategicMergePatch(original, patch, appsv1.Deployment{})
+	if err != nil {
+		t.Fatalf("apply patch: (%v)", err)
+	}
+	d = &appsv1.Deployment{}
+	err = json.Unmarshal(patched, d)
+	if err != nil {
+		t.Fatalf("unmarshal deployment: (%v)", err)
+	}
+	if d.Spec.Template.Annotations[rollout.HashAnnotation] != "abc" {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", "abc", d.Spec.Template.Annotations[rollout.HashAnnotation])
+	}
+	expected := intstr.FromString("25%")
+	if !reflect.DeepEqual(d.Spec.Strategy.RollingUpdate.MaxUnavailable, &expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", &expected, d.Spec.Strategy.RollingUpdate.MaxUnavailable)
+	}
+	if d.Spec.MinReadySeconds != 30 {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", 30, d.Spec.MinReadySeconds)
+	}
+	if len(d.Spec.Template.Spec.Volumes) != 2 {
+		t.Fatalf("expected unrelated fields to be left untouched, found (%+v)", d.Spec.Template.Spec.Volumes)
+	}
+	patch, err = rollout.Reconcile(rollout.RolloutConfig{Deployment: d, Hash: "abc"})
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if patch != nil {
+		t.Fatalf("expected no patch for the same hash, found (%s)", patch)
+	}
+}
+
+func TestRolloutRestoreStrategy(t *testing.T) {
+	d := newDeployment()
+	d.Spec.MinReadySeconds = 5
+	d.Spec.Strategy = appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}
+	patch, err := rollout.Reconcile(rollout.RolloutConfig{
+		Deployment:     d,
+		Hash:           "abc",
+		MaxUnavailable: intstr.FromString("25%"),
+		GracePeriod:    30 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	d, err = rollout.Apply(d, patch)
+	if err != nil {
+		t.Fatalf("apply patch: (%v)", err)
+	}
+	if d.Spec.Strategy.Type != appsv1.RollingUpdateDeploymentStrategyType || d.Spec.MinReadySeconds != 30 {
+		t.Fatalf("expected the rolling update to be configured, found (%+v)", d.Spec)
+	}
+
+	// The strategy is left in place while the rollout is in progress.
+	d.Status = appsv1.DeploymentStatus{Replicas: 4, UpdatedReplicas: 1, AvailableReplicas: 3}
+	patch, err = rollout.Reconcile(rollout.RolloutConfig{Deployment: d, Hash: "abc"})
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if patch != nil {
+		t.Fatalf("expected no patch while the rollout is in progress, found (%s)", patch)
+	}
+
+	// The strategy of the owner is restored once the rollout is complete.
+	d.Status = appsv1.DeploymentStatus{Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 3}
+	patch, err = rollout.Reconcile(rollout.RolloutConfig{Deployment: d, Hash: "abc"})
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	d, err = rollout.Apply(d, patch)
+	if err != nil {
+		t.Fatalf("apply patch: (%v)", err)
+	}
+	expected := appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}
+	if !reflect.DeepEqual(d.Spec.Strategy, expected) || d.Spec.MinReadySeconds != 5 {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, d.Spec)
+	}
+	if _, ok := d.Annotations[rollout.StrategyAnnotation]; ok {
+		t.Fatalf("expected %s annotation to be removed, found (%+v)", rollout.StrategyAnnotation, d.Annotations)
+	}
+}
+
+func TestRolloutDiff(t *testing.T) {
+	d := newDeployment()
+	patch, err := rollout.Diff(d, newDeployment())
+	if err != nil {
+		t.Fatalf("diff: (%v)", err)
+	}
+	if patch != nil {
+		t.Fatalf("expected no patch for equal deployments, found (%s)", patch)
+	}
+	modified := newDeployment()
+	modified.Spec.MinReadySeconds = 30
+	patch, err = rollout.Diff(d, modified)
+	if err != nil {
+		t.Fatalf("diff: (%v)", err)
+	}
+	d, err = rollout.Apply(d, patch)
+	if err != nil {
+		t.Fatalf("apply patch: (%v)", err)
+	}
+	if !reflect.DeepEqual(d, modified) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", modified, d)
+	}
+}
+
+func TestRolloutProgress(t *testing.T) {
+	d := newDeployment()
+	d.Status = appsv1.DeploymentStatus{Replicas: 4, UpdatedReplicas: 1, AvailableReplicas: 3}
+	complete, _ := rollout.Progress(d)
+	if complete {
+		t.Fatalf("expected rollout to be in progress")
+	}
+	d.Status = appsv1.DeploymentStatus{Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 3}
+	complete, _ = rollout.Progress(d)
+	if !complete {
+		t.Fatalf("expected rollout to be complete")
+	}
+}
+
+func TestRolloutSelects(t *testing.T) {
+	d := newDeployment()
+	other := appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "other"}}
+	found := rollout.Selects(map[string]string{"istio": "ingressgateway"}, []appsv1.Deployment{other, *d})
+	if found == nil || found.Name != d.Name {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", d.Name, found)
+	}
+	if rollout.Selects(map[string]string{}, []appsv1.Deployment{*d}) != nil {
+		t.Fatalf("expected an empty selector to select no deployment")
+	}
+}
+
+func TestRolloutSecretsFor(t *testing.T) {
+	d := newDeployment()
+	expected := []string{"istio-ingressgateway-certs"}
+	found := rollout.SecretsFor(d, []string{"/etc/istio/ingressgateway-certs/tls.crt", "/etc/istio/ingressgateway-certs/tls.key"})
+	if !reflect.DeepEqual(found, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, found)
+	}
+	found = rollout.SecretsFor(d, []string{"/etc/certs/tls.crt"})
+	if len(found) != 0 {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", []string{}, found)
+	}
+}
+
+func TestRolloutHash(t *testing.T) {
+	paths := []string{"/etc/istio/ingressgateway-certs/tls.crt"}
+	secrets := []corev1.Secret{{
+		ObjectMeta: metav1.ObjectMeta{Name: "istio-ingressgateway-certs", Namespace: "istio-system"},
+		Data:       map[string][]byte{"tls.crt": []byte("cert")},
+	}}
+	hash := rollout.Hash(paths, secrets)
+	if hash != rollout.Hash(paths, secrets) {
+		t.Fatalf("expected hash to be stable")
+	}
+	secrets[0].Data["tls.crt"] = []byte("renewed")
+	if hash == rollout.Hash(paths, secrets) {
+		t.Fatalf("expected hash to change with the secret data")
+	}
+}
@@ -0,0 +1,84 @@
+package secret
+
+import (
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/names"
+	appv1alpha1 "github.com/xunholy/k8s-istio-gateway-service-operator/pkg/apis/crd/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// ManagedByLabel identifies secrets created by the operator.
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+	// ManagedBy is the value of ManagedByLabel for every secret created by the operator.
+	ManagedBy = "gatewayservice-operator"
+	// OwnerNamespaceLabel is the namespace of the GatewayService the secret was created for.
+	OwnerNamespaceLabel = "crd.xunholy.github.com/owner-namespace"
+	// OwnerNameLabel is the name of the GatewayService the secret was created for, truncated and hashed by names.Label
+	// when it does not fit within a label value.
+	OwnerNameLabel = "crd.xunholy.github.com/owner-name"
+	// OwnerNameAnnotation is the full name of the GatewayService the secret was created for.
+	OwnerNameAnnotation = "crd.xunholy.github.com/owner-name"
+	// OwnerUIDLabel is the UID of the GatewayService the secret was created for.
+	OwnerUIDLabel = "crd.xunholy.github.com/owner-uid"
+	// ProviderLabel is the TLSOptions field of the certificate provider which created the secret.
+	ProviderLabel = "crd.xunholy.github.com/provider"
+	// LegacyNamespaceLabel is the only label applied by previous versions of the operator, the namespace of the
+	// GatewayService the secret was created for.
+	LegacyNamespaceLabel = "Namespace"
+)
+
+// Labels returns the standard labels applied to every secret created for the GatewayService.
+func Labels(gatewayservice *appv1alpha1.GatewayService) map[string]string {
+	return map[string]string{
+		ManagedByLabel:      ManagedBy,
+		OwnerNamespaceLabel: gatewayservice.Namespace,
+		OwnerNameLabel:      names.Label(gatewayservice.Name),
+		OwnerUIDLabel:       string(gatewayservice.UID),
+	}
+}
+
+// OwnerLabels returns the labels used to select every secret created for the GatewayService. The UID is omitted
+// so secrets left behind by a previous GatewayService with the same name are also selected.
+func OwnerLabels(gatewayservice *appv1alpha1.GatewayService) map[string]string {
+	return map[string]string{
+		ManagedByLabel:      ManagedBy,
+		OwnerNamespaceLabel: gatewayservice.Namespace,
+		OwnerNameLabel:      names.Label(gatewayservice.Name),
+	}
+}
+
+// Annotations returns the annotations applied to every secret created for the GatewayService.
+func Annotations(gatewayservice *appv1alpha1.GatewayService) map[string]string {
+	return map[string]string{
+		OwnerNameAnnotation: gatewayservice.Name,
+	}
+}
+
+// Owner returns the GatewayService the secret was created for. Secrets created before the OwnerNameAnnotation was
+// introduced only carry the OwnerNameLabel, which holds the full name as longer names could not be labelled.
+func Owner(secretObj metav1.Object) types.NamespacedName {
+	name, ok := secretObj.GetAnnotations()[OwnerNameAnnotation]
+	if !ok {
+		name = secretObj.GetLabels()[OwnerNameLabel]
+	}
+	return types.NamespacedName{Name: name, Namespace: secretObj.GetLabels()[OwnerNamespaceLabel]}
+}
+
+// IsLegacy reports whether the secret was created for the GatewayService by a previous version of the operator, which
+// named the secret after the GatewayService and labelled it with its namespace alone.
+func IsLegacy(secretObj *corev1.Secret, gatewayservice *appv1alpha1.GatewayService) bool {
+	return secretObj.Name == names.LegacySecret(gatewayservice.Name, gatewayservice.Namespace) &&
+		secretObj.Labels[LegacyNamespaceLabel] == gatewayservice.Namespace
+}
+
+// HasLabels reports whether every label is present with the same value on the secret labels.
+func HasLabels(labels map[string]string, expected map[string]string) bool {
+	for k, v := range expected {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
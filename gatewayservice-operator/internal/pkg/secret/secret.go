@@ -10,28 +10,37 @@ type SecretConfig struct {
 	Name           string
 	Namespace      string
 	Labels         map[string]string
+	Annotations    map[string]string
 	GatewayService *appv1alpha1.GatewayService
+
+	// Data overrides the secret data, otherwise the TLSSecret Cert/Key of the GatewayService are used.
+	Data map[string][]byte
 }
 
 func Reconcile(s SecretConfig) *corev1.Secret {
+	data := s.Data
+	if data == nil {
+		data = map[string][]byte{
+			"tls.key": []byte(*s.GatewayService.Spec.TLSOptions.TLSSecret.Key),
+			"tls.crt": []byte(*s.GatewayService.Spec.TLSOptions.TLSSecret.Cert),
+		}
+	}
 	return &corev1.Secret{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "Secret",
 			APIVersion: "v1",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      s.Name,
-			Namespace: s.Namespace,
-			Labels:    s.Labels,
+			Name:        s.Name,
+			Namespace:   s.Namespace,
+			Labels:      s.Labels,
+			Annotations: s.Annotations,
 		},
 		// Data contains the secret data. Each key must consist of alphanumeric
 		// characters, '-', '_' or '.'. The serialized form of the secret data is a
 		// base64 encoded string, representing the arbitrary (possibly non-string)
 		// data value here. Described in https://tools.ietf.org/html/rfc4648#section-4
-		Data: map[string][]byte{
-			"tls.key": []byte(*s.GatewayService.Spec.TLSOptions.TLSSecret.Key),
-			"tls.crt": []byte(*s.GatewayService.Spec.TLSOptions.TLSSecret.Cert),
-		},
+		Data: data,
 		// Used to facilitate programmatic handling of secret data.
 		Type: "kubernetes.io/tls",
 	}
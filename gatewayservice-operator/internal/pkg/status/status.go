@@ -2,13 +2,20 @@ package status
 
 import (
 	appv1alpha1 "github.com/xunholy/k8s-istio-gateway-service-operator/pkg/apis/crd/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type StatusConfig struct {
-	Success         bool
-	ErrorMessage    string
-	SecretName      string
-	SecretNamespace string
+	Success          bool
+	ErrorMessage     string
+	SecretName       string
+	SecretNamespace  string
+	SecretNamespaces []string
+	Conditions       []appv1alpha1.GatewayServiceCondition
+	ValidGeneration  int64
+	ValidSpec        *appv1alpha1.GatewayServiceSpec
+	Hosts            []string
+	Gateway          string
 }
 
 func Reconcile(status StatusConfig) *appv1alpha1.GatewayServiceStatus {
@@ -17,9 +24,53 @@ func Reconcile(status StatusConfig) *appv1alpha1.GatewayServiceStatus {
 			Success:      status.Success,
 			ErrorMessage: status.ErrorMessage,
 			CreatedSecretDetails: appv1alpha1.CreatedSecretDetails{
-				SecretName:      status.SecretName,
-				SecretNamespace: status.SecretNamespace,
+				SecretName:       status.SecretName,
+				SecretNamespace:  status.SecretNamespace,
+				SecretNamespaces: status.SecretNamespaces,
 			},
 		},
+		Conditions:      status.Conditions,
+		ValidGeneration: status.ValidGeneration,
+		ValidSpec:       status.ValidSpec,
+		Hosts:           status.Hosts,
+		Gateway:         status.Gateway,
 	}
 }
+
+// FindCondition returns the condition of the given type, or nil if it is not present.
+func FindCondition(conditions []appv1alpha1.GatewayServiceCondition, conditionType appv1alpha1.GatewayServiceConditionType) *appv1alpha1.GatewayServiceCondition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// SetCondition adds the condition or replaces the existing condition of the same type. The transition time is only
+// updated when the status of the condition changes.
+func SetCondition(conditions []appv1alpha1.GatewayServiceCondition, condition appv1alpha1.GatewayServiceCondition) []appv1alpha1.GatewayServiceCondition {
+	existing := FindCondition(conditions, condition.Type)
+	if existing == nil {
+		condition.LastTransitionTime = metav1.Now()
+		return append(conditions, condition)
+	}
+	if existing.Status != condition.Status {
+		condition.LastTransitionTime = metav1.Now()
+	} else {
+		condition.LastTransitionTime = existing.LastTransitionTime
+	}
+	*existing = condition
+	return conditions
+}
+
+// RemoveCondition removes the condition of the given type if it is present.
+func RemoveCondition(conditions []appv1alpha1.GatewayServiceCondition, conditionType appv1alpha1.GatewayServiceConditionType) []appv1alpha1.GatewayServiceCondition {
+	var result []appv1alpha1.GatewayServiceCondition
+	for _, c := range conditions {
+		if c.Type != conditionType {
+			result = append(result, c)
+		}
+	}
+	return result
+}
@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	s "github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/status"
 	appv1alpha1 "github.com/xunholy/k8s-istio-gateway-service-operator/pkg/apis/crd/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var (
@@ -36,3 +39,45 @@ func TestStatusReconcile(t *testing.T) {
 		t.Fatalf("Expected: (%+v)\n Found: (%+v)", expected, secretObject)
 	}
 }
+
+func TestSetCondition(t *testing.T) {
+	conditions := s.SetCondition(nil, appv1alpha1.GatewayServiceCondition{
+		Type:    appv1alpha1.CertificateExpiring,
+		Status:  corev1.ConditionFalse,
+		Reason:  "CertificateValid",
+		Message: "certificate is valid",
+	})
+	if len(conditions) != 1 {
+		t.Fatalf("expected a single condition, found (%+v)", conditions)
+	}
+	transitionTime := metav1.NewTime(time.Now().Add(-1 * time.Hour))
+	conditions[0].LastTransitionTime = transitionTime
+
+	// The transition time is retained while the status is unchanged.
+	conditions = s.SetCondition(conditions, appv1alpha1.GatewayServiceCondition{
+		Type:    appv1alpha1.CertificateExpiring,
+		Status:  corev1.ConditionFalse,
+		Reason:  "CertificateValid",
+		Message: "certificate is still valid",
+	})
+	condition := s.FindCondition(conditions, appv1alpha1.CertificateExpiring)
+	if condition == nil || condition.Message != "certificate is still valid" || !condition.LastTransitionTime.Equal(&transitionTime) {
+		t.Fatalf("expected condition message to be updated and transition time retained, found (%+v)", condition)
+	}
+
+	// The transition time is updated when the status changes.
+	conditions = s.SetCondition(conditions, appv1alpha1.GatewayServiceCondition{
+		Type:   appv1alpha1.CertificateExpiring,
+		Status: corev1.ConditionTrue,
+		Reason: "CertificateExpiring",
+	})
+	condition = s.FindCondition(conditions, appv1alpha1.CertificateExpiring)
+	if condition == nil || condition.Status != corev1.ConditionTrue || condition.LastTransitionTime.Equal(&transitionTime) {
+		t.Fatalf("expected condition status and transition time to be updated, found (%+v)", condition)
+	}
+
+	conditions = s.RemoveCondition(conditions, appv1alpha1.CertificateExpiring)
+	if s.FindCondition(conditions, appv1alpha1.CertificateExpiring) != nil {
+		t.Fatalf("expected condition to be removed, found (%+v)", conditions)
+	}
+}
@@ -0,0 +1,78 @@
+package validate_test
+
+import (
+	"testing"
+
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/validate"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/pkg/apis/crd/v1alpha1"
+)
+
+func TestTLSOptionExists(t *testing.T) {
+	options := map[string]*v1alpha1.TLSOptions{
+		"no tlsOptions": nil,
+		"no method":     {},
+		"tlsSecret":     {TLSSecret: &v1alpha1.TLSSecret{Cert: &cert, Key: &key}},
+		"tlsSecretRef":  {TLSSecretRef: &v1alpha1.TLSSecretRef{SecretName: "example"}},
+		"tlsSecretPath": {TLSSecretPath: &v1alpha1.TLSSecretPath{SecretName: "example"}},
+		"vault":         {Vault: &v1alpha1.Vault{KV: &v1alpha1.VaultKV{Path: "secret/data/example"}}},
+	}
+	// The options which are valid for each mode. The method configured is checked by provider.Exactly.
+	valid := map[string]map[string]bool{
+		"SIMPLE":           {"no method": true, "tlsSecret": true, "tlsSecretRef": true, "tlsSecretPath": true, "vault": true},
+		"MUTUAL":           {"no method": true, "tlsSecret": true, "tlsSecretRef": true, "tlsSecretPath": true, "vault": true},
+		"OPTIONAL_MUTUAL":  {"no method": true, "tlsSecret": true, "tlsSecretRef": true, "tlsSecretPath": true, "vault": true},
+		"PASSTHROUGH":      {"no tlsOptions": true, "no method": true, "tlsSecret": true, "tlsSecretRef": true, "tlsSecretPath": true, "vault": true},
+		"ISTIO_MUTUAL":     {"no tlsOptions": true},
+		"AUTO_PASSTHROUGH": {"no tlsOptions": true},
+	}
+	for mode, validOptions := range valid {
+		for option, tlsOptions := range options {
+			gatewayservice := &v1alpha1.GatewayService{
+				Spec: v1alpha1.GatewayServiceSpec{
+					Mode:       mode,
+					TLSOptions: tlsOptions,
+				},
+			}
+			err := validate.TLSOptionExists(gatewayservice)
+			if validOptions[option] && err != nil {
+				t.Fatalf("%s with %s: expected tlsOptions to be valid: (%v)", mode, option, err)
+			}
+			if !validOptions[option] && err == nil {
+				t.Fatalf("%s with %s: expected tlsOptions to be invalid", mode, option)
+			}
+		}
+	}
+}
+
+func TestTLSOptionCaCertificates(t *testing.T) {
+	caCertificates := "/etc/certs/ca.pem"
+	for _, mode := range []string{"MUTUAL", "OPTIONAL_MUTUAL"} {
+		gatewayservice := &v1alpha1.GatewayService{
+			Spec: v1alpha1.GatewayServiceSpec{
+				Mode:           mode,
+				CaCertificates: &caCertificates,
+				TLSOptions:     &v1alpha1.TLSOptions{TLSSecret: &v1alpha1.TLSSecret{Cert: &cert, Key: &key}},
+			},
+		}
+		err := validate.TLSOptionExists(gatewayservice)
+		if err != nil {
+			t.Fatalf("%s: expected certificates and caCertificates to be valid: (%v)", mode, err)
+		}
+	}
+}
+
+func TestTLSOptionEmpty(t *testing.T) {
+	caCertificates := "/etc/certs/ca.pem"
+	for _, mode := range []string{"ISTIO_MUTUAL", "AUTO_PASSTHROUGH"} {
+		gatewayservice := &v1alpha1.GatewayService{
+			Spec: v1alpha1.GatewayServiceSpec{
+				Mode:           mode,
+				CaCertificates: &caCertificates,
+			},
+		}
+		err := validate.TLSOptionExists(gatewayservice)
+		if err == nil {
+			t.Fatalf("%s: expected caCertificates to be invalid", mode)
+		}
+	}
+}
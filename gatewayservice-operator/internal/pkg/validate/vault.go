@@ -0,0 +1,20 @@
+package validate
+
+import (
+	"fmt"
+
+	"github.com/xunholy/k8s-istio-gateway-service-operator/pkg/apis/crd/v1alpha1"
+)
+
+func ValidateVault(vault v1alpha1.Vault) error {
+	if (vault.KV == nil) == (vault.PKI == nil) {
+		return fmt.Errorf("vault must contain either kv or pki")
+	}
+	if vault.KV != nil && vault.KV.Path == "" {
+		return fmt.Errorf("vault kv path cannot be empty")
+	}
+	if vault.PKI != nil && vault.PKI.Role == "" {
+		return fmt.Errorf("vault pki role cannot be empty")
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package validate_test
+
+import (
+	"testing"
+
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/validate"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/pkg/apis/crd/v1alpha1"
+)
+
+func TestValidateVault(t *testing.T) {
+	tests := []struct {
+		name  string
+		vault v1alpha1.Vault
+		valid bool
+	}{
+		{name: "kv", vault: v1alpha1.Vault{KV: &v1alpha1.VaultKV{Path: "secret/data/example"}}, valid: true},
+		{name: "pki", vault: v1alpha1.Vault{PKI: &v1alpha1.VaultPKI{Role: "example"}}, valid: true},
+		{name: "no source", vault: v1alpha1.Vault{}},
+		{name: "kv and pki", vault: v1alpha1.Vault{KV: &v1alpha1.VaultKV{Path: "secret/data/example"}, PKI: &v1alpha1.VaultPKI{Role: "example"}}},
+		{name: "no kv path", vault: v1alpha1.Vault{KV: &v1alpha1.VaultKV{}}},
+		{name: "no pki role", vault: v1alpha1.Vault{PKI: &v1alpha1.VaultPKI{}}},
+	}
+	for _, tt := range tests {
+		err := validate.ValidateVault(tt.vault)
+		if tt.valid && err != nil {
+			t.Fatalf("%s: expected vault to be valid: (%v)", tt.name, err)
+		}
+		if !tt.valid && err == nil {
+			t.Fatalf("%s: expected vault to be invalid", tt.name)
+		}
+	}
+}
@@ -0,0 +1,356 @@
+package vault
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	appv1alpha1 "github.com/xunholy/k8s-istio-gateway-service-operator/pkg/apis/crd/v1alpha1"
+)
+
+const (
+	// RefreshAtAnnotation records when the credential stored in the secret must be sourced from Vault again.
+	RefreshAtAnnotation = "crd.xunholy.github.com/vault-refresh-at"
+	// HashAnnotation records the hash of the Vault options the credential stored in the secret was sourced with.
+	HashAnnotation = "crd.xunholy.github.com/vault-hash"
+	// IssuingCAKey is the secret key holding the CA which issued a PKI certificate. The CA is not stored as ca.crt, which
+	// Istio reads as the CA verifying client certificates in MUTUAL mode.
+	IssuingCAKey = "issuing-ca.crt"
+	// LegacyCAKey is the secret key which held the issuing CA before IssuingCAKey was introduced.
+	LegacyCAKey = "ca.crt"
+
+	// DefaultRefreshInterval is used to refresh credentials which are not leased, such as KV version 2 secrets.
+	DefaultRefreshInterval = time.Hour
+)
+
+var (
+	// TokenPath is the service account token presented to Vault when logging in with the Kubernetes auth method.
+	TokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+)
+
+// Config is the Vault server and the paths and roles each namespace may use, set by the operator config. The service
+// account token of the operator is only ever presented to this server, never to an address chosen by a GatewayService.
+type Config struct {
+	Address       string
+	CACert        string
+	TLSServerName string
+	Role          string
+	AuthMountPath string
+	// Namespaces holds the policy of each namespace allowed to use Vault.
+	Namespaces map[string]Policy
+}
+
+// Policy limits the Vault paths and roles GatewayServices within a namespace may source certificates from.
+type Policy struct {
+	// KVPathPrefixes are the prefixes of the KV paths which may be read.
+	KVPathPrefixes []string
+	// PKIRoles are the roles which may issue certificates, of the form mountPath/role.
+	PKIRoles []string
+}
+
+// Allows reports an error when the Vault options of a GatewayService within the namespace are not allowed.
+func (c Config) Allows(namespace string, options *appv1alpha1.Vault) error {
+	if c.Address == "" {
+		return fmt.Errorf("vault is not configured by the operator config")
+	}
+	policy, ok := c.Namespaces[namespace]
+	if !ok {
+		return fmt.Errorf("vault is not allowed for namespace %s by the operator config", namespace)
+	}
+	if options.KV != nil {
+		if !cleanPath(options.KV.Path) || !hasPrefix(options.KV.Path, policy.KVPathPrefixes) {
+			return fmt.Errorf("vault kv path %s is not allowed for namespace %s by the operator config", options.KV.Path, namespace)
+		}
+	}
+	if options.PKI != nil {
+		role := pkiMountPath(options.PKI) + "/" + options.PKI.Role
+		if !cleanPath(role) || strings.Contains(options.PKI.Role, "/") || !contains(policy.PKIRoles, role) {
+			return fmt.Errorf("vault pki role %s is not allowed for namespace %s by the operator config", role, namespace)
+		}
+	}
+	return nil
+}
+
+// cleanPath reports whether the path is relative and already in its shortest form, so it cannot escape a prefix.
+func cleanPath(p string) bool {
+	return p != "" && !strings.HasPrefix(p, "/") && path.Clean(p) == p && !strings.ContainsAny(p, "?#%") &&
+		!strings.HasPrefix(p, "../") && p != ".."
+}
+
+// hasPrefix reports whether the path is one of the prefixes or nested beneath one.
+func hasPrefix(p string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if p == prefix || strings.HasPrefix(p, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Credential is a PEM encoded certificate and private key sourced from Vault.
+type Credential struct {
+	Cert []byte
+	Key  []byte
+	CA   []byte
+
+	// RefreshAfter is the duration after which the credential must be sourced from Vault again.
+	RefreshAfter time.Duration
+}
+
+// Client is a minimal client for the Vault HTTP API.
+type Client struct {
+	Address    string
+	Token      string
+	HTTPClient *http.Client
+}
+
+type response struct {
+	LeaseDuration int                    `json:"lease_duration"`
+	Data          map[string]interface{} `json:"data"`
+	Auth          *struct {
+		ClientToken string `json:"client_token"`
+	} `json:"auth"`
+	Errors []string `json:"errors"`
+}
+
+func NewClient(address string) *Client {
+	return &Client{
+		Address:    strings.TrimSuffix(address, "/"),
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// newConfiguredClient returns a client of the Vault server of the config, verified with its CA certificates.
+func newConfiguredClient(config Config) (*Client, error) {
+	if config.Address == "" {
+		return nil, fmt.Errorf("vault is not configured by the operator config")
+	}
+	c := NewClient(config.Address)
+	tlsConfig := &tls.Config{ServerName: config.TLSServerName}
+	if config.CACert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(config.CACert)) {
+			return nil, fmt.Errorf("vault caCert does not contain a PEM encoded certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	c.HTTPClient.Transport = &http.Transport{TLSClientConfig: tlsConfig}
+	return c, nil
+}
+
+func (c *Client) do(method string, path string, body interface{}) (*response, error) {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, fmt.Sprintf("%s/v1/%s", c.Address, strings.TrimPrefix(path, "/")), reader)
+	if err != nil {
+		return nil, err
+	}
+	if c.Token != "" {
+		req.Header.Set("X-Vault-Token", c.Token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	r := &response{}
+	err = json.NewDecoder(resp.Body).Decode(r)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("vault %s %s returned an invalid response: %v", method, path, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("vault %s %s returned %d: %s", method, path, resp.StatusCode, strings.Join(r.Errors, ", "))
+	}
+	return r, nil
+}
+
+// LoginKubernetes exchanges the service account token for a Vault token using the Kubernetes auth method.
+func (c *Client) LoginKubernetes(mountPath string, role string, jwt string) error {
+	r, err := c.do(http.MethodPost, fmt.Sprintf("auth/%s/login", mountPath), map[string]string{"role": role, "jwt": jwt})
+	if err != nil {
+		return err
+	}
+	if r.Auth == nil || r.Auth.ClientToken == "" {
+		return fmt.Errorf("vault kubernetes login for role %s did not return a token", role)
+	}
+	c.Token = r.Auth.ClientToken
+	return nil
+}
+
+// ReadKV reads the secret at path. KV version 2 secrets are unwrapped from their metadata.
+func (c *Client) ReadKV(path string, version int) (map[string]interface{}, time.Duration, error) {
+	r, err := c.do(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	data := r.Data
+	if version != 1 {
+		nested, ok := r.Data["data"].(map[string]interface{})
+		if !ok {
+			return nil, 0, fmt.Errorf("vault secret %s is not a KV version 2 secret", path)
+		}
+		data = nested
+	}
+	return data, time.Duration(r.LeaseDuration) * time.Second, nil
+}
+
+// IssuePKI issues a certificate from the PKI secrets engine role.
+func (c *Client) IssuePKI(mountPath string, role string, request map[string]interface{}) (map[string]interface{}, time.Duration, error) {
+	r, err := c.do(http.MethodPost, fmt.Sprintf("%s/issue/%s", mountPath, role), request)
+	if err != nil {
+		return nil, 0, err
+	}
+	return r.Data, time.Duration(r.LeaseDuration) * time.Second, nil
+}
+
+// Fetch logs in to the Vault server of the config using the Kubernetes auth method and sources the credential from
+// either KV or PKI. The options must be allowed by the config for the namespace of the GatewayService.
+func Fetch(config Config, options *appv1alpha1.Vault, hosts []string) (*Credential, error) {
+	c, err := newConfiguredClient(config)
+	if err != nil {
+		return nil, err
+	}
+	jwt, err := ioutil.ReadFile(TokenPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read service account token: %v", err)
+	}
+	mountPath := config.AuthMountPath
+	if mountPath == "" {
+		mountPath = "kubernetes"
+	}
+	err = c.LoginKubernetes(mountPath, config.Role, strings.TrimSpace(string(jwt)))
+	if err != nil {
+		return nil, err
+	}
+	if options.KV != nil {
+		return fetchKV(c, options.KV)
+	}
+	if options.PKI != nil {
+		return issuePKI(c, options.PKI, hosts)
+	}
+	return nil, fmt.Errorf("vault must contain either kv or pki")
+}
+
+func fetchKV(c *Client, options *appv1alpha1.VaultKV) (*Credential, error) {
+	data, lease, err := c.ReadKV(options.Path, options.Version)
+	if err != nil {
+		return nil, err
+	}
+	certKey := options.CertKey
+	if certKey == "" {
+		certKey = "tls.crt"
+	}
+	keyKey := options.KeyKey
+	if keyKey == "" {
+		keyKey = "tls.key"
+	}
+	cert, _ := data[certKey].(string)
+	key, _ := data[keyKey].(string)
+	if cert == "" || key == "" {
+		return nil, fmt.Errorf("vault secret %s must contain %s and %s", options.Path, certKey, keyKey)
+	}
+	credential := &Credential{Cert: []byte(cert), Key: []byte(key), RefreshAfter: DefaultRefreshInterval}
+	if lease > 0 {
+		credential.RefreshAfter = refreshAfter(lease)
+	}
+	return credential, nil
+}
+
+// pkiMountPath returns the mount path of the PKI secrets engine, defaulting to "pki".
+func pkiMountPath(options *appv1alpha1.VaultPKI) string {
+	if options.MountPath == "" {
+		return "pki"
+	}
+	return strings.Trim(options.MountPath, "/")
+}
+
+func issuePKI(c *Client, options *appv1alpha1.VaultPKI, hosts []string) (*Credential, error) {
+	mountPath := pkiMountPath(options)
+	names := []string{}
+	for _, host := range hosts {
+		if host != "*" {
+			names = append(names, host)
+		}
+	}
+	commonName := options.CommonName
+	if commonName == "" {
+		if len(names) == 0 {
+			return nil, fmt.Errorf("vault pki requires a commonName when no hosts can be used as the common name")
+		}
+		commonName = names[0]
+	}
+	request := map[string]interface{}{"common_name": commonName}
+	if len(names) > 0 {
+		request["alt_names"] = strings.Join(names, ",")
+	}
+	if options.TTL != "" {
+		request["ttl"] = options.TTL
+	}
+	data, lease, err := c.IssuePKI(mountPath, options.Role, request)
+	if err != nil {
+		return nil, err
+	}
+	cert, _ := data["certificate"].(string)
+	key, _ := data["private_key"].(string)
+	if cert == "" || key == "" {
+		return nil, fmt.Errorf("vault pki role %s did not issue a certificate and private key", options.Role)
+	}
+	ca, _ := data["issuing_ca"].(string)
+	credential := &Credential{Cert: []byte(cert), Key: []byte(key), CA: []byte(ca), RefreshAfter: DefaultRefreshInterval}
+	if lease > 0 {
+		credential.RefreshAfter = refreshAfter(lease)
+	} else if expiration, ok := data["expiration"].(float64); ok {
+		if remaining := time.Until(time.Unix(int64(expiration), 0)); remaining > 0 {
+			credential.RefreshAfter = refreshAfter(remaining)
+		}
+	}
+	return credential, nil
+}
+
+// refreshAfter renews credentials once two thirds of the lease has elapsed so the secret never holds an expired
+// credential.
+func refreshAfter(lease time.Duration) time.Duration {
+	return lease * 2 / 3
+}
+
+// Hash returns a hash of the server, options and hosts the credential is sourced with, used to detect changes which
+// require the credential to be sourced again before it is due to be refreshed.
+func Hash(config Config, options *appv1alpha1.Vault, hosts []string) string {
+	b, _ := json.Marshal(struct {
+		Address string             `json:"address"`
+		Role    string             `json:"role"`
+		Options *appv1alpha1.Vault `json:"options"`
+		Hosts   []string           `json:"hosts"`
+	}{config.Address, config.Role, options, hosts})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])[:16]
+}
@@ -1,6 +1,7 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -8,11 +9,12 @@ import (
 // +k8s:openapi-gen=true
 type GatewayServiceSpec struct {
 
-	// REQUIRED if mode is `MUTUAL`.
+	// REQUIRED if mode is `MUTUAL` or `OPTIONAL_MUTUAL`.
 	// +optional
 	CaCertificates *string `json:"caCertificates,omitempty"`
 
-	// List of Servers > map of list of hosts and port
+	// List of Servers > map of list of hosts and port. Hosts may be templates such as "{{.Namespace}}.{{.Domain}}",
+	// expanded from the operator config and the labels of the namespace.
 	// +kubebuilder:validation:UniqueItems=false
 	// +kubebuilder:validation:MinItems=1
 	Hosts []string `json:"hosts"`
@@ -31,8 +33,8 @@ type GatewayServiceSpec struct {
 	// +optional
 	MaxProtocolVersion *string `json:"maxProtocolVersion,omitempty"`
 
-	// Options: SIMPLE|PASSTHROUGH|MUTUAL|ISTIO_MUTUAL|AUTO_PASSTHROUGH
-	// +kubebuilder:validation:Enum=SIMPLE,PASSTHROUGH,MUTUAL,ISTIO_MUTUAL,AUTO_PASSTHROUGH
+	// Options: SIMPLE|PASSTHROUGH|MUTUAL|OPTIONAL_MUTUAL|ISTIO_MUTUAL|AUTO_PASSTHROUGH
+	// +kubebuilder:validation:Enum=SIMPLE,PASSTHROUGH,MUTUAL,OPTIONAL_MUTUAL,ISTIO_MUTUAL,AUTO_PASSTHROUGH
 	Mode string `json:"mode"`
 
 	// +kubebuilder:validation:Minimum=1
@@ -43,12 +45,13 @@ type GatewayServiceSpec struct {
 	// +kubebuilder:validation:Enum=HTTP,HTTPS,GRPC,HTTP2,MONGO,TCP,TLS
 	Protocol string `json:"protocol"`
 
-	// Options: "ingress" or "egress"
-	// +kubebuilder:validation:Enum=ingress,egress
+	// Name of a traffic class declared by the operator config, "ingress" or "egress" unless trafficClasses are
+	// configured.
 	TrafficType string `json:"trafficType"`
 
-	// Options: TLSSecret|TLSSecretRef|TLSSecretPath
-	// Supports either creating the secret, referencing the secret, or explicitly referencing the mount path in the pod.
+	// Options: TLSSecret|TLSSecretRef|TLSSecretPath|Vault
+	// Supports either creating the secret, referencing the secret, explicitly referencing the mount path in the pod,
+	// or sourcing the secret from HashiCorp Vault.
 	// +optional
 	TLSOptions *TLSOptions `json:"tlsOptions,omitempty"`
 }
@@ -65,6 +68,57 @@ type TLSOptions struct {
 	// Specifies TLS Cert/Key Path if not using SDS
 	// +optional
 	TLSSecretPath *TLSSecretPath `json:"tlsSecretPath,omitempty"`
+
+	// Specifies TLS Cert/Key to be sourced from HashiCorp Vault and created
+	// +optional
+	Vault *Vault `json:"vault,omitempty"`
+}
+
+// Vault sources the TLS Cert/Key from the Vault server of the operator config, using the paths and roles it allows
+// for the namespace of the GatewayService.
+type Vault struct {
+	// Specifies the TLS Cert/Key are read from a KV secrets engine
+	// +optional
+	KV *VaultKV `json:"kv,omitempty"`
+
+	// Specifies the TLS Cert/Key are issued by a PKI secrets engine
+	// +optional
+	PKI *VaultPKI `json:"pki,omitempty"`
+}
+
+type VaultKV struct {
+	// Path of the secret including the mount, EG. secret/data/example for KV version 2
+	Path string `json:"path"`
+
+	// Version of the KV secrets engine, defaults to 2
+	// +kubebuilder:validation:Enum=1,2
+	// +optional
+	Version int `json:"version,omitempty"`
+
+	// Key of the PEM encoded certificate within the secret, defaults to "tls.crt"
+	// +optional
+	CertKey string `json:"certKey,omitempty"`
+
+	// Key of the PEM encoded private key within the secret, defaults to "tls.key"
+	// +optional
+	KeyKey string `json:"keyKey,omitempty"`
+}
+
+type VaultPKI struct {
+	// Mount path of the PKI secrets engine, defaults to "pki"
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
+
+	// Role used to issue the certificate
+	Role string `json:"role"`
+
+	// Common name of the issued certificate, defaults to the first host
+	// +optional
+	CommonName string `json:"commonName,omitempty"`
+
+	// Requested TTL of the issued certificate, EG. 720h
+	// +optional
+	TTL string `json:"ttl,omitempty"`
 }
 
 type TLSSecretRef struct {
@@ -87,6 +141,11 @@ type TLSSecretPath struct {
 
 	// Specifies the TLS Key Path in the running Pod
 	KeyPath string `json:"keyPath,omitempty"`
+
+	// Specifies the TLS Secret mounted into the gateway pods at the directory of the Cert/Key Path.
+	// If omitted the secret must be mounted into the gateway pods manually.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
 }
 
 // GatewayServiceStatus defines the observed state of GatewayService
@@ -96,6 +155,62 @@ type GatewayServiceStatus struct {
 	// Important: Run "operator-sdk generate k8s" to regenerate code after modifying this file
 	// Add custom validation using kubebuilder tags: https://book-v1.book.kubebuilder.io/beyond_basics/generating_crd.html
 	Condition Condition `json:"condition,omitempty"`
+
+	// Conditions describe the latest observations of the GatewayService state.
+	// +optional
+	Conditions []GatewayServiceCondition `json:"conditions,omitempty"`
+
+	// ValidGeneration is the latest generation of the spec which passed validation.
+	// +optional
+	ValidGeneration int64 `json:"validGeneration,omitempty"`
+
+	// ValidSpec is the last-known-good spec, which is rendered into the Gateway while the latest generation fails
+	// validation. The cert and key of a TLSSecret are left out.
+	// +optional
+	ValidSpec *GatewayServiceSpec `json:"validSpec,omitempty"`
+
+	// Hosts of the last-known-good spec with their templates expanded, as rendered into the Gateway.
+	// +optional
+	Hosts []string `json:"hosts,omitempty"`
+
+	// Gateway is the name of the Gateway within the namespace which renders the last-known-good spec.
+	// +optional
+	Gateway string `json:"gateway,omitempty"`
+}
+
+// GatewayServiceConditionType is a valid value for GatewayServiceCondition.Type
+type GatewayServiceConditionType string
+
+const (
+	// CertificateExpiring is true when the certificate served for the hosts is within an expiry threshold or has expired.
+	CertificateExpiring GatewayServiceConditionType = "CertificateExpiring"
+	// GatewayRolledOut is true once the gateway pods have been rolled to pick up the certificates mounted for TLSSecretPath.
+	GatewayRolledOut GatewayServiceConditionType = "GatewayRolledOut"
+	// GatewayAvailable is true when the Gateway serving the trafficType exists.
+	GatewayAvailable GatewayServiceConditionType = "GatewayAvailable"
+	// SpecRendered is true when the latest generation of the spec is rendered into the Gateway. Otherwise the
+	// last-known-good spec is rendered, or the GatewayService is left out of the Gateway.
+	SpecRendered GatewayServiceConditionType = "SpecRendered"
+)
+
+type GatewayServiceCondition struct {
+	// Type of the condition.
+	Type GatewayServiceConditionType `json:"type"`
+
+	// Status of the condition, one of True, False or Unknown.
+	Status corev1.ConditionStatus `json:"status"`
+
+	// Unique, one-word, CamelCase reason for the condition's last transition.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Human-readable message indicating details about the last transition.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Last time the condition transitioned from one status to another.
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
 }
 
 type Condition struct {
@@ -118,6 +233,10 @@ type CreatedSecretDetails struct {
 	// EG. SIMPLE will result in a secret created in istio-system.
 	// However, PASSTHROUGH will result in a secret created in the namespace the CRD is applied.
 	SecretNamespace string `json:"secretNamespace,omitempty"`
+
+	// Every namespace serving the Gateway which holds the secret, starting with SecretNamespace.
+	// +optional
+	SecretNamespaces []string `json:"secretNamespaces,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
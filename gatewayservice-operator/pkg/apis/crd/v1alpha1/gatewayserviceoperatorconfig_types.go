@@ -0,0 +1,224 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GatewayServiceOperatorConfigSpec defines the settings of the operator. Settings which are omitted fall back to the
+// environment of the operator, or to their defaults.
+// +k8s:openapi-gen=true
+type GatewayServiceOperatorConfigSpec struct {
+	// Domain of the hosts served by the default server, defaults to the DOMAIN environment variable.
+	// +optional
+	Domain string `json:"domain,omitempty"`
+
+	// Name of the cluster, available to host templates as .Cluster. Defaults to the CLUSTER environment variable.
+	// +optional
+	Cluster string `json:"cluster,omitempty"`
+
+	// Template of the Gateway names, rendered with .Namespace and .TrafficType.
+	// Defaults to "{{.Namespace}}-{{.TrafficType}}-gateway".
+	// +optional
+	GatewayNameTemplate string `json:"gatewayNameTemplate,omitempty"`
+
+	// Namespace holding the secrets when the namespaces of the gateway pods are neither configured nor discovered,
+	// defaults to istio-system.
+	// +optional
+	SecretNamespace string `json:"secretNamespace,omitempty"`
+
+	// Behaviour of the server rendered into Gateways without any other servers.
+	// +optional
+	DefaultServer *DefaultServer `json:"defaultServer,omitempty"`
+
+	// Modes GatewayServices may use, every mode is allowed when empty.
+	// +optional
+	AllowedModes []string `json:"allowedModes,omitempty"`
+
+	// Protocols GatewayServices may use, every protocol is allowed when empty.
+	// +optional
+	AllowedProtocols []string `json:"allowedProtocols,omitempty"`
+
+	// Namespaces which hosts of the form "namespace/host" may name, other than the namespace of the GatewayService.
+	// "*" allows every namespace.
+	// +optional
+	AllowedHostNamespaces []string `json:"allowedHostNamespaces,omitempty"`
+
+	// Interval at which successfully reconciled GatewayServices are reconciled again, EG. 1h. Defaults to the
+	// RESYNC_PERIOD environment variable.
+	// +optional
+	ResyncPeriod string `json:"resyncPeriod,omitempty"`
+
+	// Traffic classes GatewayServices may target by trafficType, replacing "ingress" and "egress" when set.
+	// +optional
+	TrafficClasses []TrafficClass `json:"trafficClasses,omitempty"`
+
+	// Create the missing Gateways targeted by GatewayServices, selecting the gateway pods of their traffic class.
+	// Defaults to the GATEWAY_CREATE environment variable.
+	// +optional
+	CreateGateways *bool `json:"createGateways,omitempty"`
+
+	// Rolling of the gateway pods to pick up the certificates mounted for TLSSecretPath.
+	// +optional
+	Rollout *RolloutConfig `json:"rollout,omitempty"`
+
+	// Durations prior to certificate expiry at which warnings are raised, EG. ["720h", "168h", "24h"]. Defaults to
+	// the CERTIFICATE_EXPIRY_THRESHOLDS environment variable.
+	// +optional
+	CertificateExpiryThresholds []string `json:"certificateExpiryThresholds,omitempty"`
+
+	// Vault server certificates are sourced from by GatewayServices using the Vault TLSOption, which is rejected
+	// unless set.
+	// +optional
+	Vault *VaultConfig `json:"vault,omitempty"`
+}
+
+type TrafficClass struct {
+	// Name of the traffic class, the trafficType of the GatewayServices targeting it.
+	Name string `json:"name"`
+
+	// Template of the names of the Gateways of the traffic class, defaults to gatewayNameTemplate.
+	// +optional
+	GatewayNameTemplate string `json:"gatewayNameTemplate,omitempty"`
+
+	// Selector of the gateway pods of the Gateways created for the traffic class, defaults to the GATEWAY_SELECTORS
+	// environment variable.
+	// +optional
+	Selector map[string]string `json:"selector,omitempty"`
+
+	// Namespace holding the secrets of the traffic class when the namespaces of the gateway pods are neither
+	// configured nor discovered, defaults to secretNamespace.
+	// +optional
+	SecretNamespace string `json:"secretNamespace,omitempty"`
+
+	// Namespaces of the gateway pods of the traffic class, discovered from the pods selected by the Gateway when
+	// omitted. Defaults to the GATEWAY_NAMESPACES environment variable.
+	// +optional
+	GatewayNamespaces []string `json:"gatewayNamespaces,omitempty"`
+}
+
+type RolloutConfig struct {
+	// Number or percentage of gateway pods which may be unavailable while rolling, EG. 25%. Defaults to the
+	// ROLLOUT_MAX_UNAVAILABLE environment variable.
+	// +optional
+	MaxUnavailable string `json:"maxUnavailable,omitempty"`
+
+	// Time a new gateway pod must be ready before the next batch of gateway pods is rolled, EG. 30s. Defaults to the
+	// ROLLOUT_GRACE_PERIOD environment variable.
+	// +optional
+	GracePeriod string `json:"gracePeriod,omitempty"`
+}
+
+type DefaultServer struct {
+	// No default server is rendered. Gateways created by the operator are removed once they no longer serve any
+	// GatewayService, other Gateways are left without servers.
+	// +optional
+	Disabled bool `json:"disabled,omitempty"`
+
+	// Port of the default server, defaults to 80.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port uint32 `json:"port,omitempty"`
+
+	// Options: HTTP|HTTPS|GRPC|HTTP2|MONGO|TCP|TLS, defaults to HTTP.
+	// +kubebuilder:validation:Enum=HTTP,HTTPS,GRPC,HTTP2,MONGO,TCP,TLS
+	// +optional
+	Protocol string `json:"protocol,omitempty"`
+
+	// Template of the host of the default server, rendered with .Namespace, .TrafficType, .Domain and .Cluster.
+	// Defaults to "{{.Namespace}}.{{.Domain}}".
+	// +optional
+	Host string `json:"host,omitempty"`
+
+	// Secret holding the certificate served by the default server using SDS.
+	// REQUIRED if protocol is `HTTPS` or `TLS`.
+	// +optional
+	CredentialName string `json:"credentialName,omitempty"`
+}
+
+type VaultConfig struct {
+	// Address of the Vault server, EG. https://vault.example.com:8200
+	Address string `json:"address"`
+
+	// PEM encoded CA certificates verifying the Vault server, defaults to the system roots.
+	// +optional
+	CACert string `json:"caCert,omitempty"`
+
+	// Server name verified against the certificate of the Vault server, defaults to the host of the address.
+	// +optional
+	TLSServerName string `json:"tlsServerName,omitempty"`
+
+	// Specifies the Kubernetes auth method used to login to Vault
+	Auth VaultKubernetesAuth `json:"auth"`
+
+	// Paths and roles GatewayServices within each namespace may source certificates from. GatewayServices within
+	// other namespaces cannot use Vault.
+	// +optional
+	Namespaces []VaultNamespace `json:"namespaces,omitempty"`
+}
+
+type VaultKubernetesAuth struct {
+	// Vault role bound to the operator service account
+	Role string `json:"role"`
+
+	// Mount path of the Kubernetes auth method, defaults to "kubernetes"
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
+}
+
+type VaultNamespace struct {
+	// Name of the namespace.
+	Name string `json:"name"`
+
+	// Prefixes of the KV paths the namespace may read, EG. secret/data/application
+	// +optional
+	KVPathPrefixes []string `json:"kvPathPrefixes,omitempty"`
+
+	// PKI roles the namespace may issue certificates from, of the form mountPath/role, EG. pki/application
+	// +optional
+	PKIRoles []string `json:"pkiRoles,omitempty"`
+}
+
+// GatewayServiceOperatorConfigStatus defines the observed state of GatewayServiceOperatorConfig
+// +k8s:openapi-gen=true
+type GatewayServiceOperatorConfigStatus struct {
+	// ObservedGeneration is the latest generation of the spec validated by the operator.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions describe whether the configuration is valid and applied.
+	// +optional
+	Conditions []GatewayServiceCondition `json:"conditions,omitempty"`
+}
+
+// ConfigValid is true when the GatewayServiceOperatorConfig is valid and applied by the operator. Otherwise the last
+// valid configuration remains applied.
+const ConfigValid GatewayServiceConditionType = "Valid"
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// GatewayServiceOperatorConfig is the Schema for the gatewayserviceoperatorconfigs API
+// +k8s:openapi-gen=true
+// +genclient:nonNamespaced
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+type GatewayServiceOperatorConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GatewayServiceOperatorConfigSpec   `json:"spec,omitempty"`
+	Status GatewayServiceOperatorConfigStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// GatewayServiceOperatorConfigList contains a list of GatewayServiceOperatorConfig
+type GatewayServiceOperatorConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GatewayServiceOperatorConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GatewayServiceOperatorConfig{}, &GatewayServiceOperatorConfigList{})
+}
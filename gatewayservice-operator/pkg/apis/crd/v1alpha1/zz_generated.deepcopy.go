@@ -11,7 +11,7 @@ import (
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Condition) DeepCopyInto(out *Condition) {
 	*out = *in
-	out.CreatedSecretDetails = in.CreatedSecretDetails
+	in.CreatedSecretDetails.DeepCopyInto(&out.CreatedSecretDetails)
 	return
 }
 
@@ -28,6 +28,11 @@ func (in *Condition) DeepCopy() *Condition {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CreatedSecretDetails) DeepCopyInto(out *CreatedSecretDetails) {
 	*out = *in
+	if in.SecretNamespaces != nil {
+		in, out := &in.SecretNamespaces, &out.SecretNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -41,13 +46,29 @@ func (in *CreatedSecretDetails) DeepCopy() *CreatedSecretDetails {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DefaultServer) DeepCopyInto(out *DefaultServer) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DefaultServer.
+func (in *DefaultServer) DeepCopy() *DefaultServer {
+	if in == nil {
+		return nil
+	}
+	out := new(DefaultServer)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GatewayService) DeepCopyInto(out *GatewayService) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -69,6 +90,23 @@ func (in *GatewayService) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayServiceCondition) DeepCopyInto(out *GatewayServiceCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayServiceCondition.
+func (in *GatewayServiceCondition) DeepCopy() *GatewayServiceCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayServiceCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GatewayServiceList) DeepCopyInto(out *GatewayServiceList) {
 	*out = *in
@@ -102,6 +140,153 @@ func (in *GatewayServiceList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayServiceOperatorConfig) DeepCopyInto(out *GatewayServiceOperatorConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayServiceOperatorConfig.
+func (in *GatewayServiceOperatorConfig) DeepCopy() *GatewayServiceOperatorConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayServiceOperatorConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GatewayServiceOperatorConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayServiceOperatorConfigList) DeepCopyInto(out *GatewayServiceOperatorConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GatewayServiceOperatorConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayServiceOperatorConfigList.
+func (in *GatewayServiceOperatorConfigList) DeepCopy() *GatewayServiceOperatorConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayServiceOperatorConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GatewayServiceOperatorConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayServiceOperatorConfigSpec) DeepCopyInto(out *GatewayServiceOperatorConfigSpec) {
+	*out = *in
+	if in.DefaultServer != nil {
+		in, out := &in.DefaultServer, &out.DefaultServer
+		*out = new(DefaultServer)
+		**out = **in
+	}
+	if in.AllowedModes != nil {
+		in, out := &in.AllowedModes, &out.AllowedModes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedProtocols != nil {
+		in, out := &in.AllowedProtocols, &out.AllowedProtocols
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedHostNamespaces != nil {
+		in, out := &in.AllowedHostNamespaces, &out.AllowedHostNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.TrafficClasses != nil {
+		in, out := &in.TrafficClasses, &out.TrafficClasses
+		*out = make([]TrafficClass, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CreateGateways != nil {
+		in, out := &in.CreateGateways, &out.CreateGateways
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Rollout != nil {
+		in, out := &in.Rollout, &out.Rollout
+		*out = new(RolloutConfig)
+		**out = **in
+	}
+	if in.CertificateExpiryThresholds != nil {
+		in, out := &in.CertificateExpiryThresholds, &out.CertificateExpiryThresholds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Vault != nil {
+		in, out := &in.Vault, &out.Vault
+		*out = new(VaultConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayServiceOperatorConfigSpec.
+func (in *GatewayServiceOperatorConfigSpec) DeepCopy() *GatewayServiceOperatorConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayServiceOperatorConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayServiceOperatorConfigStatus) DeepCopyInto(out *GatewayServiceOperatorConfigStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]GatewayServiceCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayServiceOperatorConfigStatus.
+func (in *GatewayServiceOperatorConfigStatus) DeepCopy() *GatewayServiceOperatorConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayServiceOperatorConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GatewayServiceSpec) DeepCopyInto(out *GatewayServiceSpec) {
 	*out = *in
@@ -146,7 +331,24 @@ func (in *GatewayServiceSpec) DeepCopy() *GatewayServiceSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GatewayServiceStatus) DeepCopyInto(out *GatewayServiceStatus) {
 	*out = *in
-	out.Condition = in.Condition
+	in.Condition.DeepCopyInto(&out.Condition)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]GatewayServiceCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ValidSpec != nil {
+		in, out := &in.ValidSpec, &out.ValidSpec
+		*out = new(GatewayServiceSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -160,6 +362,22 @@ func (in *GatewayServiceStatus) DeepCopy() *GatewayServiceStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutConfig) DeepCopyInto(out *RolloutConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutConfig.
+func (in *RolloutConfig) DeepCopy() *RolloutConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TLSOptions) DeepCopyInto(out *TLSOptions) {
 	*out = *in
@@ -178,6 +396,11 @@ func (in *TLSOptions) DeepCopyInto(out *TLSOptions) {
 		*out = new(TLSSecretPath)
 		**out = **in
 	}
+	if in.Vault != nil {
+		in, out := &in.Vault, &out.Vault
+		*out = new(Vault)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -248,3 +471,155 @@ func (in *TLSSecretRef) DeepCopy() *TLSSecretRef {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrafficClass) DeepCopyInto(out *TrafficClass) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.GatewayNamespaces != nil {
+		in, out := &in.GatewayNamespaces, &out.GatewayNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrafficClass.
+func (in *TrafficClass) DeepCopy() *TrafficClass {
+	if in == nil {
+		return nil
+	}
+	out := new(TrafficClass)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Vault) DeepCopyInto(out *Vault) {
+	*out = *in
+	if in.KV != nil {
+		in, out := &in.KV, &out.KV
+		*out = new(VaultKV)
+		**out = **in
+	}
+	if in.PKI != nil {
+		in, out := &in.PKI, &out.PKI
+		*out = new(VaultPKI)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Vault.
+func (in *Vault) DeepCopy() *Vault {
+	if in == nil {
+		return nil
+	}
+	out := new(Vault)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultConfig) DeepCopyInto(out *VaultConfig) {
+	*out = *in
+	out.Auth = in.Auth
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]VaultNamespace, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultConfig.
+func (in *VaultConfig) DeepCopy() *VaultConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultKV) DeepCopyInto(out *VaultKV) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultKV.
+func (in *VaultKV) DeepCopy() *VaultKV {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultKV)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultKubernetesAuth) DeepCopyInto(out *VaultKubernetesAuth) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultKubernetesAuth.
+func (in *VaultKubernetesAuth) DeepCopy() *VaultKubernetesAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultKubernetesAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultNamespace) DeepCopyInto(out *VaultNamespace) {
+	*out = *in
+	if in.KVPathPrefixes != nil {
+		in, out := &in.KVPathPrefixes, &out.KVPathPrefixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PKIRoles != nil {
+		in, out := &in.PKIRoles, &out.PKIRoles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultNamespace.
+func (in *VaultNamespace) DeepCopy() *VaultNamespace {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultNamespace)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultPKI) DeepCopyInto(out *VaultPKI) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultPKI.
+func (in *VaultPKI) DeepCopy() *VaultPKI {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultPKI)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -11,9 +11,12 @@ import (
 
 func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenAPIDefinition {
 	return map[string]common.OpenAPIDefinition{
-		"./pkg/apis/crd/v1alpha1.GatewayService":       schema_pkg_apis_crd_v1alpha1_GatewayService(ref),
-		"./pkg/apis/crd/v1alpha1.GatewayServiceSpec":   schema_pkg_apis_crd_v1alpha1_GatewayServiceSpec(ref),
-		"./pkg/apis/crd/v1alpha1.GatewayServiceStatus": schema_pkg_apis_crd_v1alpha1_GatewayServiceStatus(ref),
+		"./pkg/apis/crd/v1alpha1.GatewayService":                     schema_pkg_apis_crd_v1alpha1_GatewayService(ref),
+		"./pkg/apis/crd/v1alpha1.GatewayServiceOperatorConfig":       schema_pkg_apis_crd_v1alpha1_GatewayServiceOperatorConfig(ref),
+		"./pkg/apis/crd/v1alpha1.GatewayServiceOperatorConfigSpec":   schema_pkg_apis_crd_v1alpha1_GatewayServiceOperatorConfigSpec(ref),
+		"./pkg/apis/crd/v1alpha1.GatewayServiceOperatorConfigStatus": schema_pkg_apis_crd_v1alpha1_GatewayServiceOperatorConfigStatus(ref),
+		"./pkg/apis/crd/v1alpha1.GatewayServiceSpec":                 schema_pkg_apis_crd_v1alpha1_GatewayServiceSpec(ref),
+		"./pkg/apis/crd/v1alpha1.GatewayServiceStatus":               schema_pkg_apis_crd_v1alpha1_GatewayServiceStatus(ref),
 	}
 }
 
@@ -60,6 +63,226 @@ func schema_pkg_apis_crd_v1alpha1_GatewayService(ref common.ReferenceCallback) c
 	}
 }
 
+func schema_pkg_apis_crd_v1alpha1_GatewayServiceOperatorConfig(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "GatewayServiceOperatorConfig is the Schema for the gatewayserviceoperatorconfigs API",
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"metadata": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"),
+						},
+					},
+					"spec": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("./pkg/apis/crd/v1alpha1.GatewayServiceOperatorConfigSpec"),
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("./pkg/apis/crd/v1alpha1.GatewayServiceOperatorConfigStatus"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"./pkg/apis/crd/v1alpha1.GatewayServiceOperatorConfigSpec", "./pkg/apis/crd/v1alpha1.GatewayServiceOperatorConfigStatus", "k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"},
+	}
+}
+
+func schema_pkg_apis_crd_v1alpha1_GatewayServiceOperatorConfigSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "GatewayServiceOperatorConfigSpec defines the settings of the operator. Settings which are omitted fall back to the environment of the operator, or to their defaults.",
+				Properties: map[string]spec.Schema{
+					"domain": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Domain of the hosts served by the default server, defaults to the DOMAIN environment variable.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"cluster": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the cluster, available to host templates as .Cluster. Defaults to the CLUSTER environment variable.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"gatewayNameTemplate": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Template of the Gateway names, rendered with .Namespace and .TrafficType. Defaults to \"{{.Namespace}}-{{.TrafficType}}-gateway\".",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"secretNamespace": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Namespace holding the secrets when the namespaces of the gateway pods are neither configured nor discovered, defaults to istio-system.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"defaultServer": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Behaviour of the server rendered into Gateways without any other servers.",
+							Ref:         ref("./pkg/apis/crd/v1alpha1.DefaultServer"),
+						},
+					},
+					"allowedModes": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Modes GatewayServices may use, every mode is allowed when empty.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"allowedProtocols": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Protocols GatewayServices may use, every protocol is allowed when empty.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"allowedHostNamespaces": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Namespaces which hosts of the form \"namespace/host\" may name, other than the namespace of the GatewayService. \"*\" allows every namespace.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"resyncPeriod": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Interval at which successfully reconciled GatewayServices are reconciled again, EG. 1h. Defaults to the RESYNC_PERIOD environment variable.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"trafficClasses": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Traffic classes GatewayServices may target by trafficType, replacing \"ingress\" and \"egress\" when set.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./pkg/apis/crd/v1alpha1.TrafficClass"),
+									},
+								},
+							},
+						},
+					},
+					"createGateways": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Create the missing Gateways targeted by GatewayServices, selecting the gateway pods of their traffic class. Defaults to the GATEWAY_CREATE environment variable.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"rollout": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Rolling of the gateway pods to pick up the certificates mounted for TLSSecretPath.",
+							Ref:         ref("./pkg/apis/crd/v1alpha1.RolloutConfig"),
+						},
+					},
+					"certificateExpiryThresholds": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Durations prior to certificate expiry at which warnings are raised, EG. [\"720h\", \"168h\", \"24h\"]. Defaults to the CERTIFICATE_EXPIRY_THRESHOLDS environment variable.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"vault": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Vault server certificates are sourced from by GatewayServices using the Vault TLSOption, which is rejected unless set.",
+							Ref:         ref("./pkg/apis/crd/v1alpha1.VaultConfig"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"./pkg/apis/crd/v1alpha1.DefaultServer", "./pkg/apis/crd/v1alpha1.RolloutConfig", "./pkg/apis/crd/v1alpha1.TrafficClass", "./pkg/apis/crd/v1alpha1.VaultConfig"},
+	}
+}
+
+func schema_pkg_apis_crd_v1alpha1_GatewayServiceOperatorConfigStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "GatewayServiceOperatorConfigStatus defines the observed state of GatewayServiceOperatorConfig",
+				Properties: map[string]spec.Schema{
+					"observedGeneration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ObservedGeneration is the latest generation of the spec validated by the operator.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"conditions": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Conditions describe whether the configuration is valid and applied.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./pkg/apis/crd/v1alpha1.GatewayServiceCondition"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"./pkg/apis/crd/v1alpha1.GatewayServiceCondition"},
+	}
+}
+
 func schema_pkg_apis_crd_v1alpha1_GatewayServiceSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -68,14 +291,14 @@ func schema_pkg_apis_crd_v1alpha1_GatewayServiceSpec(ref common.ReferenceCallbac
 				Properties: map[string]spec.Schema{
 					"caCertificates": {
 						SchemaProps: spec.SchemaProps{
-							Description: "REQUIRED if mode is `MUTUAL`.",
+							Description: "REQUIRED if mode is `MUTUAL` or `OPTIONAL_MUTUAL`.",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
 					"hosts": {
 						SchemaProps: spec.SchemaProps{
-							Description: "List of Servers > map of list of hosts and port",
+							Description: "List of Servers > map of list of hosts and port. Hosts may be templates such as \"{{.Namespace}}.{{.Domain}}\", expanded from the operator config and the labels of the namespace.",
 							Type:        []string{"array"},
 							Items: &spec.SchemaOrArray{
 								Schema: &spec.Schema{
@@ -110,7 +333,7 @@ func schema_pkg_apis_crd_v1alpha1_GatewayServiceSpec(ref common.ReferenceCallbac
 					},
 					"mode": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Options: SIMPLE|PASSTHROUGH|MUTUAL|ISTIO_MUTUAL|AUTO_PASSTHROUGH",
+							Description: "Options: SIMPLE|PASSTHROUGH|MUTUAL|OPTIONAL_MUTUAL|ISTIO_MUTUAL|AUTO_PASSTHROUGH",
 							Type:        []string{"string"},
 							Format:      "",
 						},
@@ -130,14 +353,14 @@ func schema_pkg_apis_crd_v1alpha1_GatewayServiceSpec(ref common.ReferenceCallbac
 					},
 					"trafficType": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Options: \"ingress\" or \"egress\"",
+							Description: "Name of a traffic class declared by the operator config, \"ingress\" or \"egress\" unless trafficClasses are configured.",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
 					"tlsOptions": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Options: TLSSecret|TLSSecretRef|TLSSecretPath Supports either creating the secret, referencing the secret, or explicitly referencing the mount path in the pod.",
+							Description: "Options: TLSSecret|TLSSecretRef|TLSSecretPath|Vault Supports either creating the secret, referencing the secret, explicitly referencing the mount path in the pod, or sourcing the secret from HashiCorp Vault.",
 							Ref:         ref("./pkg/apis/crd/v1alpha1.TLSOptions"),
 						},
 					},
@@ -162,10 +385,57 @@ func schema_pkg_apis_crd_v1alpha1_GatewayServiceStatus(ref common.ReferenceCallb
 							Ref:         ref("./pkg/apis/crd/v1alpha1.Condition"),
 						},
 					},
+					"conditions": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Conditions describe the latest observations of the GatewayService state.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("./pkg/apis/crd/v1alpha1.GatewayServiceCondition"),
+									},
+								},
+							},
+						},
+					},
+					"validGeneration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ValidGeneration is the latest generation of the spec which passed validation.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"validSpec": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ValidSpec is the last-known-good spec, which is rendered into the Gateway while the latest generation fails validation. The cert and key of a TLSSecret are left out.",
+							Ref:         ref("./pkg/apis/crd/v1alpha1.GatewayServiceSpec"),
+						},
+					},
+					"hosts": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Hosts of the last-known-good spec with their templates expanded, as rendered into the Gateway.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"gateway": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Gateway is the name of the Gateway within the namespace which renders the last-known-good spec.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"./pkg/apis/crd/v1alpha1.Condition"},
+			"./pkg/apis/crd/v1alpha1.Condition", "./pkg/apis/crd/v1alpha1.GatewayServiceCondition", "./pkg/apis/crd/v1alpha1.GatewayServiceSpec"},
 	}
 }
@@ -0,0 +1,10 @@
+package controller
+
+import (
+	"github.com/xunholy/k8s-istio-gateway-service-operator/pkg/controller/gateway"
+)
+
+func init() {
+	// AddToManagerFuncs is a list of functions to create controllers and add them to a manager.
+	AddToManagerFuncs = append(AddToManagerFuncs, gateway.Add)
+}
@@ -0,0 +1,351 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/config"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/drift"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/gateway"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/index"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/status"
+
+	networkv3 "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+
+	appv1alpha1 "github.com/xunholy/k8s-istio-gateway-service-operator/pkg/apis/crd/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var (
+	// blank assignment to verify that ReconcileGateway implements reconcile.Reconciler
+	_   reconcile.Reconciler = &ReconcileGateway{}
+	log                      = logf.Log.WithName("controller_gateway")
+	// gatewayResource is used to read Gateways with the dynamic client.
+	gatewayResource = schema.GroupVersionResource{Group: "networking.istio.io", Version: "v1alpha3", Resource: "gateways"}
+	// fieldManager is the manager recorded by the API server for changes made by the operator, which defaults to
+	// the name of the binary.
+	fieldManager = filepath.Base(os.Args[0])
+)
+
+// ReconcileGateway renders the servers of a Gateway from every GatewayService targeting it. Requests are keyed by
+// Gateway, so changes to many GatewayServices at once are coalesced into a single write of the Gateway.
+type ReconcileGateway struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	client   client.Client
+	recorder record.EventRecorder
+
+	// dynamicClient reads the managedFields of the Gateway, which are not part of the typed object.
+	dynamicClient dynamic.Interface
+}
+
+type RenderConfig struct {
+	Client        client.Client
+	Recorder      record.EventRecorder
+	DynamicClient dynamic.Interface
+	Config        config.Config
+}
+
+// Add creates a new Gateway Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileGateway{
+		client:        mgr.GetClient(),
+		recorder:      mgr.GetRecorder("gateway-controller"),
+		dynamicClient: dynamic.NewForConfigOrDie(mgr.GetConfig()),
+	}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller. A single worker serializes the writes to each Gateway.
+	c, err := controller.New("gateway-controller", mgr, controller.Options{Reconciler: r, MaxConcurrentReconciles: 1})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to the GatewayServices and enqueue the Gateway they target. The work queue holds each key
+	// once, so events for many GatewayServices result in a single render of the Gateway.
+	err = c.Watch(&source.Kind{Type: &appv1alpha1.GatewayService{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(gatewayServiceRequests(mgr.GetClient())),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to the operator config and render every Gateway targeted by a GatewayService again.
+	err = c.Watch(&source.Kind{Type: &appv1alpha1.GatewayServiceOperatorConfig{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(configRequests(mgr.GetClient())),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to the namespaces and render the Gateways within them again, as host templates may refer to
+	// namespace labels.
+	err = c.Watch(&source.Kind{Type: &corev1.Namespace{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(namespaceRequests(mgr.GetClient())),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to the Gateways so drift is reverted.
+	err = c.Watch(&source.Kind{Type: &v1alpha3.Gateway{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// +kubebuilder:rbac:groups=networking.istio.io,resources=gateways,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups=crd.xunholy.github.com,resources=gatewayservices,verbs=get;list;watch
+// +kubebuilder:rbac:groups=crd.xunholy.github.com,resources=gatewayserviceoperatorconfigs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+
+// Reconcile renders the servers of the Gateway from the GatewayServices targeting it.
+func (r *ReconcileGateway) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	logger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	logger.Info("Reconciling Gateway")
+	err := Render(RenderConfig{
+		Client:        r.client,
+		Recorder:      r.recorder,
+		DynamicClient: r.dynamicClient,
+		Config:        config.Load(r.client),
+	}, request.NamespacedName)
+	if err != nil {
+		logger.Error(err, "Failed to render gateway")
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{}, nil
+}
+
+// Key returns the Gateway targeted by GatewayServices of the trafficType within the namespace.
+func Key(c config.Config, namespace string, trafficType string) types.NamespacedName {
+	return types.NamespacedName{Name: c.GatewayName(namespace, trafficType), Namespace: namespace}
+}
+
+// Render renders the servers of the Gateway from every GatewayService targeting it, retrying when the Gateway was
+// changed since it was read. The Gateway is only written when the servers change.
+func Render(c RenderConfig, key types.NamespacedName) error {
+	trafficType, ok := c.Config.TrafficType(key.Namespace, key.Name)
+	if !ok {
+		// The Gateway is not managed by the operator.
+		return nil
+	}
+	return RenderTrafficType(c, key, trafficType)
+}
+
+// RenderTrafficType renders the servers of the Gateway serving the trafficType, which need not be declared by the
+// operator config, EG. when a GatewayService targeting a class which was since removed is deleted. The GatewayServices
+// rendered are those recorded as rendered by the Gateway, using the gateway index.
+func RenderTrafficType(c RenderConfig, key types.NamespacedName, trafficType string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		gatewayObj := &v1alpha3.Gateway{}
+		err := c.Client.Get(context.TODO(), key, gatewayObj)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		// Ingress and/or Egress Gateway object does not exist.
+		missing := err != nil
+		if missing && !c.Config.CreateGateways {
+			return nil
+		}
+
+		// List all GatewayService CRDs targeting the Gateway
+		gatewayservices, err := index.Rendered(c.Client, c.Config, key.Namespace, key.Name)
+		if err != nil {
+			return err
+		}
+		gatewayservices = renderable(gatewayservices)
+
+		// Without a default server a Gateway created by the operator is removed once it no longer serves anything, as
+		// Istio rejects Gateways without servers.
+		if !missing && c.Config.DefaultServer.Disabled && gatewayObj.Annotations[gateway.CreatedAnnotation] == "true" &&
+			!serving(gatewayservices) && len(gateway.UnmanagedServers(gatewayObj)) == 0 {
+			log.Info("Deleting gateway which no longer serves any GatewayService", "Gateway.Namespace", key.Namespace, "Gateway.Name", key.Name)
+			err = c.Client.Delete(context.TODO(), gatewayObj)
+			if errors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+
+		if missing {
+			if !serving(gatewayservices) {
+				return nil
+			}
+			selector := c.Config.Selectors()[trafficType]
+			if len(selector) == 0 {
+				return fmt.Errorf("no selector is configured for trafficType %s to create gateway %s", trafficType, key)
+			}
+			gatewayObj = gateway.New(key.Name, key.Namespace, selector)
+		}
+
+		values, err := c.Config.LoadHostValues(c.Client, key.Namespace, trafficType)
+		if err != nil {
+			return err
+		}
+		g := gateway.GatewayConfig{
+			Name:            key.Name,
+			TrafficType:     trafficType,
+			GatewayService:  gatewayservices,
+			Gateway:         gatewayObj,
+			Domain:          values.Domain,
+			Cluster:         values.Cluster,
+			NamespaceLabels: values.Labels,
+			DefaultServer:   c.Config.DefaultServer,
+		}
+		current := gatewayObj.Spec.Servers
+		lastApplied, applied := gatewayObj.Annotations[drift.HashAnnotation]
+		reconciledGatewayObj := gateway.Reconcile(g)
+		desiredHash := drift.Hash(reconciledGatewayObj.Spec.Servers)
+		if drift.Hash(current) == desiredHash && lastApplied == desiredHash {
+			return nil
+		}
+		// The servers no longer match those last applied by the operator, someone else has changed the Gateway.
+		if applied && drift.Hash(current) != lastApplied {
+			recordDrift(c, reconciledGatewayObj, current)
+		}
+		if reconciledGatewayObj.Annotations == nil {
+			reconciledGatewayObj.Annotations = map[string]string{}
+		}
+		reconciledGatewayObj.Annotations[drift.HashAnnotation] = desiredHash
+		if missing {
+			log.Info("Creating gateway", "Gateway.Namespace", key.Namespace, "Gateway.Name", key.Name)
+			return c.Client.Create(context.TODO(), reconciledGatewayObj)
+		}
+		return c.Client.Update(context.TODO(), reconciledGatewayObj)
+	})
+}
+
+// renderable returns the GatewayServices with the spec to render, which is the last-known-good spec when the latest
+// generation failed validation. GatewayServices which never passed validation are left out.
+func renderable(gatewayservices *appv1alpha1.GatewayServiceList) *appv1alpha1.GatewayServiceList {
+	result := &appv1alpha1.GatewayServiceList{}
+	for _, gs := range gatewayservices.Items {
+		switch {
+		case gs.Status.ValidSpec != nil && gs.Status.ValidGeneration == gs.Generation:
+		case gs.Status.ValidSpec != nil:
+			gs.Spec = *gs.Status.ValidSpec.DeepCopy()
+		case gs.Status.Condition.Success && status.FindCondition(gs.Status.Conditions, appv1alpha1.SpecRendered) == nil:
+			// Reconciled before the last-known-good spec was recorded, it is kept until validated again.
+		default:
+			log.Info("Excluding invalid gatewayservice", "GatewayService.Namespace", gs.Namespace, "GatewayService.Name", gs.Name)
+			continue
+		}
+		result.Items = append(result.Items, gs)
+	}
+	return result
+}
+
+// serving reports whether any of the GatewayServices require the Gateway.
+func serving(gatewayservices *appv1alpha1.GatewayServiceList) bool {
+	for _, gs := range gatewayservices.Items {
+		if gs.DeletionTimestamp == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// recordDrift raises a Warning event on the Gateway describing the changes reverted and who made them.
+func recordDrift(c RenderConfig, gatewayObj *v1alpha3.Gateway, current []*networkv3.Server) {
+	changes := drift.Diff(current, gatewayObj.Spec.Servers)
+	if len(changes) == 0 {
+		return
+	}
+	manager := drift.Manager{}
+	if c.DynamicClient != nil {
+		obj, err := c.DynamicClient.Resource(gatewayResource).Namespace(gatewayObj.Namespace).Get(gatewayObj.Name, metav1.GetOptions{})
+		if err != nil {
+			log.Error(err, "Failed to read managedFields of gateway", "Gateway.Namespace", gatewayObj.Namespace, "Gateway.Name", gatewayObj.Name)
+		} else {
+			manager = drift.LastManager(obj, fieldManager)
+		}
+	}
+	message := fmt.Sprintf("reverted changes made by %s: %s", manager, strings.Join(changes, ", "))
+	log.Info("Reverted gateway drift", "Gateway.Namespace", gatewayObj.Namespace, "Gateway.Name", gatewayObj.Name, "changes", changes, "manager", manager.Manager)
+	if c.Recorder != nil {
+		c.Recorder.Event(gatewayObj, corev1.EventTypeWarning, "GatewayDriftReverted", message)
+	}
+}
+
+// gatewayServiceRequests maps a GatewayService to the Gateway rendering it, as returned by index.GatewayName. As both
+// the old and new GatewayService are mapped on update, the Gateway it no longer targets is rendered as well.
+func gatewayServiceRequests(c client.Client) func(handler.MapObject) []reconcile.Request {
+	return func(obj handler.MapObject) []reconcile.Request {
+		gs, ok := obj.Object.(*appv1alpha1.GatewayService)
+		if !ok {
+			return nil
+		}
+		name := index.GatewayName(config.Load(c), gs)
+		if name == "" {
+			return nil
+		}
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: name, Namespace: gs.Namespace}}}
+	}
+}
+
+// configRequests maps the operator config to every Gateway targeted by a GatewayService.
+func configRequests(c client.Client) func(handler.MapObject) []reconcile.Request {
+	return func(obj handler.MapObject) []reconcile.Request {
+		return targetedGateways(c, &client.ListOptions{})
+	}
+}
+
+// namespaceRequests maps a namespace to every Gateway within it targeted by a GatewayService.
+func namespaceRequests(c client.Client) func(handler.MapObject) []reconcile.Request {
+	return func(obj handler.MapObject) []reconcile.Request {
+		return targetedGateways(c, &client.ListOptions{Namespace: obj.Meta.GetName()})
+	}
+}
+
+// targetedGateways returns a request for every Gateway targeted by the GatewayServices listed.
+func targetedGateways(c client.Client, opts *client.ListOptions) []reconcile.Request {
+	gatewayservices := &appv1alpha1.GatewayServiceList{}
+	err := c.List(context.TODO(), opts, gatewayservices)
+	if err != nil {
+		log.Error(err, "Failed to list GatewayServices")
+		return nil
+	}
+	cfg := config.Load(c)
+	keys := map[types.NamespacedName]bool{}
+	requests := []reconcile.Request{}
+	for i := range gatewayservices.Items {
+		gs := &gatewayservices.Items[i]
+		key := types.NamespacedName{Name: index.GatewayName(cfg, gs), Namespace: gs.Namespace}
+		if key.Name == "" || keys[key] {
+			continue
+		}
+		keys[key] = true
+		requests = append(requests, reconcile.Request{NamespacedName: key})
+	}
+	return requests
+}
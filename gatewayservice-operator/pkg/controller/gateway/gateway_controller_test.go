@@ -0,0 +1,420 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/config"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/drift"
+	gw "github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/gateway"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/names"
+	appv1alpha1 "github.com/xunholy/k8s-istio-gateway-service-operator/pkg/apis/crd/v1alpha1"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	networkv3 "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var (
+	name      = "example"
+	namespace = "application"
+	cert      = "Q2VydAo="
+	key       = "S2V5Cg=="
+)
+
+func newGatewayService(name string, trafficType string) *appv1alpha1.GatewayService {
+	gs := &appv1alpha1.GatewayService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: appv1alpha1.GatewayServiceSpec{
+			Hosts:       []string{fmt.Sprintf("%s.example.com", name)},
+			Mode:        "SIMPLE",
+			Port:        443,
+			Protocol:    "HTTPS",
+			TrafficType: trafficType,
+			TLSOptions: &appv1alpha1.TLSOptions{
+				TLSSecret: &appv1alpha1.TLSSecret{
+					Cert: &cert,
+					Key:  &key,
+				},
+			},
+		},
+	}
+	// The spec passed validation and is rendered by the Gateway of its trafficType.
+	gs.Status.ValidSpec = gs.Spec.DeepCopy()
+	gs.Status.Gateway = fmt.Sprintf("%s-%s-gateway", namespace, trafficType)
+	return gs
+}
+
+func TestGatewayReconcile(t *testing.T) {
+	gateway := &v1alpha3.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-ingress-gateway", namespace),
+			Namespace: namespace,
+		},
+	}
+	ingress := newGatewayService(name, "ingress")
+	egress := newGatewayService("other", "egress")
+
+	// Objects to track in the fake client.
+	objs := []runtime.Object{gateway, ingress, egress}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, gateway, ingress, &appv1alpha1.GatewayServiceList{})
+
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClient(objs...)
+
+	// Create a ReconcileGateway object with the fake client.
+	r := &ReconcileGateway{client: cl}
+
+	// Both GatewayServices map to the Gateway of their trafficType.
+	requests := gatewayServiceRequests(cl)(handler.MapObject{Meta: ingress, Object: ingress})
+	expectedRequests := []reconcile.Request{{NamespacedName: types.NamespacedName{Name: gateway.Name, Namespace: namespace}}}
+	if !reflect.DeepEqual(requests, expectedRequests) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expectedRequests, requests)
+	}
+	requests = gatewayServiceRequests(cl)(handler.MapObject{Meta: egress, Object: egress})
+	expectedRequests = []reconcile.Request{{NamespacedName: types.NamespacedName{Name: fmt.Sprintf("%s-egress-gateway", namespace), Namespace: namespace}}}
+	if !reflect.DeepEqual(requests, expectedRequests) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expectedRequests, requests)
+	}
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: gateway.Name, Namespace: namespace}}
+	res, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if res.Requeue {
+		t.Error("reconcile requeued request unexpectedly")
+	}
+	found := &v1alpha3.Gateway{}
+	err = r.client.Get(context.TODO(), req.NamespacedName, found)
+	if err != nil {
+		t.Fatalf("get Gateway: (%v)", err)
+	}
+	// Only the GatewayService of the ingress trafficType is served.
+	if len(found.Spec.Servers) != 1 || found.Spec.Servers[0].Port.Name != names.Port("HTTPS", name, namespace) {
+		t.Fatalf("expected a single server for the ingress GatewayService, found (%+v)", found.Spec.Servers)
+	}
+}
+
+func TestGatewayDriftReverted(t *testing.T) {
+	gatewayservice := newGatewayService(name, "ingress")
+	gatewayservice.Spec.Hosts = []string{"*"}
+
+	// A Gateway last reconciled by the operator and then changed by hand.
+	gateway := &v1alpha3.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-ingress-gateway", namespace),
+			Namespace: namespace,
+			Annotations: map[string]string{
+				drift.HashAnnotation:        "0000000000000000",
+				gw.ManagedServersAnnotation: fmt.Sprintf("https-%s-%s", name, namespace),
+			},
+		},
+		Spec: networkv3.Gateway{
+			Servers: []*networkv3.Server{
+				{
+					Port: &networkv3.Port{
+						Name:     fmt.Sprintf("https-%s-%s", name, namespace),
+						Number:   443,
+						Protocol: "HTTPS",
+					},
+					Hosts: []string{"tampered.example.com"},
+				},
+			},
+		},
+	}
+
+	// Objects to track in the fake client.
+	objs := []runtime.Object{gatewayservice, gateway}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, gateway, gatewayservice, &appv1alpha1.GatewayServiceList{})
+
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClient(objs...)
+
+	// Create a ReconcileGateway object with the fake client.
+	recorder := record.NewFakeRecorder(10)
+	r := &ReconcileGateway{client: cl, recorder: recorder}
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: gateway.Name, Namespace: namespace}}
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	found := &v1alpha3.Gateway{}
+	err = r.client.Get(context.TODO(), req.NamespacedName, found)
+	if err != nil {
+		t.Fatalf("get Gateway: (%v)", err)
+	}
+	if len(found.Spec.Servers) != 1 || !reflect.DeepEqual(found.Spec.Servers[0].Hosts, []string{"*"}) {
+		t.Fatalf("expected the desired servers to be restored, found (%+v)", found.Spec.Servers)
+	}
+	if found.Annotations[drift.HashAnnotation] != drift.Hash(found.Spec.Servers) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", drift.Hash(found.Spec.Servers), found.Annotations[drift.HashAnnotation])
+	}
+	select {
+	case event := <-recorder.Events:
+		if !strings.HasPrefix(event, "Warning GatewayDriftReverted") || !strings.Contains(event, fmt.Sprintf("reverted server https-%s-%s", name, namespace)) {
+			t.Fatalf("unexpected event (%s)", event)
+		}
+	default:
+		t.Fatalf("expected a GatewayDriftReverted event")
+	}
+
+	// The Gateway is up to date, nothing is reverted.
+	_, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if len(recorder.Events) != 0 {
+		t.Fatalf("unexpected event (%s)", <-recorder.Events)
+	}
+}
+
+func TestGatewayCreate(t *testing.T) {
+	gatewayservice := newGatewayService(name, "egress")
+	operatorConfig := &appv1alpha1.GatewayServiceOperatorConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: config.Name},
+	}
+
+	// Objects to track in the fake client.
+	objs := []runtime.Object{gatewayservice, operatorConfig}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, &v1alpha3.Gateway{}, gatewayservice, &appv1alpha1.GatewayServiceList{},
+		operatorConfig, &appv1alpha1.GatewayServiceOperatorConfigList{})
+
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClient(objs...)
+
+	// Create a ReconcileGateway object with the fake client.
+	r := &ReconcileGateway{client: cl}
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: fmt.Sprintf("%s-egress-gateway", namespace), Namespace: namespace}}
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	// Gateways are not created by default.
+	err = r.client.Get(context.TODO(), req.NamespacedName, &v1alpha3.Gateway{})
+	if !errors.IsNotFound(err) {
+		t.Fatalf("expected gateway not to be created: (%v)", err)
+	}
+
+	createGateways := true
+	operatorConfig.Spec.CreateGateways = &createGateways
+	err = r.client.Update(context.TODO(), operatorConfig)
+	if err != nil {
+		t.Fatalf("update GatewayServiceOperatorConfig: (%v)", err)
+	}
+	_, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	found := &v1alpha3.Gateway{}
+	err = r.client.Get(context.TODO(), req.NamespacedName, found)
+	if err != nil {
+		t.Fatalf("get Gateway: (%v)", err)
+	}
+	expected := map[string]string{"istio": "egressgateway"}
+	if !reflect.DeepEqual(found.Spec.Selector, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, found.Spec.Selector)
+	}
+	if len(found.Spec.Servers) != 1 {
+		t.Fatalf("expected a single server for the egress GatewayService, found (%+v)", found.Spec.Servers)
+	}
+}
+
+func TestGatewayInvalidExcluded(t *testing.T) {
+	gateway := &v1alpha3.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-ingress-gateway", namespace),
+			Namespace: namespace,
+		},
+	}
+	// The latest generation failed validation, the last-known-good spec is served.
+	lastKnownGood := newGatewayService(name, "ingress")
+	lastKnownGood.Generation = 2
+	lastKnownGood.Status.ValidGeneration = 1
+	lastKnownGood.Spec.Hosts = []string{"broken.example.com"}
+	// No generation passed validation.
+	invalid := newGatewayService("invalid", "ingress")
+	invalid.Status.ValidSpec = nil
+	invalid.Status.Conditions = []appv1alpha1.GatewayServiceCondition{{Type: appv1alpha1.SpecRendered, Reason: "Excluded"}}
+
+	// Objects to track in the fake client.
+	objs := []runtime.Object{gateway, lastKnownGood, invalid}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, gateway, lastKnownGood, &appv1alpha1.GatewayServiceList{})
+
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClient(objs...)
+
+	// Create a ReconcileGateway object with the fake client.
+	r := &ReconcileGateway{client: cl}
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: gateway.Name, Namespace: namespace}}
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	found := &v1alpha3.Gateway{}
+	err = r.client.Get(context.TODO(), req.NamespacedName, found)
+	if err != nil {
+		t.Fatalf("get Gateway: (%v)", err)
+	}
+	if len(found.Spec.Servers) != 1 {
+		t.Fatalf("expected only the last-known-good server, found (%+v)", found.Spec.Servers)
+	}
+	expected := []string{fmt.Sprintf("%s.example.com", name)}
+	if !reflect.DeepEqual(found.Spec.Servers[0].Hosts, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, found.Spec.Servers[0].Hosts)
+	}
+}
+
+func TestDefaultServerAfterLastDeleted(t *testing.T) {
+	tests := []struct {
+		name     string
+		disabled bool
+		created  bool
+		expected []string
+		deleted  bool
+	}{
+		{name: "default server", expected: []string{fmt.Sprintf("http-%s", namespace)}},
+		{name: "disabled", disabled: true, expected: []string{}},
+		{name: "disabled and created by the operator", disabled: true, created: true, deleted: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// The Gateway still serves the last GatewayService, which has been deleted.
+			serverName := fmt.Sprintf("https-%s-%s", name, namespace)
+			gateway := &v1alpha3.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        fmt.Sprintf("%s-ingress-gateway", namespace),
+					Namespace:   namespace,
+					Annotations: map[string]string{gw.ManagedServersAnnotation: serverName},
+				},
+				Spec: networkv3.Gateway{
+					Servers: []*networkv3.Server{
+						{
+							Port:  &networkv3.Port{Name: serverName, Number: 443, Protocol: "HTTPS"},
+							Hosts: []string{fmt.Sprintf("%s.example.com", name)},
+						},
+					},
+				},
+			}
+			if test.created {
+				gateway.Annotations[gw.CreatedAnnotation] = "true"
+			}
+			operatorConfig := &appv1alpha1.GatewayServiceOperatorConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: config.Name},
+				Spec: appv1alpha1.GatewayServiceOperatorConfigSpec{
+					DefaultServer: &appv1alpha1.DefaultServer{Disabled: test.disabled},
+				},
+			}
+
+			// Objects to track in the fake client.
+			objs := []runtime.Object{gateway, operatorConfig}
+
+			// Register operator types with the runtime scheme.
+			s := scheme.Scheme
+			s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, gateway, &appv1alpha1.GatewayService{}, &appv1alpha1.GatewayServiceList{},
+				operatorConfig, &appv1alpha1.GatewayServiceOperatorConfigList{})
+
+			// Create a fake client to mock API calls.
+			cl := fake.NewFakeClient(objs...)
+
+			// Create a ReconcileGateway object with the fake client.
+			r := &ReconcileGateway{client: cl}
+
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: gateway.Name, Namespace: namespace}}
+			_, err := r.Reconcile(req)
+			if err != nil {
+				t.Fatalf("reconcile: (%v)", err)
+			}
+			found := &v1alpha3.Gateway{}
+			err = r.client.Get(context.TODO(), req.NamespacedName, found)
+			if test.deleted {
+				if !errors.IsNotFound(err) {
+					t.Fatalf("expected gateway to be deleted: (%v)", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("get Gateway: (%v)", err)
+			}
+			servers := []string{}
+			for _, server := range found.Spec.Servers {
+				servers = append(servers, server.Port.Name)
+			}
+			if !reflect.DeepEqual(servers, test.expected) {
+				t.Fatalf("Expected: (%+v) \n Found: (%+v)", test.expected, servers)
+			}
+		})
+	}
+}
+
+// BenchmarkGatewayReconcile renders a Gateway targeted by thousands of GatewayServices.
+func BenchmarkGatewayReconcile(b *testing.B) {
+	for _, count := range []int{1000, 5000} {
+		b.Run(fmt.Sprintf("%d", count), func(b *testing.B) {
+			gateway := &v1alpha3.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      fmt.Sprintf("%s-ingress-gateway", namespace),
+					Namespace: namespace,
+				},
+			}
+			objs := []runtime.Object{gateway}
+			for i := 0; i < count; i++ {
+				objs = append(objs, newGatewayService(fmt.Sprintf("%s-%d", name, i), "ingress"))
+			}
+			s := scheme.Scheme
+			s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, gateway, &appv1alpha1.GatewayService{}, &appv1alpha1.GatewayServiceList{})
+			r := &ReconcileGateway{client: fake.NewFakeClient(objs...)}
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: gateway.Name, Namespace: namespace}}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				// Alternate the Gateway between the rendered servers and none so every iteration writes it.
+				found := &v1alpha3.Gateway{}
+				err := r.client.Get(context.TODO(), req.NamespacedName, found)
+				if err != nil {
+					b.Fatalf("get Gateway: (%v)", err)
+				}
+				found.Spec.Servers = nil
+				found.Annotations = nil
+				err = r.client.Update(context.TODO(), found)
+				if err != nil {
+					b.Fatalf("update Gateway: (%v)", err)
+				}
+				_, err = r.Reconcile(req)
+				if err != nil {
+					b.Fatalf("reconcile: (%v)", err)
+				}
+			}
+		})
+	}
+}
@@ -3,9 +3,20 @@ package gatewayservice
 import (
 	"context"
 	"fmt"
-	"os"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/certificate"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/config"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/finalizer"
 	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/gateway"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/host"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/index"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/metrics"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/mount"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/provider"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/rollout"
 	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/secret"
 	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/status"
 	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/validate"
@@ -14,15 +25,23 @@ import (
 	"istio.io/client-go/pkg/apis/networking/v1alpha3"
 
 	appv1alpha1 "github.com/xunholy/k8s-istio-gateway-service-operator/pkg/apis/crd/v1alpha1"
+	gatewaycontroller "github.com/xunholy/k8s-istio-gateway-service-operator/pkg/controller/gateway"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/fields"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -33,16 +52,31 @@ import (
 
 var (
 	// blank assignment to verify that ReconcileGatewayService implements reconcile.Reconciler
-	_      reconcile.Reconciler = &ReconcileGatewayService{}
-	log                         = logf.Log.WithName("controller_gatewayservice")
-	domain                      = getEnv("DOMAIN", "example.com")
+	_   reconcile.Reconciler = &ReconcileGatewayService{}
+	log                      = logf.Log.WithName("controller_gatewayservice")
+)
+
+const (
+	// gatewayServiceFinalizer guards GatewayService deletion until its server has been removed from
+	// the Gateway and every secret created on its behalf has been deleted.
+	gatewayServiceFinalizer = "finalizer.gatewayservice.crd.xunholy.github.com"
+
+	// rolloutPollInterval is how often the progress of a gateway rollout is reported while it is in progress.
+	rolloutPollInterval = 10 * time.Second
 )
 
 type ReconcileGatewayService struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver
-	client client.Client
-	scheme *runtime.Scheme
+	client   client.Client
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
+
+	// kubeClient patches the gateway Deployment, leaving the fields which are not managed by the operator untouched.
+	kubeClient kubernetes.Interface
+
+	// dynamicClient reads the managedFields of the Gateway, which are not part of the typed object.
+	dynamicClient dynamic.Interface
 }
 
 // Add creates a new GatewayService Controller and adds it to the Manager. The Manager will set fields on the Controller
@@ -53,7 +87,13 @@ func Add(mgr manager.Manager) error {
 
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileGatewayService{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+	return &ReconcileGatewayService{
+		client:        mgr.GetClient(),
+		scheme:        mgr.GetScheme(),
+		recorder:      mgr.GetRecorder("gatewayservice-controller"),
+		kubeClient:    kubernetes.NewForConfigOrDie(mgr.GetConfig()),
+		dynamicClient: dynamic.NewForConfigOrDie(mgr.GetConfig()),
+	}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -70,11 +110,38 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
-	// Modify this to be the types you create that are owned by the primary resource
-	// Watch for changes to secondary resource Secrets and requeue the owner GatewayService
-	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestForOwner{
-		IsController: true,
-		OwnerType:    &appv1alpha1.GatewayService{},
+	// Watch for changes to the Gateways and requeue every GatewayService targeting them, so the GatewayAvailable
+	// condition is updated once a Gateway is created or deleted.
+	err = c.Watch(&source.Kind{Type: &v1alpha3.Gateway{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(gatewayRequests(mgr.GetClient())),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to the operator config and requeue every GatewayService, so the config is applied without
+	// restarting the operator.
+	err = c.Watch(&source.Kind{Type: &appv1alpha1.GatewayServiceOperatorConfig{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(configRequests(mgr.GetClient())),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to the namespaces and requeue every GatewayService within them, so hosts expanded from
+	// namespace labels are validated again.
+	err = c.Watch(&source.Kind{Type: &corev1.Namespace{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(namespaceRequests(mgr.GetClient())),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to secondary resource Secrets and requeue the owner GatewayService, along with every
+	// GatewayService referencing the secret. Owner references cannot cross namespaces so the owner is resolved from
+	// the labels on the secret.
+	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(secretRequests(mgr.GetClient())),
 	})
 	if err != nil {
 		return err
@@ -83,75 +150,212 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	return nil
 }
 
+// +kubebuilder:rbac:groups=crd.xunholy.github.com,resources=gatewayservices,verbs=get;list;watch;update
+// +kubebuilder:rbac:groups=crd.xunholy.github.com,resources=gatewayservices/status,verbs=update
+// +kubebuilder:rbac:groups=crd.xunholy.github.com,resources=gatewayserviceoperatorconfigs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=networking.istio.io,resources=gateways,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=list
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;patch
+
 // Reconcile reads that state of the cluster for a GatewayService object and makes changes based on the state read
 // and what is in the GatewayService.Spec
 // Note: The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *ReconcileGatewayService) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	result, err := r.reconcile(request)
+	if err != nil {
+		if _, ok := err.(permanentError); ok {
+			metrics.RecordReconcile(metrics.ResultPermanentError)
+			// The GatewayService is reconciled again once its spec is changed.
+			return reconcile.Result{}, nil
+		}
+		metrics.RecordReconcile(metrics.ResultTransientError)
+		// The Controller retries the Request with exponential backoff.
+		return reconcile.Result{}, err
+	}
+	metrics.RecordReconcile(metrics.ResultSuccess)
+	return result, nil
+}
+
+func (r *ReconcileGatewayService) reconcile(request reconcile.Request) (reconcile.Result, error) {
 	logger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 	logger.Info("Reconciling GatewayService")
 	gatewayservice, err := r.ReconcileCRD(request)
 	if err != nil {
-		logger.Error(err, "Failed to process CRD request. Requeue")
+		logger.Error(err, "Failed to process CRD request")
 		statusErr := r.ReconcileCRDStatus(request, gatewayservice, err)
 		if statusErr != nil {
-			logger.Error(statusErr, "Failed to update CRD status. Requeue")
+			logger.Error(statusErr, "Failed to update CRD status")
 		}
-		return reconcile.Result{Requeue: true}, err
+		return reconcile.Result{}, err
 	}
 	if gatewayservice == nil {
 		return reconcile.Result{}, nil
 	}
+	// The operator config is loaded once, so every step of the reconcile applies the same settings.
+	cfg := r.operatorConfig()
+
+	if gatewayservice.ObjectMeta.DeletionTimestamp != nil {
+		err = r.ReconcileFinalizer(request, gatewayservice, cfg)
+		if err != nil {
+			logger.Error(err, "Failed to finalize GatewayService")
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{}, nil
+	}
 
-	err = r.validation(request, gatewayservice)
+	if !finalizer.Has(gatewayservice, gatewayServiceFinalizer) {
+		finalizer.Add(gatewayservice, gatewayServiceFinalizer)
+		err = r.client.Update(context.TODO(), gatewayservice)
+		if err != nil {
+			logger.Error(err, "Failed to add finalizer")
+			return reconcile.Result{}, err
+		}
+	}
+
+	namespaces, err := r.secretNamespaces(request, gatewayservice, cfg)
 	if err != nil {
-		logger.Error(err, "Failed to process TLSSecretRef request. Requeue")
+		logger.Error(err, "Failed to find the namespaces of the gateway pods", "gatewayservice.Spec.TrafficType", gatewayservice.Spec.TrafficType)
 		statusErr := r.ReconcileCRDStatus(request, gatewayservice, err)
 		if statusErr != nil {
-			logger.Error(statusErr, "Failed to update CRD status. Requeue")
+			logger.Error(statusErr, "Failed to update CRD status")
 		}
-		return reconcile.Result{Requeue: true}, err
+		return reconcile.Result{}, err
 	}
+	gatewayservice.Status.Condition.CreatedSecretDetails.SecretNamespace = namespaces[0]
+	gatewayservice.Status.Condition.CreatedSecretDetails.SecretNamespaces = namespaces
 
-	err = r.ReconcileSecret(request, gatewayservice)
+	err = r.validation(request, gatewayservice, namespaces, cfg)
 	if err != nil {
-		logger.Error(err, "Failed to process secret request. Requeue", "gatewayservice.Spec.Mode", gatewayservice.Spec.Mode)
+		logger.Error(err, "Failed to validate GatewayService")
+		if _, ok := err.(permanentError); ok {
+			r.setRenderedCondition(gatewayservice, err)
+		}
 		statusErr := r.ReconcileCRDStatus(request, gatewayservice, err)
 		if statusErr != nil {
-			logger.Error(statusErr, "Failed to update CRD status. Requeue")
+			logger.Error(statusErr, "Failed to update CRD status")
 		}
-		return reconcile.Result{Requeue: true}, err
+		return reconcile.Result{}, err
 	}
 
-	err = r.ReconcileGateway(request, gatewayservice, gatewayservice.Spec.TrafficType)
+	// A missing Gateway is reported by the status, the credential is still prepared for when the Gateway exists.
+	var gatewayErr error
+	err = r.ReconcileGatewayAvailable(request, gatewayservice, cfg)
+	if _, ok := err.(gatewayNotFoundError); ok {
+		gatewayErr, err = err, nil
+	}
 	if err != nil {
-		logger.Error(err, "Failed to process gateway request. Requeue", "gatewayservice.Spec.TrafficType", gatewayservice.Spec.TrafficType)
+		logger.Error(err, "Failed to read gateway", "gatewayservice.Spec.TrafficType", gatewayservice.Spec.TrafficType)
 		statusErr := r.ReconcileCRDStatus(request, gatewayservice, err)
 		if statusErr != nil {
-			logger.Error(statusErr, "Failed to update CRD status. Requeue")
+			logger.Error(statusErr, "Failed to update CRD status")
 		}
-		return reconcile.Result{Requeue: true}, err
+		return reconcile.Result{}, err
 	}
 
-	err = r.ReconcileCRDStatus(request, gatewayservice, nil)
+	refreshAfter, err := r.ReconcileSecret(request, gatewayservice, namespaces, cfg)
 	if err != nil {
-		logger.Error(err, "Failed to update CRD status after successful completion. Requeue")
+		logger.Error(err, "Failed to process secret request", "gatewayservice.Spec.Mode", gatewayservice.Spec.Mode)
+		statusErr := r.ReconcileCRDStatus(request, gatewayservice, err)
+		if statusErr != nil {
+			logger.Error(statusErr, "Failed to update CRD status")
+		}
+		return reconcile.Result{}, err
+	}
+
+	// The spec passed validation and its credential is in every namespace serving the Gateway, so it becomes the
+	// last-known-good spec rendered into the Gateway. Until then the Gateway keeps rendering the previous one, whose
+	// credential still exists.
+	gatewayservice.Status.ValidGeneration = gatewayservice.Generation
+	gatewayservice.Status.ValidSpec = validSpec(gatewayservice.Spec)
+	gatewayservice.Status.Gateway = cfg.GatewayName(request.Namespace, gatewayservice.Spec.TrafficType)
+	r.setRenderedCondition(gatewayservice, nil)
+
+	rolloutAfter, err := r.ReconcileRollout(request, gatewayservice, gatewayservice.Spec.TrafficType, cfg)
+	if err != nil {
+		logger.Error(err, "Failed to mount secrets into and roll gateway pods", "gatewayservice.Spec.TrafficType", gatewayservice.Spec.TrafficType)
+		statusErr := r.ReconcileCRDStatus(request, gatewayservice, err)
+		if statusErr != nil {
+			logger.Error(statusErr, "Failed to update CRD status")
+		}
+		return reconcile.Result{}, err
+	}
+
+	err = r.SweepSecrets(request, gatewayservice, namespaces, cfg)
+	if err != nil {
+		logger.Error(err, "Failed to remove secrets no longer required")
+		statusErr := r.ReconcileCRDStatus(request, gatewayservice, err)
+		if statusErr != nil {
+			logger.Error(statusErr, "Failed to update CRD status")
+		}
+		return reconcile.Result{}, err
+	}
+
+	// Reconcile again when the next certificate expiry threshold is crossed so warnings are raised on time.
+	requeueAfter := r.ReconcileCertificate(request, gatewayservice, namespaces[0], cfg)
+	// Reconcile again when the credential must be refreshed or to report rollout progress, whichever comes first.
+	requeueAfter = minRequeue(requeueAfter, refreshAfter)
+	requeueAfter = minRequeue(requeueAfter, rolloutAfter)
+	// Otherwise reconcile again on the resync interval to repair anything missed by the watches.
+	requeueAfter = minRequeue(requeueAfter, cfg.ResyncPeriod)
+
+	err = r.ReconcileCRDStatus(request, gatewayservice, gatewayErr)
+	if err != nil {
+		logger.Error(err, "Failed to update CRD status after successful completion")
+		return reconcile.Result{}, err
 	}
-	return reconcile.Result{Requeue: true}, nil
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
 }
 
+// ReconcileCRDStatus writes the status of the GatewayService, err being the failure of the reconcile if any. The
+// status is written with Get and Status().Update retried on conflict rather than a patch, as the status client of the
+// vendored controller-runtime v0.1.12 has no Patch.
 func (r *ReconcileGatewayService) ReconcileCRDStatus(request reconcile.Request, gatewayservice *appv1alpha1.GatewayService, err error) error {
 	s := status.StatusConfig{
-		Success:         err == nil,
-		ErrorMessage:    "No error found",
-		SecretName:      fmt.Sprintf("%s-%s-secret", request.Name, request.Namespace),
-		SecretNamespace: secretNamespace(gatewayservice),
+		Success:          err == nil,
+		ErrorMessage:     "No error found",
+		SecretName:       provider.SecretName(*gatewayservice),
+		SecretNamespace:  gatewayservice.Status.Condition.CreatedSecretDetails.SecretNamespace,
+		SecretNamespaces: gatewayservice.Status.Condition.CreatedSecretDetails.SecretNamespaces,
+		Conditions:       gatewayservice.Status.Conditions,
+		ValidGeneration:  gatewayservice.Status.ValidGeneration,
+		ValidSpec:        gatewayservice.Status.ValidSpec,
+		Hosts:            gatewayservice.Status.Hosts,
+		Gateway:          gatewayservice.Status.Gateway,
 	}
 	if err != nil {
 		s.ErrorMessage = err.Error()
 	}
-	gatewayservice.Status = *status.Reconcile(s)
-	return r.client.Update(context.TODO(), gatewayservice)
+	desired := *status.Reconcile(s)
+	// Without a status patch the whole status is replaced, so the latest GatewayService is read to avoid writing a stale
+	// resourceVersion and to skip writes which would not change the status. The status subresource ignores changes to
+	// the spec.
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &appv1alpha1.GatewayService{}
+		err := r.client.Get(context.TODO(), request.NamespacedName, latest)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		if equality.Semantic.DeepEqual(latest.Status, desired) {
+			gatewayservice.Status = latest.Status
+			return nil
+		}
+		latest.Status = desired
+		err = r.client.Status().Update(context.TODO(), latest)
+		if err != nil {
+			return err
+		}
+		gatewayservice.Status = latest.Status
+		gatewayservice.ResourceVersion = latest.ResourceVersion
+		return nil
+	})
 }
 
 func (r *ReconcileGatewayService) ReconcileCRD(request reconcile.Request) (*appv1alpha1.GatewayService, error) {
@@ -161,14 +365,7 @@ func (r *ReconcileGatewayService) ReconcileCRD(request reconcile.Request) (*appv
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Request object not found, could have been deleted after reconcile request.
-			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
-			// Return and don't requeue
-			for _, trafficType := range []string{"ingress", "egress"} {
-				err := r.ReconcileGateway(request, gatewayservice, trafficType)
-				if err != nil {
-					return gatewayservice, err
-				}
-			}
+			// Cleanup of the Gateway and secrets is handled by the finalizer before the object is removed.
 			// Once the CRD has been removed there is no reason to requeue any additional times.
 			return nil, nil
 		}
@@ -178,116 +375,787 @@ func (r *ReconcileGatewayService) ReconcileCRD(request reconcile.Request) (*appv
 	return gatewayservice, nil
 }
 
-func (r *ReconcileGatewayService) ReconcileGateway(request reconcile.Request, gatewayservice *appv1alpha1.GatewayService, trafficType string) error {
+// ReconcileGateway renders the Gateway serving the trafficType immediately, whether or not the trafficType is still
+// declared by the operator config. The Gateway is otherwise rendered by the Gateway controller, which coalesces the
+// changes to every GatewayService targeting it.
+func (r *ReconcileGatewayService) ReconcileGateway(key types.NamespacedName, trafficType string, cfg config.Config) error {
+	return gatewaycontroller.RenderTrafficType(gatewaycontroller.RenderConfig{
+		Client:        r.client,
+		Recorder:      r.recorder,
+		DynamicClient: r.dynamicClient,
+		Config:        cfg,
+	}, key, trafficType)
+}
+
+// ReconcileGatewayAvailable sets the GatewayAvailable condition. A gatewayNotFoundError is returned when the Gateway
+// targeted by the GatewayService does not exist and is not created by the operator.
+func (r *ReconcileGatewayService) ReconcileGatewayAvailable(request reconcile.Request, gatewayservice *appv1alpha1.GatewayService, cfg config.Config) error {
+	key := gatewaycontroller.Key(cfg, request.Namespace, gatewayservice.Spec.TrafficType)
+	err := r.client.Get(context.TODO(), key, &v1alpha3.Gateway{})
+	if err == nil {
+		r.setGatewayCondition(gatewayservice, corev1.ConditionTrue, "GatewayFound", fmt.Sprintf("gateway %s exists", key))
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return err
+	}
+	if cfg.CreateGateways {
+		r.setGatewayCondition(gatewayservice, corev1.ConditionFalse, "GatewayPending", fmt.Sprintf("gateway %s is being created", key))
+		return nil
+	}
+	message := fmt.Sprintf("gateway %s does not exist", key)
+	r.setGatewayCondition(gatewayservice, corev1.ConditionFalse, "GatewayNotFound", message)
+	return gatewayNotFoundError{fmt.Errorf("GatewayNotFound: %s", message)}
+}
+
+// gatewayNotFoundError is reported by the status of a GatewayService whose Gateway does not exist. The GatewayService
+// is reconciled again once the Gateway is created.
+type gatewayNotFoundError struct {
+	error
+}
+
+// ReconcileRollout mounts the secrets for TLSSecretPath into the gateway pods selected by the Gateway and rolls them
+// whenever the mounted certificates change, as the mounted files are otherwise not picked up. The rollout is reported
+// by the GatewayRolledOut condition and the duration after which progress must be reported again is returned, zero
+// once the rollout is complete.
+func (r *ReconcileGatewayService) ReconcileRollout(request reconcile.Request, gatewayservice *appv1alpha1.GatewayService, trafficType string, cfg config.Config) (time.Duration, error) {
+	gatewayObj, deployment, err := r.gatewayDeployment(gatewaycontroller.Key(cfg, request.Namespace, trafficType), trafficType, cfg)
+	if err != nil {
+		return 0, err
+	}
+	rolled := false
+	if deployment != nil {
+		var rejected map[types.NamespacedName]error
+		deployment, rolled, rejected, err = r.ReconcileMounts(deployment, cfg)
+		if err != nil {
+			return 0, err
+		}
+		if mountErr, ok := rejected[request.NamespacedName]; ok {
+			return 0, mountErr
+		}
+	}
+
+	_, p := provider.For(gatewayservice.Spec.TLSOptions)
+	if p == nil || !mountsCredential(p.Credential(*gatewayservice, gateway.TlsMode(gatewayservice.Spec.Mode))) {
+		gatewayservice.Status.Conditions = status.RemoveCondition(gatewayservice.Status.Conditions, appv1alpha1.GatewayRolledOut)
+		return 0, nil
+	}
+	if gatewayObj == nil {
+		return 0, nil
+	}
+	if deployment == nil {
+		r.setRolloutCondition(gatewayservice, corev1.ConditionUnknown, "DeploymentNotFound",
+			fmt.Sprintf("no deployment is selected by gateway %s", gatewayObj.Name))
+		return 0, nil
+	}
+	if rolled && r.recorder != nil {
+		r.recorder.Eventf(gatewayservice, corev1.EventTypeNormal, "RolloutStarted",
+			"rolling deployment %s/%s to pick up certificates mounted for TLSSecretPath", deployment.Namespace, deployment.Name)
+	}
+	complete, message := rollout.Progress(deployment)
+	if !complete {
+		r.setRolloutCondition(gatewayservice, corev1.ConditionFalse, "RolloutInProgress", message)
+		return rolloutPollInterval, nil
+	}
+	r.setRolloutCondition(gatewayservice, corev1.ConditionTrue, "RolloutComplete", message)
+	return 0, nil
+}
+
+// ReconcileMounts mounts the secret of every GatewayService served by the gateway Deployment which uses TLSSecretPath
+// with a secretName into the gateway pods, and removes the mounts which are no longer required. The Deployment may
+// serve the Gateways of many namespaces, so the mounts of every GatewayService whose Gateway selects it are kept.
+// Whenever the secrets mounted for the cert and key paths of those GatewayServices change the gateway pods are rolled.
+// The mounts and the rollout are applied as a single patch, so the pods are rolled once, and the patched Deployment is
+// returned along with whether a rollout was started. A path is only mounted for the first GatewayService, ordered by
+// namespace and name, and an error is returned for every other GatewayService mounting the same path.
+func (r *ReconcileGatewayService) ReconcileMounts(deployment *appsv1.Deployment, cfg config.Config) (*appsv1.Deployment, bool, map[types.NamespacedName]error, error) {
+	credentials, err := r.mountedCredentials(deployment, cfg)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	keys := []types.NamespacedName{}
+	for key := range credentials {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	mounts := []mount.Mount{}
+	paths := []string{}
+	for _, key := range keys {
+		credential := credentials[key]
+		paths = append(paths, credential.CertPath, credential.KeyPath)
+		if credential.MountSecretName == "" {
+			continue
+		}
+		m, err := mount.ForPaths(key.Name, key.Namespace, credential.MountSecretName, credential.CertPath, credential.KeyPath)
+		if err != nil {
+			// The GatewayService reports the invalid paths itself, the other mounts are still reconciled.
+			log.Error(err, "Invalid TLSSecretPath", "Request.Namespace", key.Namespace, "Request.Name", key.Name)
+			continue
+		}
+		mounts = append(mounts, m)
+	}
+	mounts, rejected := mount.Unique(mounts)
+	mountPatch, err := mount.Reconcile(mount.MountConfig{Deployment: deployment, Mounts: mounts})
+	if err != nil {
+		return nil, false, nil, err
+	}
+	mounted, err := rollout.Apply(deployment, mountPatch)
+	if err != nil {
+		return nil, false, nil, err
+	}
+
+	// The hash covers the secrets mounted for every GatewayService served by the Deployment once the mounts are
+	// applied, a change to any of them rolls the gateway pods.
+	secrets := []corev1.Secret{}
+	for _, name := range rollout.SecretsFor(mounted, paths) {
+		secretObj := corev1.Secret{}
+		err = r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: deployment.Namespace}, &secretObj)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, false, nil, err
+		}
+		secrets = append(secrets, secretObj)
+	}
+	hash := ""
+	if len(paths) > 0 {
+		hash = rollout.Hash(paths, secrets)
+	}
+	rolloutPatch, err := rollout.Reconcile(rollout.RolloutConfig{
+		Deployment:     mounted,
+		Hash:           hash,
+		MaxUnavailable: cfg.RolloutMaxUnavailable,
+		GracePeriod:    cfg.RolloutGracePeriod,
+	})
+	if err != nil {
+		return nil, false, nil, err
+	}
+	rolled := mounted.Spec.Template.Annotations[rollout.HashAnnotation] != hash
+	modified, err := rollout.Apply(mounted, rolloutPatch)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	patch, err := rollout.Diff(deployment, modified)
+	if err != nil || patch == nil {
+		return deployment, false, rejected, err
+	}
+	deployment, err = r.kubeClient.AppsV1().Deployments(deployment.Namespace).Patch(deployment.Name, types.StrategicMergePatchType, patch)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	return deployment, rolled, rejected, nil
+}
+
+// gatewayDeployment returns the Gateway serving the trafficType and the Deployment of the gateway pods it selects, from
+// the first of the namespaces serving the Gateway with a selected Deployment. Both are nil if the Gateway does not
+// exist, and the Deployment is nil if no Deployment is selected.
+func (r *ReconcileGatewayService) gatewayDeployment(key types.NamespacedName, trafficType string, cfg config.Config) (*v1alpha3.Gateway, *appsv1.Deployment, error) {
 	gatewayObj := &v1alpha3.Gateway{}
-	err := r.client.Get(context.TODO(), types.NamespacedName{Name: fmt.Sprintf("%s-%s-gateway", request.Namespace, trafficType), Namespace: request.Namespace}, gatewayObj)
+	err := r.client.Get(context.TODO(), key, gatewayObj)
 	if err != nil {
 		if errors.IsNotFound(err) {
-			// Ingress and/or Egress Gateway object does not exist.
-			return nil
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+	if len(gatewayObj.Spec.Selector) == 0 {
+		return gatewayObj, nil, nil
+	}
+	namespaces, err := r.gatewayNamespaces(trafficType, gatewayObj, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, namespace := range namespaces {
+		deployments, err := r.kubeClient.AppsV1().Deployments(namespace).List(metav1.ListOptions{})
+		if err != nil {
+			return nil, nil, err
+		}
+		if deployment := rollout.Selects(gatewayObj.Spec.Selector, deployments.Items); deployment != nil {
+			return gatewayObj, deployment, nil
 		}
-		return err
 	}
+	return gatewayObj, nil, nil
+}
 
-	// List all GatewayService CRDs
+// mountedCredentials returns the credentials mounted within the pods of the gateway Deployment for every
+// GatewayService, within any namespace, whose Gateway selects the Deployment, keyed by GatewayService. GatewayServices
+// pending deletion are omitted.
+func (r *ReconcileGatewayService) mountedCredentials(deployment *appsv1.Deployment, cfg config.Config) (map[types.NamespacedName]*provider.Credential, error) {
 	gatewayservices := &appv1alpha1.GatewayServiceList{}
-	listOps := &client.ListOptions{
-		Namespace:     request.Namespace,
-		FieldSelector: fields.OneTermEqualSelector("spec.trafficType", trafficType),
+	err := r.client.List(context.TODO(), &client.ListOptions{}, gatewayservices)
+	if err != nil {
+		return nil, err
+	}
+	// selected records whether each Gateway selects the Deployment, as many GatewayServices share a Gateway.
+	selected := map[types.NamespacedName]bool{}
+	credentials := map[types.NamespacedName]*provider.Credential{}
+	for i := range gatewayservices.Items {
+		gs := gatewayservices.Items[i]
+		if gs.DeletionTimestamp != nil {
+			continue
+		}
+		_, p := provider.For(gs.Spec.TLSOptions)
+		if p == nil {
+			continue
+		}
+		credential := p.Credential(gs, gateway.TlsMode(gs.Spec.Mode))
+		if !mountsCredential(credential) {
+			continue
+		}
+		key := gatewaycontroller.Key(cfg, gs.Namespace, gs.Spec.TrafficType)
+		selects, ok := selected[key]
+		if !ok {
+			selects, err = r.selectsDeployment(key, deployment)
+			if err != nil {
+				return nil, err
+			}
+			selected[key] = selects
+		}
+		if selects {
+			credentials[types.NamespacedName{Name: gs.Name, Namespace: gs.Namespace}] = credential
+		}
 	}
+	return credentials, nil
+}
 
-	err = r.client.List(context.TODO(), listOps, gatewayservices)
+// selectsDeployment reports whether the Gateway selects the pods of the Deployment, false if the Gateway does not
+// exist.
+func (r *ReconcileGatewayService) selectsDeployment(key types.NamespacedName, deployment *appsv1.Deployment) (bool, error) {
+	gatewayObj := &v1alpha3.Gateway{}
+	err := r.client.Get(context.TODO(), key, gatewayObj)
 	if err != nil {
-		return err
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
 	}
+	return rollout.Selects(gatewayObj.Spec.Selector, []appsv1.Deployment{*deployment}) != nil, nil
+}
 
-	g := gateway.GatewayConfig{
-		Name:           fmt.Sprintf("%s-%s-gateway", request.Namespace, trafficType),
-		TrafficType:    trafficType,
-		GatewayService: gatewayservices,
-		Gateway:        gatewayObj,
-		Domain:         domain,
+// ReconcileSecret ensures the credential of the GatewayService using the provider configured by the TLSOptions, and
+// copies the secret created into every other namespace serving the Gateway. The duration after which the credential
+// must be refreshed is returned, zero if it is never refreshed.
+func (r *ReconcileGatewayService) ReconcileSecret(request reconcile.Request, gatewayservice *appv1alpha1.GatewayService, namespaces []string, cfg config.Config) (time.Duration, error) {
+	_, p := provider.For(gatewayservice.Spec.TLSOptions)
+	if p == nil {
+		return 0, nil
 	}
-	reconciledGatewayObj := gateway.Reconcile(g)
-	return r.client.Update(context.TODO(), reconciledGatewayObj)
+	c := r.providerConfig(gatewayservice, namespaces, cfg)
+	err := provider.VerifySecretName(c)
+	if err != nil {
+		return 0, err
+	}
+	refreshAfter, err := p.EnsureCredential(c)
+	if err != nil {
+		return 0, err
+	}
+	return refreshAfter, provider.Copy(c)
 }
 
-func (r *ReconcileGatewayService) ReconcileSecret(request reconcile.Request, gatewayservice *appv1alpha1.GatewayService) error {
-	if gatewayservice.Spec.TLSOptions != nil {
-		if gatewayservice.Spec.TLSOptions.TLSSecret != nil {
-			if gatewayservice.Spec.TLSOptions.TLSSecret.Cert == nil || gatewayservice.Spec.TLSOptions.TLSSecret.Key == nil {
-				return fmt.Errorf("cert and/or key cannot be nil")
-			}
-			secretObj := &corev1.Secret{}
-			key := types.NamespacedName{Name: fmt.Sprintf("%s-%s-secret", request.Name, request.Namespace), Namespace: secretNamespace(gatewayservice)}
-			err := r.client.Get(context.TODO(), key, secretObj)
+// ReconcileFinalizer removes the GatewayService server from every Gateway and deletes the secrets created on its
+// behalf, then releases the finalizer so the object can be removed.
+func (r *ReconcileGatewayService) ReconcileFinalizer(request reconcile.Request, gatewayservice *appv1alpha1.GatewayService, cfg config.Config) error {
+	if !finalizer.Has(gatewayservice, gatewayServiceFinalizer) {
+		return nil
+	}
+	// The TrafficType may have changed during the lifetime of the GatewayService so the Gateway of every traffic class
+	// declared by the operator config is reconciled, along with those of the classes the GatewayService targets, which
+	// may no longer be declared, and the Gateway recorded as rendering it.
+	for _, target := range finalizerGateways(cfg, gatewayservice) {
+		err := r.ReconcileGateway(target.key, target.trafficType, cfg)
+		if err != nil {
+			return err
+		}
+		_, deployment, err := r.gatewayDeployment(target.key, target.trafficType, cfg)
+		if err != nil {
+			return err
+		}
+		if deployment != nil {
+			_, _, _, err = r.ReconcileMounts(deployment, cfg)
 			if err != nil {
-				if errors.IsNotFound(err) {
-					err := validate.ValidateSecretEncoding(*gatewayservice.Spec.TLSOptions.TLSSecret)
-					if err != nil {
-						return fmt.Errorf("cert and/or key are not base64 encoded")
-					}
-					s := secret.SecretConfig{
-						Name:           fmt.Sprintf("%s-%s-secret", request.Name, request.Namespace),
-						Namespace:      secretNamespace(gatewayservice),
-						Labels:         map[string]string{"Namespace": request.Namespace},
-						GatewayService: gatewayservice,
-					}
-					reconciledSecretObj := secret.Reconcile(s)
-
-					// SetControllerReference sets owner as a Controller OwnerReference on owned.
-					// This is used for garbage collection of the owned object and for
-					// reconciling the owner object on changes to owned (with a Watch + EnqueueRequestForOwner).
-					// Since only one OwnerReference can be a controller, it returns an error if
-					// there is another OwnerReference with Controller flag set.
-					err = controllerutil.SetControllerReference(gatewayservice, reconciledSecretObj, r.scheme)
-					if err != nil {
-						return err
-					}
-					return r.client.Create(context.TODO(), reconciledSecretObj)
-				}
 				return err
 			}
 		}
 	}
-	return nil
+	err := r.SweepSecrets(request, gatewayservice, nil, cfg)
+	if err != nil {
+		return err
+	}
+	metrics.DeleteCertificateExpiry(request.Namespace, request.Name)
+	finalizer.Remove(gatewayservice, gatewayServiceFinalizer)
+	return r.client.Update(context.TODO(), gatewayservice)
+}
+
+// gatewayTarget is a Gateway along with the trafficType it serves.
+type gatewayTarget struct {
+	key         types.NamespacedName
+	trafficType string
 }
 
-func (r *ReconcileGatewayService) validation(request reconcile.Request, gatewayservice *appv1alpha1.GatewayService) error {
-	err := validate.TLSOptionExists(gatewayservice)
+// finalizerGateways returns the Gateways of the trafficTypes returned by finalizerTrafficTypes, followed by the Gateway
+// recorded as rendering the last-known-good spec. The recorded Gateway differs when its traffic class was removed
+// along with the gatewayNameTemplate which named it.
+func finalizerGateways(cfg config.Config, gatewayservice *appv1alpha1.GatewayService) []gatewayTarget {
+	targets := []gatewayTarget{}
+	for _, trafficType := range finalizerTrafficTypes(cfg, gatewayservice) {
+		targets = append(targets, gatewayTarget{key: gatewaycontroller.Key(cfg, gatewayservice.Namespace, trafficType), trafficType: trafficType})
+	}
+	if gatewayservice.Status.Gateway == "" || gatewayservice.Status.ValidSpec == nil {
+		return targets
+	}
+	recorded := gatewayTarget{
+		key:         types.NamespacedName{Name: gatewayservice.Status.Gateway, Namespace: gatewayservice.Namespace},
+		trafficType: gatewayservice.Status.ValidSpec.TrafficType,
+	}
+	for _, target := range targets {
+		if target.key == recorded.key {
+			return targets
+		}
+	}
+	return append(targets, recorded)
+}
+
+// finalizerTrafficTypes returns the trafficTypes declared by the operator config followed by those targeted by the spec
+// and the last-known-good spec of the GatewayService.
+func finalizerTrafficTypes(cfg config.Config, gatewayservice *appv1alpha1.GatewayService) []string {
+	trafficTypes := append([]string{}, cfg.TrafficTypes...)
+	targeted := []string{gatewayservice.Spec.TrafficType}
+	if gatewayservice.Status.ValidSpec != nil {
+		targeted = append(targeted, gatewayservice.Status.ValidSpec.TrafficType)
+	}
+	for _, trafficType := range targeted {
+		if trafficType == "" || containsString(trafficTypes, trafficType) {
+			continue
+		}
+		trafficTypes = append(trafficTypes, trafficType)
+	}
+	return trafficTypes
+}
+
+// SweepSecrets deletes every secret created for the GatewayService that the current Spec no longer requires, keeping
+// the copies within the namespaces serving the Gateway. This covers Mode and TLSOptions changes as well as deletion of
+// the GatewayService itself.
+func (r *ReconcileGatewayService) SweepSecrets(request reconcile.Request, gatewayservice *appv1alpha1.GatewayService, namespaces []string, cfg config.Config) error {
+	return provider.Cleanup(r.providerConfig(gatewayservice, namespaces, cfg))
+}
+
+// ReconcileCertificate inspects the certificate served for the GatewayService hosts. The expiry is exported as a
+// metric, and the CertificateExpiring condition is set with a Warning event raised as each threshold is crossed.
+// The duration until the next threshold is crossed is returned, zero if there is no certificate to inspect.
+func (r *ReconcileGatewayService) ReconcileCertificate(request reconcile.Request, gatewayservice *appv1alpha1.GatewayService, secretNamespace string, cfg config.Config) time.Duration {
+	logger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	data, err := r.certificateData(gatewayservice, secretNamespace)
+	if err != nil {
+		logger.Error(err, "Failed to read certificate")
+		return 0
+	}
+	if data == nil {
+		// TLSSecretPath certificates are only available within the gateway pods and cannot be inspected.
+		metrics.DeleteCertificateExpiry(request.Namespace, request.Name)
+		gatewayservice.Status.Conditions = status.RemoveCondition(gatewayservice.Status.Conditions, appv1alpha1.CertificateExpiring)
+		return 0
+	}
+	cert, err := certificate.Parse(data)
+	if err != nil {
+		metrics.DeleteCertificateExpiry(request.Namespace, request.Name)
+		r.setCertificateCondition(gatewayservice, corev1.ConditionUnknown, "CertificateInvalid", err.Error())
+		return 0
+	}
+	hosts := gatewayservice.Status.Hosts
+	metrics.SetCertificateExpiry(request.Namespace, request.Name, hosts, cert.NotAfter)
+
+	expiry := certificate.Evaluate(cert.NotAfter, time.Now(), cfg.CertificateExpiryThresholds)
+	notAfter := cert.NotAfter.UTC().Format(time.RFC3339)
+	switch {
+	case expiry.Expired:
+		r.setCertificateCondition(gatewayservice, corev1.ConditionTrue, "CertificateExpired",
+			fmt.Sprintf("certificate for hosts %v expired at %s", hosts, notAfter))
+	case expiry.Expiring:
+		r.setCertificateCondition(gatewayservice, corev1.ConditionTrue, "CertificateExpiring",
+			fmt.Sprintf("certificate for hosts %v expires at %s, within %s", hosts, notAfter, expiry.Threshold))
+	default:
+		r.setCertificateCondition(gatewayservice, corev1.ConditionFalse, "CertificateValid",
+			fmt.Sprintf("certificate for hosts %v expires at %s", hosts, notAfter))
+	}
+	return expiry.Next
+}
+
+// certificateData returns the certificate served for the GatewayService, nil if there is none. In PASSTHROUGH mode the
+// secret referenced by TLSSecretRef is not added to the Gateway, but is inspected as the application serves it.
+func (r *ReconcileGatewayService) certificateData(gatewayservice *appv1alpha1.GatewayService, secretNamespace string) ([]byte, error) {
+	_, p := provider.For(gatewayservice.Spec.TLSOptions)
+	if p == nil {
+		return nil, nil
+	}
+	secretName := ""
+	if credential := p.Credential(*gatewayservice, gateway.TlsMode(gatewayservice.Spec.Mode)); credential != nil {
+		secretName = credential.Name
+	}
+	if ref := gatewayservice.Spec.TLSOptions.TLSSecretRef; ref != nil && passthrough(gatewayservice) {
+		secretName = ref.SecretName
+	}
+	if secretName == "" {
+		return nil, nil
+	}
+	secretObj := &corev1.Secret{}
+	key := types.NamespacedName{Name: secretName, Namespace: secretNamespace}
+	err := r.client.Get(context.TODO(), key, secretObj)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return secretObj.Data["tls.crt"], nil
+}
+
+// setCertificateCondition sets the CertificateExpiring condition, raising a Warning event whenever the certificate
+// crosses into a new expiry threshold.
+func (r *ReconcileGatewayService) setCertificateCondition(gatewayservice *appv1alpha1.GatewayService, conditionStatus corev1.ConditionStatus, reason string, message string) {
+	previous := status.FindCondition(gatewayservice.Status.Conditions, appv1alpha1.CertificateExpiring)
+	if conditionStatus == corev1.ConditionTrue && (previous == nil || previous.Message != message) && r.recorder != nil {
+		r.recorder.Event(gatewayservice, corev1.EventTypeWarning, reason, message)
+	}
+	gatewayservice.Status.Conditions = status.SetCondition(gatewayservice.Status.Conditions, appv1alpha1.GatewayServiceCondition{
+		Type:    appv1alpha1.CertificateExpiring,
+		Status:  conditionStatus,
+		Reason:  reason,
+		Message: message,
+	})
+}
+
+// setGatewayCondition sets the GatewayAvailable condition.
+func (r *ReconcileGatewayService) setGatewayCondition(gatewayservice *appv1alpha1.GatewayService, conditionStatus corev1.ConditionStatus, reason string, message string) {
+	gatewayservice.Status.Conditions = status.SetCondition(gatewayservice.Status.Conditions, appv1alpha1.GatewayServiceCondition{
+		Type:    appv1alpha1.GatewayAvailable,
+		Status:  conditionStatus,
+		Reason:  reason,
+		Message: message,
+	})
+}
+
+// validSpec returns the spec recorded as the last-known-good spec. Rendering only references the secret created from a
+// TLSSecret, so its cert and key are left out rather than copied into the status, which is readable by anyone who can
+// read the GatewayService.
+func validSpec(spec appv1alpha1.GatewayServiceSpec) *appv1alpha1.GatewayServiceSpec {
+	valid := spec.DeepCopy()
+	if valid.TLSOptions != nil && valid.TLSOptions.TLSSecret != nil {
+		valid.TLSOptions.TLSSecret = &appv1alpha1.TLSSecret{}
+	}
+	return valid
+}
+
+// setRenderedCondition sets the SpecRendered condition from the last-known-good spec, err is the validation failure
+// of the latest generation.
+func (r *ReconcileGatewayService) setRenderedCondition(gatewayservice *appv1alpha1.GatewayService, err error) {
+	condition := appv1alpha1.GatewayServiceCondition{
+		Type:    appv1alpha1.SpecRendered,
+		Status:  corev1.ConditionTrue,
+		Reason:  "Current",
+		Message: fmt.Sprintf("generation %d is rendered", gatewayservice.Generation),
+	}
+	switch {
+	case err == nil:
+	case gatewayservice.Status.ValidSpec != nil:
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = "LastKnownGood"
+		condition.Message = fmt.Sprintf("generation %d is rendered until the spec is fixed: %v", gatewayservice.Status.ValidGeneration, err)
+	default:
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = "Excluded"
+		condition.Message = fmt.Sprintf("excluded from the gateway until the spec is fixed: %v", err)
+	}
+	gatewayservice.Status.Conditions = status.SetCondition(gatewayservice.Status.Conditions, condition)
+}
+
+// setRolloutCondition sets the GatewayRolledOut condition.
+func (r *ReconcileGatewayService) setRolloutCondition(gatewayservice *appv1alpha1.GatewayService, conditionStatus corev1.ConditionStatus, reason string, message string) {
+	gatewayservice.Status.Conditions = status.SetCondition(gatewayservice.Status.Conditions, appv1alpha1.GatewayServiceCondition{
+		Type:    appv1alpha1.GatewayRolledOut,
+		Status:  conditionStatus,
+		Reason:  reason,
+		Message: message,
+	})
+}
+
+func (r *ReconcileGatewayService) validation(request reconcile.Request, gatewayservice *appv1alpha1.GatewayService, namespaces []string, cfg config.Config) error {
+	err := cfg.Allows(gatewayservice.Spec)
+	if err != nil {
+		return permanentError{err}
+	}
+	hosts, err := r.expandHosts(gatewayservice, cfg)
 	if err != nil {
 		return err
 	}
-	if gatewayservice.Spec.Mode != "PASSTHROUGH" {
-		if gatewayservice.Spec.TLSOptions.TLSSecretRef != nil {
-			secret := &corev1.Secret{}
-			key := types.NamespacedName{Name: gatewayservice.Spec.TLSOptions.TLSSecretRef.SecretName, Namespace: secretNamespace(gatewayservice)}
-			err := r.client.Get(context.TODO(), key, secret)
+	err = r.modeSupported(gatewayservice)
+	if err != nil {
+		return permanentError{err}
+	}
+	err = validate.TLSOptionExists(gatewayservice)
+	if err != nil {
+		return permanentError{err}
+	}
+	_, p := provider.For(gatewayservice.Spec.TLSOptions)
+	// PASSTHROUGH credentials are held by the application, so the TLSOptions may configure no provider.
+	if gatewayservice.Spec.TLSOptions != nil && (p != nil || !passthrough(gatewayservice)) {
+		err = provider.Exactly(gatewayservice.Spec.TLSOptions)
+		if err != nil {
+			return permanentError{err}
+		}
+	}
+	if p != nil {
+		// Referenced secrets must exist within every namespace serving the Gateway.
+		for i := range namespaces {
+			c := r.providerConfig(gatewayservice, namespaces[i:], cfg)
+			c.Hosts = host.Names(hosts)
+			err = p.Validate(c)
+			if err != nil && permanent(err) {
+				return permanentError{err}
+			}
 			if err != nil {
-				if errors.IsNotFound(err) {
-					return fmt.Errorf("reference to secret %v in namespace %v does not exist -- NAME %v", secret, secretNamespace(gatewayservice), gatewayservice.Spec.TLSOptions.TLSSecretRef.SecretName)
-				}
 				return err
 			}
 		}
 	}
+	// The expanded hosts are reported along with the spec they were expanded from.
+	gatewayservice.Status.Hosts = hosts
 	return nil
 }
 
-// TODO: If a secret is SIMPLE and eventually becomes PASSTHROUGH the original secret is not cleaned up in istio-system.
-// However, when the CRD is removed due to ownership both secrets will be cleaned up appropriately.
+// expandHosts returns the canonical hosts of the GatewayService with their templates expanded from the operator config
+// and the labels of its namespace.
+func (r *ReconcileGatewayService) expandHosts(gatewayservice *appv1alpha1.GatewayService, cfg config.Config) ([]string, error) {
+	values, err := cfg.LoadHostValues(r.client, gatewayservice.Namespace, gatewayservice.Spec.TrafficType)
+	if err != nil {
+		return nil, err
+	}
+	hosts, err := gateway.ExpandHosts(gatewayservice.Spec.Hosts, values)
+	if err != nil {
+		return nil, permanentError{err}
+	}
+	hosts, err = host.Normalize(hosts, gatewayservice.Namespace, cfg.HostPolicy())
+	if err != nil {
+		return nil, permanentError{err}
+	}
+	return hosts, nil
+}
+
+// modeSupported reports an error when the mode is not supported by the Istio API of the operator or by the Gateway CRD of the
+// connected Istio. Istio versions which do not publish a schema for the Gateway CRD are assumed to support the mode.
+func (r *ReconcileGatewayService) modeSupported(gatewayservice *appv1alpha1.GatewayService) error {
+	mode := gatewayservice.Spec.Mode
+	if !gateway.ModeSupported(mode) {
+		return fmt.Errorf("mode %s is not supported by the Istio API of the operator", mode)
+	}
+	if r.dynamicClient == nil {
+		return nil
+	}
+	// The CRD is served as v1 from Kubernetes 1.16, older clusters only serve v1beta1.
+	for _, version := range []string{"v1", "v1beta1"} {
+		resource := schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: version, Resource: "customresourcedefinitions"}
+		crd, err := r.dynamicClient.Resource(resource).Get(gateway.CRDName, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			log.Error(err, "Failed to read gateway CRD, the mode is assumed to be supported", "CRD.Name", gateway.CRDName)
+			return nil
+		}
+		modes := gateway.CRDModes(crd)
+		if modes == nil {
+			return nil
+		}
+		for _, m := range modes {
+			if m == mode {
+				return nil
+			}
+		}
+		return fmt.Errorf("mode %s is not supported by the connected Istio, supported modes are %s", mode, strings.Join(modes, ", "))
+	}
+	return nil
+}
+
+// permanentError is an error which can only be resolved by changing the GatewayService spec, so the GatewayService is
+// not requeued.
+type permanentError struct {
+	error
+}
+
+// permanent reports whether the validation error is caused by the GatewayService spec. Errors from the API server and
+// secrets which are yet to be created resolve without changing the GatewayService.
+func permanent(err error) bool {
+	if _, ok := err.(errors.APIStatus); ok {
+		return false
+	}
+	return !provider.IsMissingSecret(err)
+}
+
+// providerConfig returns the configuration passed to the certificate provider of the GatewayService. The secret is
+// created within the first of the namespaces and copied into the others.
+func (r *ReconcileGatewayService) providerConfig(gatewayservice *appv1alpha1.GatewayService, namespaces []string, cfg config.Config) provider.ProviderConfig {
+	c := provider.ProviderConfig{
+		Client:         r.client,
+		Scheme:         r.scheme,
+		GatewayService: gatewayservice,
+		Mode:           gateway.TlsMode(gatewayservice.Spec.Mode),
+		Hosts:          host.Names(gatewayservice.Status.Hosts),
+		Vault:          cfg.Vault,
+	}
+	if len(namespaces) > 0 {
+		c.SecretNamespace = namespaces[0]
+		c.CopyNamespaces = namespaces[1:]
+	}
+	return c
+}
+
+// gatewayRequests maps a Gateway to every GatewayService it renders, as returned by index.Rendered.
+func gatewayRequests(c client.Client) func(handler.MapObject) []reconcile.Request {
+	return func(obj handler.MapObject) []reconcile.Request {
+		gatewayservices, err := index.Rendered(c, config.Load(c), obj.Meta.GetNamespace(), obj.Meta.GetName())
+		if err != nil {
+			log.Error(err, "Failed to list GatewayServices targeting gateway", "Gateway.Namespace", obj.Meta.GetNamespace(), "Gateway.Name", obj.Meta.GetName())
+			return nil
+		}
+		requests := []reconcile.Request{}
+		for _, gs := range gatewayservices.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: gs.Name, Namespace: gs.Namespace}})
+		}
+		return requests
+	}
+}
+
+// configRequests maps the operator config to every GatewayService.
+func configRequests(c client.Client) func(handler.MapObject) []reconcile.Request {
+	return func(obj handler.MapObject) []reconcile.Request {
+		return gatewayServiceRequests(c, &client.ListOptions{})
+	}
+}
+
+// namespaceRequests maps a namespace to every GatewayService within it, as host templates may refer to namespace labels.
+func namespaceRequests(c client.Client) func(handler.MapObject) []reconcile.Request {
+	return func(obj handler.MapObject) []reconcile.Request {
+		return gatewayServiceRequests(c, &client.ListOptions{Namespace: obj.Meta.GetName()})
+	}
+}
+
+// gatewayServiceRequests returns a request for every GatewayService listed.
+func gatewayServiceRequests(c client.Client, opts *client.ListOptions) []reconcile.Request {
+	gatewayservices := &appv1alpha1.GatewayServiceList{}
+	err := c.List(context.TODO(), opts, gatewayservices)
+	if err != nil {
+		log.Error(err, "Failed to list GatewayServices")
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, gs := range gatewayservices.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: gs.Name, Namespace: gs.Namespace}})
+	}
+	return requests
+}
+
+// secretRequests maps a secret to the GatewayServices which reference it, using the secretName index, and to the
+// GatewayService it was created for.
+func secretRequests(c client.Client) func(handler.MapObject) []reconcile.Request {
+	return func(obj handler.MapObject) []reconcile.Request {
+		requests := secretOwnerRequests(obj)
+		gatewayservices, err := index.List(c, "", index.SecretNameField, obj.Meta.GetName())
+		if err != nil {
+			log.Error(err, "Failed to list GatewayServices referencing secret", "Secret.Namespace", obj.Meta.GetNamespace(), "Secret.Name", obj.Meta.GetName())
+			return requests
+		}
+		for i := range gatewayservices.Items {
+			gs := &gatewayservices.Items[i]
+			// PASSTHROUGH secrets are referenced from the namespace of the GatewayService, other secrets from any of the
+			// namespaces serving the Gateway.
+			if passthrough(gs) && gs.Namespace != obj.Meta.GetNamespace() {
+				continue
+			}
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: gs.Name, Namespace: gs.Namespace}})
+		}
+		return requests
+	}
+}
+
+// secretOwnerRequests maps a secret created by the operator back to the GatewayService it was created for.
+func secretOwnerRequests(obj handler.MapObject) []reconcile.Request {
+	labels := obj.Meta.GetLabels()
+	if labels[secret.ManagedByLabel] != secret.ManagedBy {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: secret.Owner(obj.Meta)}}
+}
+
+// secretNamespaces returns the namespaces holding the secret of the GatewayService, the first of which the secret is
+// created within or referenced from. Secrets which are no longer required after a Mode change are removed by
+// SweepSecrets.
 // https://github.com/xUnholy/k8s-istio-gateway-service-operator/issues/16
-func secretNamespace(gs *appv1alpha1.GatewayService) string {
-	if gateway.TlsMode(gs.Spec.Mode) == networkv3.Server_TLSOptions_PASSTHROUGH {
-		return gs.Namespace
+func (r *ReconcileGatewayService) secretNamespaces(request reconcile.Request, gs *appv1alpha1.GatewayService, cfg config.Config) ([]string, error) {
+	if passthrough(gs) {
+		return []string{gs.Namespace}, nil
+	}
+	// SIMPLE, MUTUAL and OPTIONAL_MUTUAL result in the secrets being created and/or referenced in the namespaces the
+	// gateway pods are running within.
+	gatewayObj := &v1alpha3.Gateway{}
+	err := r.client.Get(context.TODO(), gatewaycontroller.Key(cfg, request.Namespace, gs.Spec.TrafficType), gatewayObj)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+		gatewayObj = nil
+	}
+	return r.gatewayNamespaces(gs.Spec.TrafficType, gatewayObj, cfg)
+}
+
+// gatewayNamespaces returns the namespaces the gateway pods selected by the Gateway are running within, sorted.
+// Namespaces configured for the trafficType by the operator config take precedence, and the secretNamespace of the
+// traffic class is assumed when no gateway pods are found.
+func (r *ReconcileGatewayService) gatewayNamespaces(trafficType string, gatewayObj *v1alpha3.Gateway, cfg config.Config) ([]string, error) {
+	if configured := cfg.Namespaces(trafficType); len(configured) > 0 {
+		return configured, nil
+	}
+	if gatewayObj != nil && len(gatewayObj.Spec.Selector) > 0 && r.kubeClient != nil {
+		pods, err := r.kubeClient.CoreV1().Pods(metav1.NamespaceAll).List(metav1.ListOptions{
+			LabelSelector: labels.SelectorFromSet(gatewayObj.Spec.Selector).String(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if namespaces := gateway.PodNamespaces(pods.Items); len(namespaces) > 0 {
+			return namespaces, nil
+		}
+	}
+	return []string{cfg.TrafficSecretNamespace(trafficType)}, nil
+}
+
+// passthrough reports whether the GatewayService uses PASSTHROUGH mode, where secrets are handled by the application.
+func passthrough(gs *appv1alpha1.GatewayService) bool {
+	return gateway.TlsMode(gs.Spec.Mode) == networkv3.Server_TLSOptions_PASSTHROUGH
+}
+
+// mountsCredential reports whether the credential is mounted within the gateway pods rather than fetched using SDS.
+func mountsCredential(credential *provider.Credential) bool {
+	return credential != nil && (credential.CertPath != "" || credential.KeyPath != "")
+}
+
+// operatorConfig returns the configuration applied by the operator.
+func (r *ReconcileGatewayService) operatorConfig() config.Config {
+	return config.Load(r.client)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
 	}
-	// Both SIMPLE and MUTUAL result in the secrets being created and/or referenced in the namespace istio is running
-	return "istio-system"
+	return false
 }
 
-func getEnv(k string, d string) string {
-	if v, e := os.LookupEnv(k); e {
-		return v
+// minRequeue returns the shortest non-zero duration.
+func minRequeue(a time.Duration, b time.Duration) time.Duration {
+	if a == 0 || (b > 0 && b < a) {
+		return b
 	}
-	return d
+	return a
 }
@@ -2,21 +2,55 @@ package gatewayservice
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 	"unicode/utf8"
 
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/config"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/finalizer"
+	g "github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/gateway"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/names"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/rollout"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/secret"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/status"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/vault"
 	appv1alpha1 "github.com/xunholy/k8s-istio-gateway-service-operator/pkg/apis/crd/v1alpha1"
+	gatewaycontroller "github.com/xunholy/k8s-istio-gateway-service-operator/pkg/controller/gateway"
 	"k8s.io/client-go/kubernetes/scheme"
 
 	networkv3 "istio.io/api/networking/v1alpha3"
 	"istio.io/client-go/pkg/apis/networking/v1alpha3"
 
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -124,9 +158,9 @@ func TestTLSSecretRefInvalidSecret(t *testing.T) {
 	if err == nil {
 		t.Fatalf("Expected failure due to TLSSecretRef not found (%v)", err)
 	}
-	// Check the result of reconciliation to make sure it has the desired state.
-	if !res.Requeue {
-		t.Error("reconcile did not requeue request as expected")
+	// Check the result of reconciliation, the Controller retries transient failures with backoff.
+	if res.Requeue || res.RequeueAfter != 0 {
+		t.Error("reconcile requeued request without backoff")
 	}
 }
 
@@ -170,12 +204,12 @@ func TestNoTLSOption(t *testing.T) {
 		},
 	}
 	res, err := r.Reconcile(req)
-	if err == nil {
-		t.Fatalf("Expected failure due to TLSOption not found (%v)", err)
+	if err != nil {
+		t.Fatalf("expected invalid spec to be a permanent failure (%v)", err)
 	}
 	// Check the result of reconciliation to make sure it has the desired state.
-	if !res.Requeue {
-		t.Error("reconcile did not requeue request as expected")
+	if res.Requeue || res.RequeueAfter != 0 {
+		t.Error("reconcile requeued request for a permanent failure")
 	}
 }
 
@@ -266,8 +300,8 @@ func TestCertAndKey(t *testing.T) {
 		t.Fatalf("reconcile: (%v)", err)
 	}
 	// Check the result of reconciliation to make sure it has the desired state.
-	if !res.Requeue {
-		t.Error("reconcile did not requeue request as expected")
+	if res.RequeueAfter <= 0 {
+		t.Error("reconcile did not schedule a resync as expected")
 	}
 	// Check if gatewayservice has been created.
 	gatewayservice = &appv1alpha1.GatewayService{}
@@ -275,6 +309,11 @@ func TestCertAndKey(t *testing.T) {
 	if err != nil {
 		t.Fatalf("get GatewayService: (%v)", err)
 	}
+	// The cert and key are left out of the last-known-good spec, the secret created from them is rendered instead.
+	valid := gatewayservice.Status.ValidSpec
+	if valid == nil || valid.TLSOptions == nil || !reflect.DeepEqual(valid.TLSOptions.TLSSecret, &appv1alpha1.TLSSecret{}) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", appv1alpha1.TLSSecret{}, valid)
+	}
 }
 
 func TestCertAndNoKey(t *testing.T) {
@@ -322,12 +361,12 @@ func TestCertAndNoKey(t *testing.T) {
 		},
 	}
 	res, err := r.Reconcile(req)
-	if err == nil {
-		t.Fatalf("expected failue due to missing cert and/or key")
+	if err != nil {
+		t.Fatalf("expected invalid spec to be a permanent failure (%v)", err)
 	}
 	// Check the result of reconciliation to make sure it has the desired state.
-	if !res.Requeue {
-		t.Error("reconcile did not requeue request as expected")
+	if res.Requeue || res.RequeueAfter != 0 {
+		t.Error("reconcile requeued request for a permanent failure")
 	}
 	// Check if gatewayservice has been created.
 	gatewayservice = &appv1alpha1.GatewayService{}
@@ -382,12 +421,12 @@ func TestNoCertAndKey(t *testing.T) {
 		},
 	}
 	res, err := r.Reconcile(req)
-	if err == nil {
-		t.Fatalf("expected failue due to missing cert and/or key")
+	if err != nil {
+		t.Fatalf("expected invalid spec to be a permanent failure (%v)", err)
 	}
 	// Check the result of reconciliation to make sure it has the desired state.
-	if !res.Requeue {
-		t.Error("reconcile did not requeue request as expected")
+	if res.Requeue || res.RequeueAfter != 0 {
+		t.Error("reconcile requeued request for a permanent failure")
 	}
 	// Check if gatewayservice has been created.
 	gatewayservice = &appv1alpha1.GatewayService{}
@@ -440,12 +479,12 @@ func TestNoCertAndNoKey(t *testing.T) {
 		},
 	}
 	res, err := r.Reconcile(req)
-	if err == nil {
-		t.Fatalf("expected failue due to missing cert and/or key")
+	if err != nil {
+		t.Fatalf("expected invalid spec to be a permanent failure (%v)", err)
 	}
 	// Check the result of reconciliation to make sure it has the desired state.
-	if !res.Requeue {
-		t.Error("reconcile did not requeue request as expected")
+	if res.Requeue || res.RequeueAfter != 0 {
+		t.Error("reconcile requeued request for a permanent failure")
 	}
 	// Check if gatewayservice has been created.
 	gatewayservice = &appv1alpha1.GatewayService{}
@@ -529,11 +568,11 @@ func TestCertAndKeyWithSecretRef(t *testing.T) {
 	}
 	res, err := r.Reconcile(req)
 	if err == nil {
-		t.Fatalf("Expected failure due to TLSSecretRef not found (%v)", err)
+		t.Fatalf("Expected failure due to both TLSSecret and TLSSecretRef being set (%v)", err)
 	}
-	// Check the result of reconciliation to make sure it has the desired state.
-	if !res.Requeue {
-		t.Error("reconcile did not requeue request as expected")
+	// Check the result of reconciliation, the Controller retries transient failures with backoff.
+	if res.Requeue || res.RequeueAfter != 0 {
+		t.Error("reconcile requeued request without backoff")
 	}
 	// Check if gatewayservice has been created.
 	gatewayservice = &appv1alpha1.GatewayService{}
@@ -541,6 +580,9 @@ func TestCertAndKeyWithSecretRef(t *testing.T) {
 	if err != nil {
 		t.Fatalf("get GatewayService: (%v)", err)
 	}
+	if !strings.Contains(gatewayservice.Status.Condition.ErrorMessage, "only one of") {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", "only one of", gatewayservice.Status.Condition.ErrorMessage)
+	}
 }
 
 func TestIncorrectCertAndKeyEncoding(t *testing.T) {
@@ -591,12 +633,12 @@ func TestIncorrectCertAndKeyEncoding(t *testing.T) {
 		},
 	}
 	res, err := r.Reconcile(req)
-	if err == nil {
-		t.Fatalf("expected failue due to invalid cert encoding")
+	if err != nil {
+		t.Fatalf("expected invalid spec to be a permanent failure (%v)", err)
 	}
 	// Check the result of reconciliation to make sure it has the desired state.
-	if !res.Requeue {
-		t.Error("reconcile did not requeue request as expected")
+	if res.Requeue || res.RequeueAfter != 0 {
+		t.Error("reconcile requeued request for a permanent failure")
 	}
 	// Check if gatewayservice has been created.
 	gatewayservice = &appv1alpha1.GatewayService{}
@@ -680,8 +722,8 @@ func TestGatewayServiceControllerReconciler_Simple(t *testing.T) {
 		t.Fatalf("reconcile: (%v)", err)
 	}
 	// Check the result of reconciliation to make sure it has the desired state.
-	if !res.Requeue {
-		t.Error("reconcile did not requeue request as expected")
+	if res.RequeueAfter <= 0 {
+		t.Error("reconcile did not schedule a resync as expected")
 	}
 	// Check if gatewayservice has been created.
 	gatewayservice = &appv1alpha1.GatewayService{}
@@ -741,8 +783,8 @@ func TestGatewayServiceControllerReconciler_Passthrough(t *testing.T) {
 		t.Fatalf("reconcile: (%v)", err)
 	}
 	// Check the result of reconciliation to make sure it has the desired state.
-	if !res.Requeue {
-		t.Error("reconcile did not requeue request as expected")
+	if res.RequeueAfter <= 0 {
+		t.Error("reconcile did not schedule a resync as expected")
 	}
 	// Check if gatewayservice has been created.
 	gatewayservice = &appv1alpha1.GatewayService{}
@@ -751,3 +793,2305 @@ func TestGatewayServiceControllerReconciler_Passthrough(t *testing.T) {
 		t.Fatalf("get GatewayService: (%v)", err)
 	}
 }
+
+func TestFinalizerAdded(t *testing.T) {
+	// A TestGatewayService resource with metadata and spec.
+	gatewayservice := &appv1alpha1.GatewayService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: appv1alpha1.GatewayServiceSpec{
+			Hosts:       []string{"*"},
+			Mode:        "SIMPLE",
+			Port:        80,
+			Protocol:    "HTTPS",
+			TrafficType: "ingress",
+			TLSOptions: &appv1alpha1.TLSOptions{
+				TLSSecret: &appv1alpha1.TLSSecret{
+					Cert: &cert,
+					Key:  &key,
+				},
+			},
+		},
+	}
+
+	gateway := &v1alpha3.Gateway{}
+
+	// Objects to track in the fake client.
+	objs := []runtime.Object{gatewayservice}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, gateway, gatewayservice)
+
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClient(objs...)
+
+	// Create a ReconcileMemcached object with the scheme and fake client.
+	r := &ReconcileGatewayService{client: cl, scheme: s}
+
+	// Mock request to simulate Reconcile() being called on an event for a
+	// watched resource.
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	gatewayservice = &appv1alpha1.GatewayService{}
+	err = r.client.Get(context.TODO(), req.NamespacedName, gatewayservice)
+	if err != nil {
+		t.Fatalf("get GatewayService: (%v)", err)
+	}
+	if !finalizer.Has(gatewayservice, gatewayServiceFinalizer) {
+		t.Errorf("expected finalizer %s to be added", gatewayServiceFinalizer)
+	}
+}
+
+func TestFinalizerCleanup(t *testing.T) {
+	deletionTimestamp := metav1.Now()
+	// A TestGatewayService resource pending deletion.
+	gatewayservice := &appv1alpha1.GatewayService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         namespace,
+			DeletionTimestamp: &deletionTimestamp,
+			Finalizers:        []string{gatewayServiceFinalizer},
+		},
+		Spec: appv1alpha1.GatewayServiceSpec{
+			Hosts:       []string{"*"},
+			Mode:        "SIMPLE",
+			Port:        443,
+			Protocol:    "HTTPS",
+			TrafficType: "ingress",
+			TLSOptions: &appv1alpha1.TLSOptions{
+				TLSSecret: &appv1alpha1.TLSSecret{
+					Cert: &cert,
+					Key:  &key,
+				},
+			},
+		},
+	}
+
+	gateway := &v1alpha3.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-ingress-gateway", namespace),
+			Namespace: namespace,
+		},
+		Spec: networkv3.Gateway{
+			Servers: []*networkv3.Server{
+				{
+					Port: &networkv3.Port{
+						Name:     fmt.Sprintf("https-%s-%s", name, namespace),
+						Number:   443,
+						Protocol: "HTTPS",
+					},
+					Hosts: []string{"*"},
+					Tls: &networkv3.Server_TLSOptions{
+						Mode:           networkv3.Server_TLSOptions_SIMPLE,
+						CredentialName: fmt.Sprintf("%s-%s-secret", name, namespace),
+					},
+				},
+			},
+		},
+	}
+
+	secretObj := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s-secret", name, namespace),
+			Namespace: config.DefaultSecretNamespace,
+			Labels:    secret.Labels(gatewayservice),
+		},
+	}
+
+	// Objects to track in the fake client.
+	objs := []runtime.Object{gatewayservice, gateway, secretObj}
+
+	// List GatewayService objects filtering by labels
+	gatewayservicesList := &appv1alpha1.GatewayServiceList{}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, gateway, gatewayservice, gatewayservicesList)
+
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClient(objs...)
+
+	// Create a ReconcileMemcached object with the scheme and fake client.
+	r := &ReconcileGatewayService{client: cl, scheme: s}
+
+	// Mock request to simulate Reconcile() being called on an event for a
+	// watched resource.
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	res, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if res.Requeue {
+		t.Error("reconcile should not requeue request once finalized")
+	}
+	// Check the secret created in istio-system has been removed.
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: secretObj.Name, Namespace: secretObj.Namespace}, &corev1.Secret{})
+	if !errors.IsNotFound(err) {
+		t.Errorf("expected secret %s/%s to be deleted: (%v)", secretObj.Namespace, secretObj.Name, err)
+	}
+	// Check the server has been removed from the Gateway.
+	gateway = &v1alpha3.Gateway{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: fmt.Sprintf("%s-ingress-gateway", namespace), Namespace: namespace}, gateway)
+	if err != nil {
+		t.Fatalf("get Gateway: (%v)", err)
+	}
+	for _, server := range gateway.Spec.Servers {
+		if server.Port.Name == fmt.Sprintf("https-%s-%s", name, namespace) {
+			t.Errorf("expected server %s to be removed from the Gateway", server.Port.Name)
+		}
+	}
+	// Check the finalizer has been released.
+	gatewayservice = &appv1alpha1.GatewayService{}
+	err = r.client.Get(context.TODO(), req.NamespacedName, gatewayservice)
+	if err != nil {
+		t.Fatalf("get GatewayService: (%v)", err)
+	}
+	if finalizer.Has(gatewayservice, gatewayServiceFinalizer) {
+		t.Errorf("expected finalizer %s to be removed", gatewayServiceFinalizer)
+	}
+}
+
+func TestSweepSecretsModeChange(t *testing.T) {
+	// A TestGatewayService resource which has changed from SIMPLE to PASSTHROUGH.
+	gatewayservice := &appv1alpha1.GatewayService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Namespace:  namespace,
+			Finalizers: []string{gatewayServiceFinalizer},
+		},
+		Spec: appv1alpha1.GatewayServiceSpec{
+			Hosts:       []string{"*"},
+			Mode:        "PASSTHROUGH",
+			Port:        443,
+			Protocol:    "HTTPS",
+			TrafficType: "ingress",
+			TLSOptions: &appv1alpha1.TLSOptions{
+				TLSSecret: &appv1alpha1.TLSSecret{
+					Cert: &cert,
+					Key:  &key,
+				},
+			},
+		},
+	}
+
+	gateway := &v1alpha3.Gateway{}
+
+	// Secret previously created in istio-system while the GatewayService was SIMPLE.
+	staleSecretObj := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s-secret", name, namespace),
+			Namespace: config.DefaultSecretNamespace,
+			Labels:    secret.Labels(gatewayservice),
+		},
+	}
+
+	// Secret not created by the operator which must be left untouched.
+	unmanagedSecretObj := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "unmanaged-secret",
+			Namespace: config.DefaultSecretNamespace,
+		},
+	}
+
+	// Objects to track in the fake client.
+	objs := []runtime.Object{gatewayservice, staleSecretObj, unmanagedSecretObj}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, gateway, gatewayservice)
+
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClient(objs...)
+
+	// Create a ReconcileMemcached object with the scheme and fake client.
+	r := &ReconcileGatewayService{client: cl, scheme: s}
+
+	// Mock request to simulate Reconcile() being called on an event for a
+	// watched resource.
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	// Check the stale secret in istio-system has been removed.
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: staleSecretObj.Name, Namespace: staleSecretObj.Namespace}, &corev1.Secret{})
+	if !errors.IsNotFound(err) {
+		t.Errorf("expected secret %s/%s to be deleted: (%v)", staleSecretObj.Namespace, staleSecretObj.Name, err)
+	}
+	// Check the secret has been created in the GatewayService namespace with the standard labels.
+	secretObj := &corev1.Secret{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: names.Secret(name, namespace), Namespace: namespace}, secretObj)
+	if err != nil {
+		t.Fatalf("get Secret: (%v)", err)
+	}
+	if !secret.HasLabels(secretObj.Labels, secret.Labels(gatewayservice)) {
+		t.Errorf("expected secret labels (%+v) to contain (%+v)", secretObj.Labels, secret.Labels(gatewayservice))
+	}
+	// Check the unmanaged secret has been left untouched.
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: unmanagedSecretObj.Name, Namespace: unmanagedSecretObj.Namespace}, &corev1.Secret{})
+	if err != nil {
+		t.Errorf("expected secret %s/%s to be left untouched: (%v)", unmanagedSecretObj.Namespace, unmanagedSecretObj.Name, err)
+	}
+}
+
+func generateCertificate(t *testing.T, notAfter time.Time) string {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: (%v)", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatalf("create certificate: (%v)", err)
+	}
+	return base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func TestCertificateExpiring(t *testing.T) {
+	expiringCert := generateCertificate(t, time.Now().Add(12*time.Hour))
+	// A TestGatewayService resource with metadata and spec.
+	gatewayservice := &appv1alpha1.GatewayService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: appv1alpha1.GatewayServiceSpec{
+			Hosts:       []string{"*"},
+			Mode:        "SIMPLE",
+			Port:        443,
+			Protocol:    "HTTPS",
+			TrafficType: "ingress",
+			TLSOptions: &appv1alpha1.TLSOptions{
+				TLSSecret: &appv1alpha1.TLSSecret{
+					Cert: &expiringCert,
+					Key:  &key,
+				},
+			},
+		},
+	}
+
+	gateway := &v1alpha3.Gateway{}
+
+	// Objects to track in the fake client.
+	objs := []runtime.Object{gatewayservice}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, gateway, gatewayservice)
+
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClient(objs...)
+
+	// Create a fake recorder to capture events.
+	recorder := record.NewFakeRecorder(10)
+
+	// Create a ReconcileMemcached object with the scheme and fake client.
+	r := &ReconcileGatewayService{client: cl, scheme: s, recorder: recorder}
+
+	// Mock request to simulate Reconcile() being called on an event for a
+	// watched resource.
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	res, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	// The certificate expires within the 24h threshold, the next reconcile is scheduled for the expiry itself.
+	if res.RequeueAfter <= 0 || res.RequeueAfter > 12*time.Hour {
+		t.Errorf("expected reconcile to be scheduled before the certificate expires, found (%v)", res.RequeueAfter)
+	}
+	gatewayservice = &appv1alpha1.GatewayService{}
+	err = r.client.Get(context.TODO(), req.NamespacedName, gatewayservice)
+	if err != nil {
+		t.Fatalf("get GatewayService: (%v)", err)
+	}
+	condition := status.FindCondition(gatewayservice.Status.Conditions, appv1alpha1.CertificateExpiring)
+	if condition == nil || condition.Status != corev1.ConditionTrue {
+		t.Fatalf("expected CertificateExpiring condition to be true, found (%+v)", condition)
+	}
+	select {
+	case event := <-recorder.Events:
+		if !strings.HasPrefix(event, corev1.EventTypeWarning) {
+			t.Errorf("expected a Warning event, found (%s)", event)
+		}
+	default:
+		t.Errorf("expected a Warning event to be recorded")
+	}
+
+	// Reconciling again within the same threshold must not raise another event.
+	_, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	select {
+	case event := <-recorder.Events:
+		t.Errorf("expected no further events, found (%s)", event)
+	default:
+	}
+}
+
+func TestCertificateExpiringPassthrough(t *testing.T) {
+	expiringCert, err := base64.StdEncoding.DecodeString(generateCertificate(t, time.Now().Add(12*time.Hour)))
+	if err != nil {
+		t.Fatalf("decode certificate: (%v)", err)
+	}
+	// A TestGatewayService resource referencing the secret of an application which terminates TLS itself.
+	gatewayservice := &appv1alpha1.GatewayService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: appv1alpha1.GatewayServiceSpec{
+			Hosts:       []string{"*"},
+			Mode:        "PASSTHROUGH",
+			Port:        443,
+			Protocol:    "TLS",
+			TrafficType: "ingress",
+			TLSOptions: &appv1alpha1.TLSOptions{
+				TLSSecretRef: &appv1alpha1.TLSSecretRef{
+					SecretName: "application-certs",
+				},
+			},
+		},
+	}
+	secretObj := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "application-certs",
+			Namespace: namespace,
+		},
+		Data: map[string][]byte{
+			"tls.crt": expiringCert,
+			"tls.key": pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: []byte("key")}),
+		},
+	}
+
+	gateway := &v1alpha3.Gateway{}
+
+	// Objects to track in the fake client.
+	objs := []runtime.Object{gatewayservice, secretObj}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, gateway, gatewayservice)
+
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClient(objs...)
+
+	// Create a fake recorder to capture events.
+	recorder := record.NewFakeRecorder(10)
+
+	// Create a ReconcileMemcached object with the scheme and fake client.
+	r := &ReconcileGatewayService{client: cl, scheme: s, recorder: recorder}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	_, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	gatewayservice = &appv1alpha1.GatewayService{}
+	err = r.client.Get(context.TODO(), req.NamespacedName, gatewayservice)
+	if err != nil {
+		t.Fatalf("get GatewayService: (%v)", err)
+	}
+	// The secret is not served by the Gateway, but its certificate is still inspected.
+	condition := status.FindCondition(gatewayservice.Status.Conditions, appv1alpha1.CertificateExpiring)
+	if condition == nil || condition.Status != corev1.ConditionTrue {
+		t.Fatalf("expected CertificateExpiring condition to be true, found (%+v)", condition)
+	}
+}
+
+func TestVaultSecret(t *testing.T) {
+	vaultCert, err := base64.StdEncoding.DecodeString(generateCertificate(t, time.Now().Add(90*24*time.Hour)))
+	if err != nil {
+		t.Fatalf("decode certificate: (%v)", err)
+	}
+	// Start an HTTP fake of the Vault API serving Kubernetes auth and a KV version 2 secret.
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/auth/kubernetes/login", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"auth": map[string]interface{}{"client_token": "s.vault-token"}})
+	})
+	mux.HandleFunc("/v1/secret/data/example", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"data": map[string]interface{}{"tls.crt": string(vaultCert), "tls.key": "vault-key"},
+			},
+		})
+	})
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+
+	tokenFile, err := ioutil.TempFile("", "token")
+	if err != nil {
+		t.Fatalf("create token: (%v)", err)
+	}
+	defer os.Remove(tokenFile.Name())
+	_, _ = tokenFile.WriteString("service-account-token")
+	tokenFile.Close()
+	tokenPath := vault.TokenPath
+	vault.TokenPath = tokenFile.Name()
+	defer func() { vault.TokenPath = tokenPath }()
+
+	// A TestGatewayService resource with metadata and spec.
+	gatewayservice := &appv1alpha1.GatewayService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: appv1alpha1.GatewayServiceSpec{
+			Hosts:       []string{"*"},
+			Mode:        "SIMPLE",
+			Port:        443,
+			Protocol:    "HTTPS",
+			TrafficType: "ingress",
+			TLSOptions: &appv1alpha1.TLSOptions{
+				Vault: &appv1alpha1.Vault{
+					KV: &appv1alpha1.VaultKV{Path: "secret/data/example"},
+				},
+			},
+		},
+	}
+
+	gateway := &v1alpha3.Gateway{}
+	operatorConfig := &appv1alpha1.GatewayServiceOperatorConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: config.Name,
+		},
+	}
+
+	// Objects to track in the fake client.
+	objs := []runtime.Object{gatewayservice, operatorConfig}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, gateway, gatewayservice,
+		operatorConfig, &appv1alpha1.GatewayServiceOperatorConfigList{})
+
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClient(objs...)
+
+	// Create a ReconcileMemcached object with the scheme and fake client.
+	r := &ReconcileGatewayService{client: cl, scheme: s}
+
+	// Mock request to simulate Reconcile() being called on an event for a
+	// watched resource.
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	// The Vault server is only taken from the operator config, which must allow the path for the namespace.
+	_, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	found := &appv1alpha1.GatewayService{}
+	err = r.client.Get(context.TODO(), req.NamespacedName, found)
+	if err != nil {
+		t.Fatalf("get GatewayService: (%v)", err)
+	}
+	expected := "vault is not configured by the operator config"
+	if found.Status.Condition.Success || found.Status.Condition.ErrorMessage != expected {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, found.Status.Condition)
+	}
+	operatorConfig.Spec.Vault = &appv1alpha1.VaultConfig{
+		Address: server.URL,
+		CACert:  string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})),
+		Auth:    appv1alpha1.VaultKubernetesAuth{Role: "gatewayservice"},
+		Namespaces: []appv1alpha1.VaultNamespace{
+			{Name: namespace, KVPathPrefixes: []string{"secret/data/other"}},
+		},
+	}
+	err = r.client.Update(context.TODO(), operatorConfig)
+	if err != nil {
+		t.Fatalf("update GatewayServiceOperatorConfig: (%v)", err)
+	}
+	_, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	err = r.client.Get(context.TODO(), req.NamespacedName, found)
+	if err != nil {
+		t.Fatalf("get GatewayService: (%v)", err)
+	}
+	expected = "vault kv path secret/data/example is not allowed for namespace application by the operator config"
+	if found.Status.Condition.Success || found.Status.Condition.ErrorMessage != expected {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, found.Status.Condition)
+	}
+	operatorConfig.Spec.Vault.Namespaces[0].KVPathPrefixes = []string{"secret/data/example"}
+	err = r.client.Update(context.TODO(), operatorConfig)
+	if err != nil {
+		t.Fatalf("update GatewayServiceOperatorConfig: (%v)", err)
+	}
+
+	res, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	// KV version 2 secrets are not leased, the credential is refreshed after the default interval.
+	if res.RequeueAfter <= 0 || res.RequeueAfter > vault.DefaultRefreshInterval {
+		t.Errorf("expected reconcile to be scheduled for the vault refresh, found (%v)", res.RequeueAfter)
+	}
+	secretObj := &corev1.Secret{}
+	key := types.NamespacedName{Name: names.Secret(name, namespace), Namespace: config.DefaultSecretNamespace}
+	err = r.client.Get(context.TODO(), key, secretObj)
+	if err != nil {
+		t.Fatalf("get secret: (%v)", err)
+	}
+	if string(secretObj.Data["tls.crt"]) != string(vaultCert) || string(secretObj.Data["tls.key"]) != "vault-key" {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", string(vaultCert), string(secretObj.Data["tls.crt"]))
+	}
+	if secretObj.Annotations[vault.HashAnnotation] == "" || secretObj.Annotations[vault.RefreshAtAnnotation] == "" {
+		t.Fatalf("expected vault annotations on secret, found (%+v)", secretObj.Annotations)
+	}
+
+	// The credential is not due for refresh, reconciling again must not contact Vault.
+	server.Close()
+	_, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+}
+
+func TestVaultPKISecret(t *testing.T) {
+	vaultCert, err := base64.StdEncoding.DecodeString(generateCertificate(t, time.Now().Add(90*24*time.Hour)))
+	if err != nil {
+		t.Fatalf("decode certificate: (%v)", err)
+	}
+	// Start an HTTP fake of the Vault API serving Kubernetes auth and a PKI role.
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/auth/kubernetes/login", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"auth": map[string]interface{}{"client_token": "s.vault-token"}})
+	})
+	mux.HandleFunc("/v1/pki/issue/example", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"certificate": string(vaultCert),
+				"private_key": "vault-key",
+				"issuing_ca":  "vault-ca",
+			},
+		})
+	})
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+
+	tokenFile, err := ioutil.TempFile("", "token")
+	if err != nil {
+		t.Fatalf("create token: (%v)", err)
+	}
+	defer os.Remove(tokenFile.Name())
+	_, _ = tokenFile.WriteString("service-account-token")
+	tokenFile.Close()
+	tokenPath := vault.TokenPath
+	vault.TokenPath = tokenFile.Name()
+	defer func() { vault.TokenPath = tokenPath }()
+
+	// A TestGatewayService resource with metadata and spec.
+	gatewayservice := &appv1alpha1.GatewayService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: appv1alpha1.GatewayServiceSpec{
+			Hosts:       []string{"*"},
+			Mode:        "MUTUAL",
+			Port:        443,
+			Protocol:    "HTTPS",
+			TrafficType: "ingress",
+			TLSOptions: &appv1alpha1.TLSOptions{
+				Vault: &appv1alpha1.Vault{
+					PKI: &appv1alpha1.VaultPKI{Role: "example", CommonName: "example.com"},
+				},
+			},
+		},
+	}
+	// A secret sourced by a previous version of the operator, which stored the issuing CA as ca.crt.
+	secretObj := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      names.Secret(name, namespace),
+			Namespace: config.DefaultSecretNamespace,
+			Labels:    secret.Labels(gatewayservice),
+		},
+		Data: map[string][]byte{"tls.crt": vaultCert, "tls.key": []byte("vault-key"), "ca.crt": []byte("vault-ca")},
+	}
+	operatorConfig := &appv1alpha1.GatewayServiceOperatorConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: config.Name,
+		},
+		Spec: appv1alpha1.GatewayServiceOperatorConfigSpec{
+			Vault: &appv1alpha1.VaultConfig{
+				Address: server.URL,
+				CACert:  string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})),
+				Auth:    appv1alpha1.VaultKubernetesAuth{Role: "gatewayservice"},
+				Namespaces: []appv1alpha1.VaultNamespace{
+					{Name: namespace, PKIRoles: []string{"pki/example"}},
+				},
+			},
+		},
+	}
+
+	// Objects to track in the fake client.
+	objs := []runtime.Object{gatewayservice, secretObj, operatorConfig}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, &v1alpha3.Gateway{}, gatewayservice,
+		operatorConfig, &appv1alpha1.GatewayServiceOperatorConfigList{})
+
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClient(objs...)
+
+	// Create a ReconcileMemcached object with the scheme and fake client.
+	r := &ReconcileGatewayService{client: cl, scheme: s}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	_, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	secretObj = &corev1.Secret{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: names.Secret(name, namespace), Namespace: config.DefaultSecretNamespace}, secretObj)
+	if err != nil {
+		t.Fatalf("get secret: (%v)", err)
+	}
+	// The issuing CA is kept apart from ca.crt, which Istio would use to verify client certificates.
+	if string(secretObj.Data[vault.IssuingCAKey]) != "vault-ca" {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", "vault-ca", string(secretObj.Data[vault.IssuingCAKey]))
+	}
+	if _, ok := secretObj.Data["ca.crt"]; ok {
+		t.Fatalf("expected ca.crt to be removed from the secret, found (%+v)", secretObj.Data)
+	}
+}
+
+func TestRolloutTLSSecretPath(t *testing.T) {
+	// A TestGatewayService resource with metadata and spec.
+	gatewayservice := &appv1alpha1.GatewayService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: appv1alpha1.GatewayServiceSpec{
+			Hosts:       []string{"*"},
+			Mode:        "SIMPLE",
+			Port:        443,
+			Protocol:    "HTTPS",
+			TrafficType: "ingress",
+			TLSOptions: &appv1alpha1.TLSOptions{
+				TLSSecretPath: &appv1alpha1.TLSSecretPath{
+					CertPath: "/etc/istio/ingressgateway-certs/tls.crt",
+					KeyPath:  "/etc/istio/ingressgateway-certs/tls.key",
+				},
+			},
+		},
+	}
+
+	gateway := &v1alpha3.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-ingress-gateway", namespace),
+			Namespace: namespace,
+		},
+		Spec: networkv3.Gateway{
+			Selector: map[string]string{"istio": "ingressgateway"},
+		},
+	}
+
+	replicas := int32(2)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "istio-ingressgateway",
+			Namespace: config.DefaultSecretNamespace,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"istio": "ingressgateway"}},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:         "istio-proxy",
+						VolumeMounts: []corev1.VolumeMount{{Name: "ingressgateway-certs", MountPath: "/etc/istio/ingressgateway-certs"}},
+					}},
+					Volumes: []corev1.Volume{{
+						Name:         "ingressgateway-certs",
+						VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "istio-ingressgateway-certs"}},
+					}},
+				},
+			},
+		},
+	}
+
+	mountedSecretObj := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "istio-ingressgateway-certs",
+			Namespace: config.DefaultSecretNamespace,
+		},
+		Data: map[string][]byte{"tls.crt": []byte(cert), "tls.key": []byte(key)},
+	}
+
+	// Objects to track in the fake client.
+	objs := []runtime.Object{gatewayservice, gateway, mountedSecretObj}
+
+	// List GatewayService objects filtering by labels
+	gatewayservicesList := &appv1alpha1.GatewayServiceList{}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, gateway, gatewayservice, gatewayservicesList)
+
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClient(objs...)
+
+	// Create a ReconcileMemcached object with the scheme and fake client.
+	r := &ReconcileGatewayService{client: cl, scheme: s, recorder: record.NewFakeRecorder(10), kubeClient: k8sfake.NewSimpleClientset(deployment)}
+
+	// Mock request to simulate Reconcile() being called on an event for a
+	// watched resource.
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	res, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	// The gateway pods have not been rolled yet, progress is reported again shortly.
+	if res.RequeueAfter != rolloutPollInterval {
+		t.Errorf("Expected: (%+v) \n Found: (%+v)", rolloutPollInterval, res.RequeueAfter)
+	}
+	deployment, err = r.kubeClient.AppsV1().Deployments(config.DefaultSecretNamespace).Get("istio-ingressgateway", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get Deployment: (%v)", err)
+	}
+	hash := deployment.Spec.Template.Annotations[rollout.HashAnnotation]
+	if hash == "" {
+		t.Fatalf("expected pod template to be annotated with %s", rollout.HashAnnotation)
+	}
+	if deployment.Spec.MinReadySeconds != 30 {
+		t.Errorf("Expected: (%+v) \n Found: (%+v)", 30, deployment.Spec.MinReadySeconds)
+	}
+	gatewayservice = &appv1alpha1.GatewayService{}
+	err = r.client.Get(context.TODO(), req.NamespacedName, gatewayservice)
+	if err != nil {
+		t.Fatalf("get GatewayService: (%v)", err)
+	}
+	condition := status.FindCondition(gatewayservice.Status.Conditions, appv1alpha1.GatewayRolledOut)
+	if condition == nil || condition.Status != corev1.ConditionFalse {
+		t.Fatalf("expected GatewayRolledOut condition to be false, found (%+v)", condition)
+	}
+
+	// Once every replica has been updated the rollout is complete.
+	deployment.Status = appsv1.DeploymentStatus{Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2}
+	_, err = r.kubeClient.AppsV1().Deployments(config.DefaultSecretNamespace).UpdateStatus(deployment)
+	if err != nil {
+		t.Fatalf("update Deployment: (%v)", err)
+	}
+	_, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	gatewayservice = &appv1alpha1.GatewayService{}
+	err = r.client.Get(context.TODO(), req.NamespacedName, gatewayservice)
+	if err != nil {
+		t.Fatalf("get GatewayService: (%v)", err)
+	}
+	condition = status.FindCondition(gatewayservice.Status.Conditions, appv1alpha1.GatewayRolledOut)
+	if condition == nil || condition.Status != corev1.ConditionTrue {
+		t.Fatalf("expected GatewayRolledOut condition to be true, found (%+v)", condition)
+	}
+	// The strategy of the Deployment is restored once the rollout is complete.
+	deployment, err = r.kubeClient.AppsV1().Deployments(config.DefaultSecretNamespace).Get("istio-ingressgateway", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get Deployment: (%v)", err)
+	}
+	if _, ok := deployment.Annotations[rollout.StrategyAnnotation]; ok || deployment.Spec.MinReadySeconds != 0 {
+		t.Errorf("expected the strategy of the deployment to be restored, found (%+v)", deployment)
+	}
+
+	// Renewing the mounted certificate rolls the gateway pods again.
+	mountedSecretObj.Data["tls.crt"] = []byte("renewed")
+	err = r.client.Update(context.TODO(), mountedSecretObj)
+	if err != nil {
+		t.Fatalf("update Secret: (%v)", err)
+	}
+	_, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	deployment, err = r.kubeClient.AppsV1().Deployments(config.DefaultSecretNamespace).Get("istio-ingressgateway", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get Deployment: (%v)", err)
+	}
+	if deployment.Spec.Template.Annotations[rollout.HashAnnotation] == hash {
+		t.Errorf("expected pod template hash to change after the mounted certificate was renewed")
+	}
+}
+
+func TestMountTLSSecretPath(t *testing.T) {
+	// A TestGatewayService resource with metadata and spec.
+	gatewayservice := &appv1alpha1.GatewayService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: appv1alpha1.GatewayServiceSpec{
+			Hosts:       []string{"*"},
+			Mode:        "SIMPLE",
+			Port:        443,
+			Protocol:    "HTTPS",
+			TrafficType: "ingress",
+			TLSOptions: &appv1alpha1.TLSOptions{
+				TLSSecretPath: &appv1alpha1.TLSSecretPath{
+					CertPath:   "/etc/certs/example/tls.crt",
+					KeyPath:    "/etc/certs/example/tls.key",
+					SecretName: "example-certs",
+				},
+			},
+		},
+	}
+
+	gateway := &v1alpha3.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-ingress-gateway", namespace),
+			Namespace: namespace,
+		},
+		Spec: networkv3.Gateway{
+			Selector: map[string]string{"istio": "ingressgateway"},
+		},
+	}
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "istio-ingressgateway",
+			Namespace: config.DefaultSecretNamespace,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"istio": "ingressgateway"}},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "istio-proxy", Image: "istio/proxyv2"}},
+				},
+			},
+		},
+	}
+
+	mountedSecretObj := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-certs",
+			Namespace: config.DefaultSecretNamespace,
+		},
+		Data: map[string][]byte{"tls.crt": []byte(cert), "tls.key": []byte(key)},
+	}
+
+	// Objects to track in the fake client.
+	objs := []runtime.Object{gatewayservice, gateway, mountedSecretObj}
+
+	// List GatewayService objects filtering by labels
+	gatewayservicesList := &appv1alpha1.GatewayServiceList{}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, gateway, gatewayservice, gatewayservicesList)
+
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClient(objs...)
+
+	// Create a ReconcileMemcached object with the scheme and fake client.
+	r := &ReconcileGatewayService{client: cl, scheme: s, kubeClient: k8sfake.NewSimpleClientset(deployment)}
+
+	// Mock request to simulate Reconcile() being called on an event for a
+	// watched resource.
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	// The mounts and the rollout are applied as a single patch.
+	patches := 0
+	for _, action := range r.kubeClient.(*k8sfake.Clientset).Actions() {
+		if action.GetVerb() == "patch" {
+			patches++
+		}
+	}
+	if patches != 1 {
+		t.Errorf("Expected: (%+v) \n Found: (%+v)", 1, patches)
+	}
+	deployment, err = r.kubeClient.AppsV1().Deployments(config.DefaultSecretNamespace).Get("istio-ingressgateway", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get Deployment: (%v)", err)
+	}
+	if deployment.Spec.Template.Annotations[rollout.HashAnnotation] == "" {
+		t.Errorf("expected pod template to be annotated with %s", rollout.HashAnnotation)
+	}
+	volumeName := names.Volume(name, namespace)
+	expectedVolumes := []corev1.Volume{
+		{Name: volumeName, VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "example-certs"}}},
+	}
+	if !reflect.DeepEqual(deployment.Spec.Template.Spec.Volumes, expectedVolumes) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expectedVolumes, deployment.Spec.Template.Spec.Volumes)
+	}
+	expectedMounts := []corev1.VolumeMount{{Name: volumeName, MountPath: "/etc/certs/example", ReadOnly: true}}
+	if !reflect.DeepEqual(deployment.Spec.Template.Spec.Containers[0].VolumeMounts, expectedMounts) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expectedMounts, deployment.Spec.Template.Spec.Containers[0].VolumeMounts)
+	}
+	if deployment.Spec.Template.Spec.Containers[0].Image != "istio/proxyv2" {
+		t.Errorf("expected unrelated fields to be left untouched, found (%+v)", deployment.Spec.Template.Spec.Containers[0])
+	}
+
+	// Deleting the GatewayService removes the mount.
+	gatewayservice = &appv1alpha1.GatewayService{}
+	err = r.client.Get(context.TODO(), req.NamespacedName, gatewayservice)
+	if err != nil {
+		t.Fatalf("get GatewayService: (%v)", err)
+	}
+	deletionTimestamp := metav1.Now()
+	gatewayservice.DeletionTimestamp = &deletionTimestamp
+	err = r.client.Update(context.TODO(), gatewayservice)
+	if err != nil {
+		t.Fatalf("update GatewayService: (%v)", err)
+	}
+	_, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	deployment, err = r.kubeClient.AppsV1().Deployments(config.DefaultSecretNamespace).Get("istio-ingressgateway", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get Deployment: (%v)", err)
+	}
+	if len(deployment.Spec.Template.Spec.Volumes) != 0 || len(deployment.Spec.Template.Spec.Containers[0].VolumeMounts) != 0 {
+		t.Fatalf("expected mount to be removed, found (%+v)", deployment.Spec.Template.Spec)
+	}
+}
+
+func TestMountSharedDeployment(t *testing.T) {
+	otherNamespace := "other"
+	newGatewayService := func(namespace string) *appv1alpha1.GatewayService {
+		return &appv1alpha1.GatewayService{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			Spec: appv1alpha1.GatewayServiceSpec{
+				Hosts:       []string{"*"},
+				Mode:        "SIMPLE",
+				Port:        443,
+				Protocol:    "HTTPS",
+				TrafficType: "ingress",
+				TLSOptions: &appv1alpha1.TLSOptions{
+					TLSSecretPath: &appv1alpha1.TLSSecretPath{
+						CertPath:   fmt.Sprintf("/etc/certs/%s/tls.crt", namespace),
+						KeyPath:    fmt.Sprintf("/etc/certs/%s/tls.key", namespace),
+						SecretName: fmt.Sprintf("%s-certs", namespace),
+					},
+				},
+			},
+		}
+	}
+	newGateway := func(namespace string) *v1alpha3.Gateway {
+		return &v1alpha3.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("%s-ingress-gateway", namespace),
+				Namespace: namespace,
+			},
+			Spec: networkv3.Gateway{
+				Selector: map[string]string{"istio": "ingressgateway"},
+			},
+		}
+	}
+
+	// The Gateways of both namespaces select the same gateway Deployment.
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "istio-ingressgateway",
+			Namespace: config.DefaultSecretNamespace,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"istio": "ingressgateway"}},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "istio-proxy", Image: "istio/proxyv2"}},
+				},
+			},
+		},
+	}
+
+	newSecret := func(namespace string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("%s-certs", namespace),
+				Namespace: config.DefaultSecretNamespace,
+			},
+			Data: map[string][]byte{"tls.crt": []byte(cert), "tls.key": []byte(key)},
+		}
+	}
+
+	// Objects to track in the fake client.
+	objs := []runtime.Object{
+		newGatewayService(namespace), newGateway(namespace), newSecret(namespace),
+		newGatewayService(otherNamespace), newGateway(otherNamespace), newSecret(otherNamespace),
+	}
+
+	// List GatewayService objects filtering by labels
+	gatewayservicesList := &appv1alpha1.GatewayServiceList{}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, &v1alpha3.Gateway{}, &appv1alpha1.GatewayService{}, gatewayservicesList)
+
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClient(objs...)
+
+	// Create a ReconcileMemcached object with the scheme and fake client.
+	r := &ReconcileGatewayService{client: cl, scheme: s, kubeClient: k8sfake.NewSimpleClientset(deployment)}
+
+	volumes := func() []string {
+		deployment, err := r.kubeClient.AppsV1().Deployments(config.DefaultSecretNamespace).Get("istio-ingressgateway", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("get Deployment: (%v)", err)
+		}
+		found := []string{}
+		for _, v := range deployment.Spec.Template.Spec.Volumes {
+			found = append(found, v.Secret.SecretName)
+		}
+		sort.Strings(found)
+		return found
+	}
+
+	// Reconciling either namespace keeps the mounts of the other.
+	for _, ns := range []string{namespace, otherNamespace, namespace} {
+		_, err := r.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: ns}})
+		if err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+	}
+	expected := []string{"application-certs", "other-certs"}
+	if found := volumes(); !reflect.DeepEqual(found, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, found)
+	}
+
+	// Deleting the GatewayService of one namespace only removes its own mount.
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: otherNamespace}}
+	gatewayservice := &appv1alpha1.GatewayService{}
+	err := r.client.Get(context.TODO(), req.NamespacedName, gatewayservice)
+	if err != nil {
+		t.Fatalf("get GatewayService: (%v)", err)
+	}
+	deletionTimestamp := metav1.Now()
+	gatewayservice.DeletionTimestamp = &deletionTimestamp
+	err = r.client.Update(context.TODO(), gatewayservice)
+	if err != nil {
+		t.Fatalf("update GatewayService: (%v)", err)
+	}
+	_, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	expected = []string{"application-certs"}
+	if found := volumes(); !reflect.DeepEqual(found, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, found)
+	}
+
+	// A path already mounted for another GatewayService is rejected.
+	conflicting := newGatewayService("third")
+	conflicting.Spec.TLSOptions.TLSSecretPath = newGatewayService(namespace).Spec.TLSOptions.TLSSecretPath
+	err = r.client.Create(context.TODO(), newGateway("third"))
+	if err != nil {
+		t.Fatalf("create Gateway: (%v)", err)
+	}
+	err = r.client.Create(context.TODO(), conflicting)
+	if err != nil {
+		t.Fatalf("create GatewayService: (%v)", err)
+	}
+	req = reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: "third"}}
+	_, err = r.Reconcile(req)
+	if err == nil || !strings.Contains(err.Error(), "already mounted") {
+		t.Fatalf("expected the mountPath to be rejected, found (%v)", err)
+	}
+	err = r.client.Get(context.TODO(), req.NamespacedName, conflicting)
+	if err != nil {
+		t.Fatalf("get GatewayService: (%v)", err)
+	}
+	if !strings.Contains(conflicting.Status.Condition.ErrorMessage, "already mounted") {
+		t.Fatalf("expected the status to report the rejected mountPath, found (%+v)", conflicting.Status.Condition)
+	}
+	if found := volumes(); !reflect.DeepEqual(found, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, found)
+	}
+}
+
+// statusCounter counts the writes to the status subresource.
+type statusCounter struct {
+	client.Client
+	updates int
+}
+
+func (c *statusCounter) Status() client.StatusWriter {
+	return &statusCounterWriter{StatusWriter: c.Client.Status(), counter: c}
+}
+
+type statusCounterWriter struct {
+	client.StatusWriter
+	counter *statusCounter
+}
+
+func (w *statusCounterWriter) Update(ctx context.Context, obj runtime.Object) error {
+	w.counter.updates++
+	return w.StatusWriter.Update(ctx, obj)
+}
+
+func TestStatusUnchanged(t *testing.T) {
+	// A TestGatewayService resource with metadata and spec.
+	gatewayservice := &appv1alpha1.GatewayService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: appv1alpha1.GatewayServiceSpec{
+			Hosts:       []string{"*"},
+			Mode:        "SIMPLE",
+			Port:        443,
+			Protocol:    "HTTPS",
+			TrafficType: "ingress",
+			TLSOptions: &appv1alpha1.TLSOptions{
+				TLSSecret: &appv1alpha1.TLSSecret{
+					Cert: &cert,
+					Key:  &key,
+				},
+			},
+		},
+	}
+
+	gateway := &v1alpha3.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-ingress-gateway", namespace),
+			Namespace: namespace,
+		},
+	}
+
+	// Objects to track in the fake client.
+	objs := []runtime.Object{gatewayservice, gateway}
+
+	// List GatewayService objects filtering by labels
+	gatewayservicesList := &appv1alpha1.GatewayServiceList{}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, gateway, gatewayservice, gatewayservicesList)
+
+	// Create a fake client to mock API calls.
+	cl := &statusCounter{Client: fake.NewFakeClient(objs...)}
+
+	// Create a ReconcileMemcached object with the scheme and fake client.
+	r := &ReconcileGatewayService{client: cl, scheme: s}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if cl.updates != 1 {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", 1, cl.updates)
+	}
+	gatewayservice = &appv1alpha1.GatewayService{}
+	err = r.client.Get(context.TODO(), req.NamespacedName, gatewayservice)
+	if err != nil {
+		t.Fatalf("get GatewayService: (%v)", err)
+	}
+	if !gatewayservice.Status.Condition.Success {
+		t.Fatalf("expected the status to be written, found (%+v)", gatewayservice.Status)
+	}
+
+	// Nothing has changed, the status is not written again.
+	_, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if cl.updates != 1 {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", 1, cl.updates)
+	}
+}
+
+func TestGatewayNotFound(t *testing.T) {
+	// A TestGatewayService resource with metadata and spec.
+	gatewayservice := &appv1alpha1.GatewayService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: appv1alpha1.GatewayServiceSpec{
+			Hosts:       []string{"*"},
+			Mode:        "SIMPLE",
+			Port:        443,
+			Protocol:    "HTTPS",
+			TrafficType: "ingress",
+			TLSOptions: &appv1alpha1.TLSOptions{
+				TLSSecret: &appv1alpha1.TLSSecret{
+					Cert: &cert,
+					Key:  &key,
+				},
+			},
+		},
+	}
+
+	// Objects to track in the fake client.
+	objs := []runtime.Object{gatewayservice}
+
+	// List GatewayService objects filtering by labels
+	gatewayservicesList := &appv1alpha1.GatewayServiceList{}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, &v1alpha3.Gateway{}, gatewayservice, gatewayservicesList)
+
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClient(objs...)
+
+	// Create a ReconcileMemcached object with the scheme and fake client.
+	r := &ReconcileGatewayService{client: cl, scheme: s}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	gatewayservice = &appv1alpha1.GatewayService{}
+	err = r.client.Get(context.TODO(), req.NamespacedName, gatewayservice)
+	if err != nil {
+		t.Fatalf("get GatewayService: (%v)", err)
+	}
+	if gatewayservice.Status.Condition.Success || !strings.HasPrefix(gatewayservice.Status.Condition.ErrorMessage, "GatewayNotFound") {
+		t.Fatalf("expected the status to report GatewayNotFound, found (%+v)", gatewayservice.Status)
+	}
+	condition := status.FindCondition(gatewayservice.Status.Conditions, appv1alpha1.GatewayAvailable)
+	if condition == nil || condition.Reason != "GatewayNotFound" {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", "GatewayNotFound", condition)
+	}
+}
+
+func TestLastKnownGood(t *testing.T) {
+	validSpec := appv1alpha1.GatewayServiceSpec{
+		Hosts:       []string{"*"},
+		Mode:        "SIMPLE",
+		Port:        443,
+		Protocol:    "HTTPS",
+		TrafficType: "ingress",
+		TLSOptions: &appv1alpha1.TLSOptions{
+			TLSSecret: &appv1alpha1.TLSSecret{
+				Cert: &cert,
+				Key:  &key,
+			},
+		},
+	}
+	// A TestGatewayService resource whose latest generation has no TLSOptions.
+	gatewayservice := &appv1alpha1.GatewayService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Namespace:  namespace,
+			Generation: 2,
+			Finalizers: []string{gatewayServiceFinalizer},
+		},
+		Spec: appv1alpha1.GatewayServiceSpec{
+			Hosts:       []string{"*"},
+			Mode:        "SIMPLE",
+			Port:        443,
+			Protocol:    "HTTPS",
+			TrafficType: "ingress",
+		},
+		Status: appv1alpha1.GatewayServiceStatus{
+			ValidGeneration: 1,
+			ValidSpec:       validSpec.DeepCopy(),
+		},
+	}
+
+	// Objects to track in the fake client.
+	objs := []runtime.Object{gatewayservice}
+
+	// List GatewayService objects filtering by labels
+	gatewayservicesList := &appv1alpha1.GatewayServiceList{}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, &v1alpha3.Gateway{}, gatewayservice, gatewayservicesList)
+
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClient(objs...)
+
+	// Create a ReconcileMemcached object with the scheme and fake client.
+	r := &ReconcileGatewayService{client: cl, scheme: s}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	gatewayservice = &appv1alpha1.GatewayService{}
+	err = r.client.Get(context.TODO(), req.NamespacedName, gatewayservice)
+	if err != nil {
+		t.Fatalf("get GatewayService: (%v)", err)
+	}
+	// The last-known-good spec is kept until the spec is fixed.
+	if gatewayservice.Status.ValidGeneration != 1 || !reflect.DeepEqual(gatewayservice.Status.ValidSpec, &validSpec) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", validSpec, gatewayservice.Status.ValidSpec)
+	}
+	condition := status.FindCondition(gatewayservice.Status.Conditions, appv1alpha1.SpecRendered)
+	if condition == nil || condition.Reason != "LastKnownGood" {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", "LastKnownGood", condition)
+	}
+}
+
+func TestLastKnownGoodCredentialFailure(t *testing.T) {
+	validSpec := appv1alpha1.GatewayServiceSpec{
+		Hosts:       []string{"*"},
+		Mode:        "PASSTHROUGH",
+		Port:        443,
+		Protocol:    "HTTPS",
+		TrafficType: "ingress",
+	}
+	// A TestGatewayService resource whose latest generation requires a credential.
+	gatewayservice := &appv1alpha1.GatewayService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Namespace:  namespace,
+			Generation: 2,
+			Finalizers: []string{gatewayServiceFinalizer},
+		},
+		Spec: appv1alpha1.GatewayServiceSpec{
+			Hosts:       []string{"*"},
+			Mode:        "SIMPLE",
+			Port:        443,
+			Protocol:    "HTTPS",
+			TrafficType: "ingress",
+			TLSOptions: &appv1alpha1.TLSOptions{
+				TLSSecret: &appv1alpha1.TLSSecret{
+					Cert: &cert,
+					Key:  &key,
+				},
+			},
+		},
+		Status: appv1alpha1.GatewayServiceStatus{
+			ValidGeneration: 1,
+			ValidSpec:       validSpec.DeepCopy(),
+			Gateway:         g.Name(namespace, "ingress"),
+		},
+	}
+	// A secret of the same name which is not managed by the operator, so the credential cannot be ensured.
+	secretObj := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      names.Secret(name, namespace),
+			Namespace: config.DefaultSecretNamespace,
+		},
+	}
+
+	// Objects to track in the fake client.
+	objs := []runtime.Object{gatewayservice, secretObj}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, &v1alpha3.Gateway{}, gatewayservice)
+
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClient(objs...)
+
+	// Create a ReconcileMemcached object with the scheme and fake client.
+	r := &ReconcileGatewayService{client: cl, scheme: s}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	_, err := r.Reconcile(req)
+	if err == nil {
+		t.Fatal("expected reconcile to fail while the credential cannot be ensured")
+	}
+	gatewayservice = &appv1alpha1.GatewayService{}
+	err = r.client.Get(context.TODO(), req.NamespacedName, gatewayservice)
+	if err != nil {
+		t.Fatalf("get GatewayService: (%v)", err)
+	}
+	// The Gateway keeps rendering the previous spec, whose credential exists.
+	if gatewayservice.Status.ValidGeneration != 1 || !reflect.DeepEqual(gatewayservice.Status.ValidSpec, &validSpec) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", validSpec, gatewayservice.Status.ValidSpec)
+	}
+	if gatewayservice.Status.Condition.Success {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", false, gatewayservice.Status.Condition.Success)
+	}
+}
+
+func TestModeNotSupported(t *testing.T) {
+	// The Gateway CRD of the connected Istio, which does not support AUTO_PASSTHROUGH and OPTIONAL_MUTUAL.
+	crd := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1beta1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   map[string]interface{}{"name": "gateways.networking.istio.io"},
+		"spec": map[string]interface{}{
+			"validation": map[string]interface{}{
+				"openAPIV3Schema": map[string]interface{}{
+					"properties": map[string]interface{}{
+						"spec": map[string]interface{}{
+							"properties": map[string]interface{}{
+								"servers": map[string]interface{}{
+									"items": map[string]interface{}{
+										"properties": map[string]interface{}{
+											"tls": map[string]interface{}{
+												"properties": map[string]interface{}{
+													"mode": map[string]interface{}{
+														"enum": []interface{}{"PASSTHROUGH", "SIMPLE", "MUTUAL", "ISTIO_MUTUAL"},
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}}
+	tests := []struct {
+		mode    string
+		message string
+	}{
+		{mode: "OPTIONAL_MUTUAL", message: "mode OPTIONAL_MUTUAL is not supported by the connected Istio"},
+		{mode: "AUTO_PASSTHROUGH", message: "mode AUTO_PASSTHROUGH is not supported by the connected Istio"},
+	}
+	for _, tt := range tests {
+		// A TestGatewayService resource with metadata and spec.
+		gatewayservice := &appv1alpha1.GatewayService{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       name,
+				Namespace:  namespace,
+				Finalizers: []string{gatewayServiceFinalizer},
+			},
+			Spec: appv1alpha1.GatewayServiceSpec{
+				Hosts:       []string{"*"},
+				Mode:        tt.mode,
+				Port:        443,
+				Protocol:    "TLS",
+				TrafficType: "ingress",
+			},
+		}
+
+		// Objects to track in the fake client.
+		objs := []runtime.Object{gatewayservice}
+
+		// Register operator types with the runtime scheme.
+		s := scheme.Scheme
+		s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, &v1alpha3.Gateway{}, gatewayservice)
+
+		// Create a fake client to mock API calls.
+		cl := fake.NewFakeClient(objs...)
+
+		// Create a ReconcileMemcached object with the scheme and fake client.
+		r := &ReconcileGatewayService{client: cl, scheme: s, dynamicClient: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), crd)}
+
+		req := reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      name,
+				Namespace: namespace,
+			},
+		}
+		res, err := r.Reconcile(req)
+		if err != nil {
+			t.Fatalf("%s: expected an unsupported mode to be a permanent failure (%v)", tt.mode, err)
+		}
+		if res.Requeue || res.RequeueAfter != 0 {
+			t.Errorf("%s: reconcile requeued request for a permanent failure", tt.mode)
+		}
+		gatewayservice = &appv1alpha1.GatewayService{}
+		err = r.client.Get(context.TODO(), req.NamespacedName, gatewayservice)
+		if err != nil {
+			t.Fatalf("get GatewayService: (%v)", err)
+		}
+		if gatewayservice.Status.Condition.Success || !strings.HasPrefix(gatewayservice.Status.Condition.ErrorMessage, tt.message) {
+			t.Fatalf("Expected: (%+v) \n Found: (%+v)", tt.message, gatewayservice.Status.Condition.ErrorMessage)
+		}
+	}
+}
+
+func TestSecretCopiedToGatewayNamespaces(t *testing.T) {
+	// A TestGatewayService resource with metadata and spec.
+	gatewayservice := &appv1alpha1.GatewayService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: appv1alpha1.GatewayServiceSpec{
+			Hosts:       []string{"*"},
+			Mode:        "SIMPLE",
+			Port:        443,
+			Protocol:    "HTTPS",
+			TrafficType: "ingress",
+			TLSOptions: &appv1alpha1.TLSOptions{
+				TLSSecret: &appv1alpha1.TLSSecret{
+					Cert: &cert,
+					Key:  &key,
+				},
+			},
+		},
+	}
+
+	gateway := &v1alpha3.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-ingress-gateway", namespace),
+			Namespace: namespace,
+		},
+		Spec: networkv3.Gateway{
+			Selector: map[string]string{"istio": "ingressgateway"},
+		},
+	}
+
+	// The gateway pods run in dedicated namespaces.
+	newPod := func(podName string, podNamespace string, podLabels map[string]string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: podName, Namespace: podNamespace, Labels: podLabels}}
+	}
+	kubeClient := k8sfake.NewSimpleClientset(
+		newPod("ingressgateway-a", "istio-ingress", map[string]string{"istio": "ingressgateway"}),
+		newPod("ingressgateway-b", "gateways-external", map[string]string{"istio": "ingressgateway"}),
+		newPod("egressgateway", "istio-egress", map[string]string{"istio": "egressgateway"}),
+	)
+
+	// Objects to track in the fake client.
+	objs := []runtime.Object{gatewayservice, gateway}
+
+	// List GatewayService objects filtering by labels
+	gatewayservicesList := &appv1alpha1.GatewayServiceList{}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, gateway, gatewayservice, gatewayservicesList,
+		&appv1alpha1.GatewayServiceOperatorConfig{}, &appv1alpha1.GatewayServiceOperatorConfigList{})
+
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClient(objs...)
+
+	// Create a ReconcileMemcached object with the scheme and fake client.
+	r := &ReconcileGatewayService{client: cl, scheme: s, kubeClient: kubeClient}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	// The secret is created in every namespace serving the Gateway.
+	for _, secretNamespace := range []string{"gateways-external", "istio-ingress"} {
+		secretObj := &corev1.Secret{}
+		err = r.client.Get(context.TODO(), types.NamespacedName{Name: names.Secret(name, namespace), Namespace: secretNamespace}, secretObj)
+		if err != nil {
+			t.Fatalf("get secret in namespace %s: (%v)", secretNamespace, err)
+		}
+		if string(secretObj.Data["tls.crt"]) != cert {
+			t.Fatalf("Expected: (%+v) \n Found: (%+v)", cert, string(secretObj.Data["tls.crt"]))
+		}
+	}
+	gatewayservice = &appv1alpha1.GatewayService{}
+	err = r.client.Get(context.TODO(), req.NamespacedName, gatewayservice)
+	if err != nil {
+		t.Fatalf("get GatewayService: (%v)", err)
+	}
+	expected := []string{"gateways-external", "istio-ingress"}
+	if !reflect.DeepEqual(gatewayservice.Status.Condition.CreatedSecretDetails.SecretNamespaces, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, gatewayservice.Status.Condition.CreatedSecretDetails.SecretNamespaces)
+	}
+
+	// The configured namespaces take precedence, the copy no longer required is removed.
+	operatorConfig := &appv1alpha1.GatewayServiceOperatorConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: config.Name},
+		Spec: appv1alpha1.GatewayServiceOperatorConfigSpec{
+			TrafficClasses: []appv1alpha1.TrafficClass{{Name: "ingress", GatewayNamespaces: []string{"istio-ingress"}}},
+		},
+	}
+	err = r.client.Create(context.TODO(), operatorConfig)
+	if err != nil {
+		t.Fatalf("create GatewayServiceOperatorConfig: (%v)", err)
+	}
+	_, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: names.Secret(name, namespace), Namespace: "istio-ingress"}, &corev1.Secret{})
+	if err != nil {
+		t.Fatalf("get secret in namespace istio-ingress: (%v)", err)
+	}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: names.Secret(name, namespace), Namespace: "gateways-external"}, &corev1.Secret{})
+	if !errors.IsNotFound(err) {
+		t.Fatalf("expected the secret in namespace gateways-external to be removed: (%v)", err)
+	}
+}
+
+func TestOperatorConfigApplied(t *testing.T) {
+	gatewayservice := &appv1alpha1.GatewayService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: appv1alpha1.GatewayServiceSpec{
+			Hosts:       []string{"*"},
+			Mode:        "PASSTHROUGH",
+			Port:        443,
+			Protocol:    "TLS",
+			TrafficType: "ingress",
+		},
+	}
+	// The Gateway is named by the template of the operator config.
+	gateway := &v1alpha3.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ingress",
+			Namespace: namespace,
+		},
+	}
+	operatorConfig := &appv1alpha1.GatewayServiceOperatorConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: config.Name,
+		},
+		Spec: appv1alpha1.GatewayServiceOperatorConfigSpec{
+			GatewayNameTemplate: "{{.TrafficType}}",
+			AllowedModes:        []string{"SIMPLE"},
+			ResyncPeriod:        "5m",
+		},
+	}
+
+	// Objects to track in the fake client.
+	objs := []runtime.Object{gatewayservice, gateway, operatorConfig}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, gateway, gatewayservice, &appv1alpha1.GatewayServiceList{},
+		operatorConfig, &appv1alpha1.GatewayServiceOperatorConfigList{})
+
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClient(objs...)
+
+	// Create a ReconcileMemcached object with the scheme and fake client.
+	r := &ReconcileGatewayService{client: cl, scheme: s}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	// Every GatewayService is requeued when the operator config changes.
+	requests := configRequests(cl)(handler.MapObject{Meta: operatorConfig, Object: operatorConfig})
+	if !reflect.DeepEqual(requests, []reconcile.Request{req}) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", []reconcile.Request{req}, requests)
+	}
+
+	// The mode is not allowed by the operator config.
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	found := &appv1alpha1.GatewayService{}
+	err = r.client.Get(context.TODO(), req.NamespacedName, found)
+	if err != nil {
+		t.Fatalf("get GatewayService: (%v)", err)
+	}
+	expected := "mode PASSTHROUGH is not allowed by the operator config, allowed modes are SIMPLE"
+	if found.Status.Condition.Success || found.Status.Condition.ErrorMessage != expected {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, found.Status.Condition)
+	}
+
+	// The updated operator config is applied on the next reconcile.
+	operatorConfig.Spec.AllowedModes = append(operatorConfig.Spec.AllowedModes, "PASSTHROUGH")
+	err = r.client.Update(context.TODO(), operatorConfig)
+	if err != nil {
+		t.Fatalf("update GatewayServiceOperatorConfig: (%v)", err)
+	}
+	res, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	if res.RequeueAfter <= 0 || res.RequeueAfter > 5*time.Minute {
+		t.Errorf("expected reconcile to be scheduled within the resyncPeriod, found (%v)", res.RequeueAfter)
+	}
+	err = r.client.Get(context.TODO(), req.NamespacedName, found)
+	if err != nil {
+		t.Fatalf("get GatewayService: (%v)", err)
+	}
+	if !found.Status.Condition.Success {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", "success", found.Status.Condition)
+	}
+	available := status.FindCondition(found.Status.Conditions, appv1alpha1.GatewayAvailable)
+	if available == nil || available.Status != corev1.ConditionTrue {
+		t.Fatalf("expected the gateway named by the template to be available, found (%+v)", available)
+	}
+
+	// An invalid operator config leaves the last valid config applied.
+	operatorConfig.Spec.ResyncPeriod = "soon"
+	err = r.client.Update(context.TODO(), operatorConfig)
+	if err != nil {
+		t.Fatalf("update GatewayServiceOperatorConfig: (%v)", err)
+	}
+	if d := r.operatorConfig().ResyncPeriod; d != 5*time.Minute {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", 5*time.Minute, d)
+	}
+
+	// Removing the operator config restores the defaults.
+	err = r.client.Delete(context.TODO(), operatorConfig)
+	if err != nil {
+		t.Fatalf("delete GatewayServiceOperatorConfig: (%v)", err)
+	}
+	if c := r.operatorConfig(); !reflect.DeepEqual(c, config.Defaults()) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", config.Defaults(), c)
+	}
+}
+
+func TestHostTemplates(t *testing.T) {
+	gatewayservice := &appv1alpha1.GatewayService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: appv1alpha1.GatewayServiceSpec{
+			Hosts:       []string{"{{.Labels.team}}.{{.Cluster}}.{{.Domain}}", "www.example.com"},
+			Mode:        "PASSTHROUGH",
+			Port:        443,
+			Protocol:    "TLS",
+			TrafficType: "ingress",
+		},
+	}
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   namespace,
+			Labels: map[string]string{"team": "payments"},
+		},
+	}
+	operatorConfig := &appv1alpha1.GatewayServiceOperatorConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: config.Name,
+		},
+		Spec: appv1alpha1.GatewayServiceOperatorConfigSpec{
+			Domain:  "example.org",
+			Cluster: "east",
+		},
+	}
+
+	// Objects to track in the fake client.
+	objs := []runtime.Object{gatewayservice, ns, operatorConfig}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, gatewayservice, &appv1alpha1.GatewayServiceList{},
+		operatorConfig, &appv1alpha1.GatewayServiceOperatorConfigList{})
+
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClient(objs...)
+
+	// Create a ReconcileMemcached object with the scheme and fake client.
+	r := &ReconcileGatewayService{client: cl, scheme: s}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	// Every GatewayService within the namespace is requeued when its labels change.
+	requests := namespaceRequests(cl)(handler.MapObject{Meta: ns, Object: ns})
+	if !reflect.DeepEqual(requests, []reconcile.Request{req}) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", []reconcile.Request{req}, requests)
+	}
+
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	found := &appv1alpha1.GatewayService{}
+	err = r.client.Get(context.TODO(), req.NamespacedName, found)
+	if err != nil {
+		t.Fatalf("get GatewayService: (%v)", err)
+	}
+	expected := []string{"payments.east.example.org", "www.example.com"}
+	if !reflect.DeepEqual(found.Status.Hosts, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, found.Status.Hosts)
+	}
+
+	// A host referring to a label the namespace no longer has fails validation, the hosts last expanded remain.
+	ns.Labels = nil
+	err = r.client.Update(context.TODO(), ns)
+	if err != nil {
+		t.Fatalf("update Namespace: (%v)", err)
+	}
+	_, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	err = r.client.Get(context.TODO(), req.NamespacedName, found)
+	if err != nil {
+		t.Fatalf("get GatewayService: (%v)", err)
+	}
+	if found.Status.Condition.Success || !strings.HasPrefix(found.Status.Condition.ErrorMessage, `host "{{.Labels.team}}.{{.Cluster}}.{{.Domain}}" cannot be expanded`) {
+		t.Fatalf("expected the host to fail expansion, found (%+v)", found.Status.Condition)
+	}
+	if !reflect.DeepEqual(found.Status.Hosts, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, found.Status.Hosts)
+	}
+}
+
+func TestHostsNormalized(t *testing.T) {
+	gatewayservice := &appv1alpha1.GatewayService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: appv1alpha1.GatewayServiceSpec{
+			Hosts:       []string{"WWW.Example.com.", "www.example.com", "shared/api.example.com"},
+			Mode:        "PASSTHROUGH",
+			Port:        443,
+			Protocol:    "TLS",
+			TrafficType: "ingress",
+		},
+	}
+	operatorConfig := &appv1alpha1.GatewayServiceOperatorConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: config.Name,
+		},
+	}
+
+	// Objects to track in the fake client.
+	objs := []runtime.Object{gatewayservice, operatorConfig}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, gatewayservice, &appv1alpha1.GatewayServiceList{},
+		operatorConfig, &appv1alpha1.GatewayServiceOperatorConfigList{})
+
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClient(objs...)
+
+	// Create a ReconcileMemcached object with the scheme and fake client.
+	r := &ReconcileGatewayService{client: cl, scheme: s}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	// Hosts may only name the namespace of the GatewayService unless the operator config allows others.
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	found := &appv1alpha1.GatewayService{}
+	err = r.client.Get(context.TODO(), req.NamespacedName, found)
+	if err != nil {
+		t.Fatalf("get GatewayService: (%v)", err)
+	}
+	expectedErr := "host shared/api.example.com names namespace shared, which is not allowed by the operator config"
+	if found.Status.Condition.Success || found.Status.Condition.ErrorMessage != expectedErr {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expectedErr, found.Status.Condition)
+	}
+
+	operatorConfig.Spec.AllowedHostNamespaces = []string{"shared"}
+	err = r.client.Update(context.TODO(), operatorConfig)
+	if err != nil {
+		t.Fatalf("update GatewayServiceOperatorConfig: (%v)", err)
+	}
+	_, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	err = r.client.Get(context.TODO(), req.NamespacedName, found)
+	if err != nil {
+		t.Fatalf("get GatewayService: (%v)", err)
+	}
+	expected := []string{"www.example.com", "shared/api.example.com"}
+	if !found.Status.Condition.Success || !reflect.DeepEqual(found.Status.Hosts, expected) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, found.Status)
+	}
+}
+
+func TestLegacySecretName(t *testing.T) {
+	// A GatewayService reconciled before secret names were made unique.
+	gatewayservice := &appv1alpha1.GatewayService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Namespace:  namespace,
+			Finalizers: []string{gatewayServiceFinalizer},
+		},
+		Spec: appv1alpha1.GatewayServiceSpec{
+			Hosts:       []string{"*"},
+			Mode:        "SIMPLE",
+			Port:        443,
+			Protocol:    "HTTPS",
+			TrafficType: "ingress",
+			TLSOptions: &appv1alpha1.TLSOptions{
+				TLSSecret: &appv1alpha1.TLSSecret{
+					Cert: &cert,
+					Key:  &key,
+				},
+			},
+		},
+		Status: appv1alpha1.GatewayServiceStatus{
+			Condition: appv1alpha1.Condition{
+				Success: true,
+				CreatedSecretDetails: appv1alpha1.CreatedSecretDetails{
+					SecretName: names.LegacySecret(name, namespace),
+				},
+			},
+		},
+	}
+	legacySecretObj := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      names.LegacySecret(name, namespace),
+			Namespace: config.DefaultSecretNamespace,
+			Labels:    secret.Labels(gatewayservice),
+		},
+	}
+	legacySecretObj.Labels[secret.ProviderLabel] = "tlsSecret"
+
+	// Objects to track in the fake client.
+	objs := []runtime.Object{gatewayservice, legacySecretObj}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, gatewayservice, &appv1alpha1.GatewayServiceList{})
+
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClient(objs...)
+
+	// Create a ReconcileMemcached object with the scheme and fake client.
+	r := &ReconcileGatewayService{client: cl, scheme: s}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+
+	// The legacy secret is kept rather than replaced by a secret with the unique name.
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: legacySecretObj.Name, Namespace: legacySecretObj.Namespace}, &corev1.Secret{})
+	if err != nil {
+		t.Fatalf("expected secret %s/%s to be kept: (%v)", legacySecretObj.Namespace, legacySecretObj.Name, err)
+	}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: names.Secret(name, namespace), Namespace: config.DefaultSecretNamespace}, &corev1.Secret{})
+	if !errors.IsNotFound(err) {
+		t.Fatalf("expected no secret with the unique name to be created: (%v)", err)
+	}
+	found := &appv1alpha1.GatewayService{}
+	err = r.client.Get(context.TODO(), req.NamespacedName, found)
+	if err != nil {
+		t.Fatalf("get GatewayService: (%v)", err)
+	}
+	if found.Status.Condition.CreatedSecretDetails.SecretName != legacySecretObj.Name {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", legacySecretObj.Name, found.Status.Condition.CreatedSecretDetails.SecretName)
+	}
+}
+
+func TestTrafficClasses(t *testing.T) {
+	gatewayservice := &appv1alpha1.GatewayService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: appv1alpha1.GatewayServiceSpec{
+			Hosts:       []string{"*"},
+			Mode:        "SIMPLE",
+			Port:        443,
+			Protocol:    "HTTPS",
+			TrafficType: "partner",
+			TLSOptions: &appv1alpha1.TLSOptions{
+				TLSSecret: &appv1alpha1.TLSSecret{
+					Cert: &cert,
+					Key:  &key,
+				},
+			},
+		},
+	}
+	// The Gateway is named by the template of the traffic class.
+	gateway := &v1alpha3.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("partner-%s", namespace),
+			Namespace: namespace,
+		},
+	}
+	operatorConfig := &appv1alpha1.GatewayServiceOperatorConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: config.Name,
+		},
+	}
+
+	// Objects to track in the fake client.
+	objs := []runtime.Object{gatewayservice, gateway, operatorConfig}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, gateway, gatewayservice, &appv1alpha1.GatewayServiceList{},
+		operatorConfig, &appv1alpha1.GatewayServiceOperatorConfigList{})
+
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClient(objs...)
+
+	// Create a ReconcileMemcached object with the scheme and fake client.
+	r := &ReconcileGatewayService{client: cl, scheme: s}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+
+	// The trafficType is not declared by the operator config.
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	found := &appv1alpha1.GatewayService{}
+	err = r.client.Get(context.TODO(), req.NamespacedName, found)
+	if err != nil {
+		t.Fatalf("get GatewayService: (%v)", err)
+	}
+	expected := `trafficType "partner" is not declared by the operator config, trafficTypes are ingress, egress`
+	if found.Status.Condition.Success || found.Status.Condition.ErrorMessage != expected {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", expected, found.Status.Condition)
+	}
+
+	operatorConfig.Spec.TrafficClasses = []appv1alpha1.TrafficClass{
+		{Name: "ingress"},
+		{Name: "partner", GatewayNameTemplate: "{{.TrafficType}}-{{.Namespace}}", SecretNamespace: "partner-system"},
+	}
+	err = r.client.Update(context.TODO(), operatorConfig)
+	if err != nil {
+		t.Fatalf("update GatewayServiceOperatorConfig: (%v)", err)
+	}
+	_, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	err = r.client.Get(context.TODO(), req.NamespacedName, found)
+	if err != nil {
+		t.Fatalf("get GatewayService: (%v)", err)
+	}
+	if !found.Status.Condition.Success || found.Status.Condition.CreatedSecretDetails.SecretNamespace != "partner-system" {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", "partner-system", found.Status.Condition)
+	}
+	secretKey := types.NamespacedName{Name: names.Secret(name, namespace), Namespace: "partner-system"}
+	err = r.client.Get(context.TODO(), secretKey, &corev1.Secret{})
+	if err != nil {
+		t.Fatalf("get Secret: (%v)", err)
+	}
+	if found.Status.Gateway != gateway.Name {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", gateway.Name, found.Status.Gateway)
+	}
+
+	// The Gateway controller renders the GatewayServices recorded as rendered by the Gateway.
+	gatewayKey := types.NamespacedName{Name: gateway.Name, Namespace: namespace}
+	err = gatewaycontroller.Render(gatewaycontroller.RenderConfig{Client: r.client, Config: r.operatorConfig()}, gatewayKey)
+	if err != nil {
+		t.Fatalf("render Gateway: (%v)", err)
+	}
+	err = r.client.Get(context.TODO(), gatewayKey, gateway)
+	if err != nil {
+		t.Fatalf("get Gateway: (%v)", err)
+	}
+	if len(gateway.Spec.Servers) != 1 || gateway.Spec.Servers[0].Port.Name != names.Port("HTTPS", name, namespace) {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", names.Port("HTTPS", name, namespace), gateway.Spec.Servers)
+	}
+
+	// Deletion removes the server from the Gateway of the traffic class and the secret from its namespace.
+	deletionTimestamp := metav1.Now()
+	found.DeletionTimestamp = &deletionTimestamp
+	err = r.client.Update(context.TODO(), found)
+	if err != nil {
+		t.Fatalf("update GatewayService: (%v)", err)
+	}
+	_, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	err = r.client.Get(context.TODO(), secretKey, &corev1.Secret{})
+	if !errors.IsNotFound(err) {
+		t.Errorf("expected secret %s to be deleted: (%v)", secretKey, err)
+	}
+	err = r.client.Get(context.TODO(), gatewayKey, gateway)
+	if err != nil {
+		t.Fatalf("get Gateway: (%v)", err)
+	}
+	for _, server := range gateway.Spec.Servers {
+		if server.Port.Name == names.Port("HTTPS", name, namespace) {
+			t.Errorf("expected server %s to be removed from the Gateway", server.Port.Name)
+		}
+	}
+}
+
+func TestFinalizerUndeclaredTrafficType(t *testing.T) {
+	deletionTimestamp := metav1.Now()
+	spec := appv1alpha1.GatewayServiceSpec{
+		Hosts:       []string{"*"},
+		Mode:        "SIMPLE",
+		Port:        443,
+		Protocol:    "HTTPS",
+		TrafficType: "partner",
+		TLSOptions: &appv1alpha1.TLSOptions{
+			TLSSecret: &appv1alpha1.TLSSecret{
+				Cert: &cert,
+				Key:  &key,
+			},
+		},
+	}
+	// A GatewayService pending deletion which targets a traffic class the operator config no longer declares, rendered
+	// by the Gateway named by the gatewayNameTemplate of the class.
+	gatewayservice := &appv1alpha1.GatewayService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         namespace,
+			Generation:        1,
+			DeletionTimestamp: &deletionTimestamp,
+			Finalizers:        []string{gatewayServiceFinalizer},
+		},
+		Spec: spec,
+		Status: appv1alpha1.GatewayServiceStatus{
+			ValidGeneration: 1,
+			ValidSpec:       spec.DeepCopy(),
+			Gateway:         fmt.Sprintf("partner-%s", namespace),
+		},
+	}
+	portName := names.Port("HTTPS", name, namespace)
+	gateway := &v1alpha3.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("partner-%s", namespace),
+			Namespace:   namespace,
+			Annotations: map[string]string{g.ManagedServersAnnotation: portName},
+		},
+		Spec: networkv3.Gateway{
+			Servers: []*networkv3.Server{
+				{
+					Port: &networkv3.Port{
+						Name:     portName,
+						Number:   443,
+						Protocol: "HTTPS",
+					},
+					Hosts: []string{"*"},
+					Tls: &networkv3.Server_TLSOptions{
+						Mode:           networkv3.Server_TLSOptions_SIMPLE,
+						CredentialName: names.Secret(name, namespace),
+					},
+				},
+			},
+		},
+	}
+	secretObj := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      names.Secret(name, namespace),
+			Namespace: "partner-system",
+			Labels:    secret.Labels(gatewayservice),
+		},
+	}
+
+	// Objects to track in the fake client.
+	objs := []runtime.Object{gatewayservice, gateway, secretObj}
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, gateway, gatewayservice, &appv1alpha1.GatewayServiceList{})
+
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClient(objs...)
+
+	// Create a ReconcileMemcached object with the scheme and fake client.
+	r := &ReconcileGatewayService{client: cl, scheme: s}
+
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("reconcile: (%v)", err)
+	}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: secretObj.Name, Namespace: secretObj.Namespace}, &corev1.Secret{})
+	if !errors.IsNotFound(err) {
+		t.Errorf("expected secret %s/%s to be deleted: (%v)", secretObj.Namespace, secretObj.Name, err)
+	}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: gateway.Name, Namespace: namespace}, gateway)
+	if err != nil {
+		t.Fatalf("get Gateway: (%v)", err)
+	}
+	for _, server := range gateway.Spec.Servers {
+		if server.Port.Name == portName {
+			t.Errorf("expected server %s to be removed from the Gateway", server.Port.Name)
+		}
+	}
+}
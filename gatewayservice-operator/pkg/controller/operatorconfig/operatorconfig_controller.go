@@ -0,0 +1,130 @@
+package operatorconfig
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/config"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/status"
+
+	appv1alpha1 "github.com/xunholy/k8s-istio-gateway-service-operator/pkg/apis/crd/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var (
+	// blank assignment to verify that ReconcileOperatorConfig implements reconcile.Reconciler
+	_   reconcile.Reconciler = &ReconcileOperatorConfig{}
+	log                      = logf.Log.WithName("controller_operatorconfig")
+)
+
+// ReconcileOperatorConfig reports whether a GatewayServiceOperatorConfig is valid and applied within its status. The
+// config itself is applied by the other controllers, which read it on every reconcile.
+type ReconcileOperatorConfig struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	client client.Client
+}
+
+// Add creates a new GatewayServiceOperatorConfig Controller and adds it to the Manager. The Manager will set fields on
+// the Controller and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileOperatorConfig{client: mgr.GetClient()}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New("operatorconfig-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to primary resource GatewayServiceOperatorConfig
+	err = c.Watch(&source.Kind{Type: &appv1alpha1.GatewayServiceOperatorConfig{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// +kubebuilder:rbac:groups=crd.xunholy.github.com,resources=gatewayserviceoperatorconfigs,verbs=get;list;watch;update
+// +kubebuilder:rbac:groups=crd.xunholy.github.com,resources=gatewayserviceoperatorconfigs/status,verbs=update
+
+// Reconcile validates the GatewayServiceOperatorConfig and sets its Valid condition.
+func (r *ReconcileOperatorConfig) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	logger := log.WithValues("Request.Name", request.Name)
+	logger.Info("Reconciling GatewayServiceOperatorConfig")
+	operatorConfig := &appv1alpha1.GatewayServiceOperatorConfig{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, operatorConfig)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// The defaults are applied once the config has been removed.
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	condition := appv1alpha1.GatewayServiceCondition{
+		Type:    appv1alpha1.ConfigValid,
+		Status:  corev1.ConditionTrue,
+		Reason:  "Applied",
+		Message: fmt.Sprintf("generation %d is applied", operatorConfig.Generation),
+	}
+	if operatorConfig.Name != config.Name {
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = "Ignored"
+		condition.Message = fmt.Sprintf("only the GatewayServiceOperatorConfig named %s is applied", config.Name)
+	} else if _, err := config.Parse(operatorConfig.Spec, config.Defaults()); err != nil {
+		logger.Info("Invalid GatewayServiceOperatorConfig, the last valid config remains applied", "error", err.Error())
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = "Invalid"
+		condition.Message = fmt.Sprintf("the last valid config remains applied until the spec is fixed: %v", err)
+	}
+
+	err = r.ReconcileStatus(request, operatorConfig, condition)
+	if err != nil {
+		logger.Error(err, "Failed to update GatewayServiceOperatorConfig status")
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{}, nil
+}
+
+// ReconcileStatus records the condition and the generation it was observed for, skipping writes which would not change
+// the status.
+func (r *ReconcileOperatorConfig) ReconcileStatus(request reconcile.Request, operatorConfig *appv1alpha1.GatewayServiceOperatorConfig, condition appv1alpha1.GatewayServiceCondition) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &appv1alpha1.GatewayServiceOperatorConfig{}
+		err := r.client.Get(context.TODO(), request.NamespacedName, latest)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		desired := latest.Status.DeepCopy()
+		desired.ObservedGeneration = operatorConfig.Generation
+		desired.Conditions = status.SetCondition(desired.Conditions, condition)
+		if equality.Semantic.DeepEqual(&latest.Status, desired) {
+			return nil
+		}
+		latest.Status = *desired
+		return r.client.Status().Update(context.TODO(), latest)
+	})
+}
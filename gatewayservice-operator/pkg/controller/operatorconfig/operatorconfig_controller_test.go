@@ -0,0 +1,71 @@
+package operatorconfig
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/config"
+	"github.com/xunholy/k8s-istio-gateway-service-operator/internal/pkg/status"
+	appv1alpha1 "github.com/xunholy/k8s-istio-gateway-service-operator/pkg/apis/crd/v1alpha1"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestOperatorConfigStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     appv1alpha1.GatewayServiceOperatorConfigSpec
+		expected corev1.ConditionStatus
+		reason   string
+	}{
+		{name: config.Name, spec: appv1alpha1.GatewayServiceOperatorConfigSpec{ResyncPeriod: "30m"}, expected: corev1.ConditionTrue, reason: "Applied"},
+		{name: config.Name, spec: appv1alpha1.GatewayServiceOperatorConfigSpec{ResyncPeriod: "soon"}, expected: corev1.ConditionFalse, reason: "Invalid"},
+		{name: "other", spec: appv1alpha1.GatewayServiceOperatorConfigSpec{}, expected: corev1.ConditionFalse, reason: "Ignored"},
+	}
+	for _, test := range tests {
+		operatorConfig := &appv1alpha1.GatewayServiceOperatorConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       test.name,
+				Generation: 2,
+			},
+			Spec: test.spec,
+		}
+
+		// Objects to track in the fake client.
+		objs := []runtime.Object{operatorConfig}
+
+		// Register operator types with the runtime scheme.
+		s := scheme.Scheme
+		s.AddKnownTypes(appv1alpha1.SchemeGroupVersion, operatorConfig, &appv1alpha1.GatewayServiceOperatorConfigList{})
+
+		// Create a fake client to mock API calls.
+		cl := fake.NewFakeClient(objs...)
+
+		// Create a ReconcileOperatorConfig object with the fake client.
+		r := &ReconcileOperatorConfig{client: cl}
+
+		req := reconcile.Request{NamespacedName: types.NamespacedName{Name: test.name}}
+		_, err := r.Reconcile(req)
+		if err != nil {
+			t.Fatalf("reconcile: (%v)", err)
+		}
+		found := &appv1alpha1.GatewayServiceOperatorConfig{}
+		err = r.client.Get(context.TODO(), req.NamespacedName, found)
+		if err != nil {
+			t.Fatalf("get GatewayServiceOperatorConfig: (%v)", err)
+		}
+		condition := status.FindCondition(found.Status.Conditions, appv1alpha1.ConfigValid)
+		if condition == nil || condition.Status != test.expected || condition.Reason != test.reason {
+			t.Fatalf("Expected: (%+v %+v) \n Found: (%+v)", test.expected, test.reason, condition)
+		}
+		if found.Status.ObservedGeneration != 2 {
+			t.Fatalf("Expected: (%+v) \n Found: (%+v)", 2, found.Status.ObservedGeneration)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package controller_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// rbacMarker matches the permissions declared by the controllers above their Reconcile functions.
+var rbacMarker = regexp.MustCompile(`(?m)^// \+kubebuilder:rbac:groups=([^,]*),resources=([^,]*),verbs=(\S*)$`)
+
+// permission is a verb on a resource which a controller uses.
+type permission struct {
+	source   string
+	group    string
+	resource string
+	verb     string
+}
+
+// controllerPermissions returns the permissions declared by every controller.
+func controllerPermissions(t *testing.T) []permission {
+	permissions := []permission{}
+	err := filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return err
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, match := range rbacMarker.FindAllStringSubmatch(string(data), -1) {
+			group := match[1]
+			if group == "core" {
+				group = ""
+			}
+			for _, resource := range strings.Split(match[2], ";") {
+				for _, verb := range strings.Split(match[3], ";") {
+					permissions = append(permissions, permission{source: path, group: group, resource: resource, verb: verb})
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("read controllers: (%v)", err)
+	}
+	return permissions
+}
+
+// decodeManifest decodes the manifest deployed with the operator into obj.
+func decodeManifest(t *testing.T, manifest string, obj interface{}) {
+	f, err := os.Open(filepath.Join("..", "..", "deploy", manifest))
+	if err != nil {
+		t.Fatalf("open manifest: (%v)", err)
+	}
+	defer f.Close()
+	err = yaml.NewYAMLOrJSONDecoder(f, 4096).Decode(obj)
+	if err != nil {
+		t.Fatalf("decode manifest %s: (%v)", manifest, err)
+	}
+}
+
+// clusterRoleRules returns the rules of the ClusterRole deployed with the operator. The Role only grants access within
+// the namespace of the operator, so it is kept apart.
+func clusterRoleRules(t *testing.T) []rbacv1.PolicyRule {
+	role := rbacv1.ClusterRole{}
+	decodeManifest(t, "cluster_role.yaml", &role)
+	if role.Kind != "ClusterRole" {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", "ClusterRole", role.Kind)
+	}
+	return role.Rules
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value || v == rbacv1.ResourceAll {
+			return true
+		}
+	}
+	return false
+}
+
+func TestManifestsGrantControllerPermissions(t *testing.T) {
+	permissions := controllerPermissions(t)
+	if len(permissions) == 0 {
+		t.Fatal("expected the controllers to declare their permissions")
+	}
+	// The operator watches every namespace, and the operator config and namespaces are cluster-scoped, so every
+	// permission of the controllers must be granted by the ClusterRole.
+	rules := clusterRoleRules(t)
+	for _, p := range permissions {
+		granted := false
+		for _, rule := range rules {
+			if contains(rule.APIGroups, p.group) && contains(rule.Resources, p.resource) && contains(rule.Verbs, p.verb) {
+				granted = true
+				break
+			}
+		}
+		if !granted {
+			t.Errorf("expected the ClusterRole to grant %s on %s in group %q, used by %s", p.verb, p.resource, p.group, p.source)
+		}
+	}
+}
+
+func TestOperatorWatchesEveryNamespace(t *testing.T) {
+	role := rbacv1.Role{}
+	decodeManifest(t, "role.yaml", &role)
+	if role.Kind != "Role" {
+		t.Fatalf("Expected: (%+v) \n Found: (%+v)", "Role", role.Kind)
+	}
+	deployment := appsv1.Deployment{}
+	decodeManifest(t, "operator.yaml", &deployment)
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		for _, env := range container.Env {
+			if env.Name == "WATCH_NAMESPACE" && (env.Value != "" || env.ValueFrom != nil) {
+				t.Fatalf("expected WATCH_NAMESPACE to be empty so every namespace is watched, found (%+v)", env)
+			}
+		}
+	}
+}